@@ -21,19 +21,26 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/openclarity/functionclarity/pkg/utils"
 	"io"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/openclarity/functionclarity/pkg/clients"
 	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	opts "github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/policy"
+	"github.com/openclarity/functionclarity/pkg/scan"
 	"github.com/openclarity/functionclarity/pkg/verify"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"gopkg.in/yaml.v3"
@@ -42,13 +49,27 @@ import (
 type RequestParameters struct {
 	FunctionName                 string `json:"functionName"`
 	ReservedConcurrentExecutions int    `json:"reservedConcurrentExecutions"`
+	// Layers, Runtime, ImageUri and ImageConfig are set by configuration updates that change the verified function
+	Layers      *[]string       `json:"layers"`
+	Runtime     string          `json:"runtime"`
+	ImageUri    string          `json:"imageUri"`
+	ImageConfig json.RawMessage `json:"imageConfig"`
+}
+
+type ResponseElements struct {
+	// FunctionArn is the qualified ARN of a published version
+	FunctionArn     string `json:"functionArn"`
+	LayerVersionArn string `json:"layerVersionArn"`
 }
 
 type RecordMessage struct {
-	AwsRegion         string            `json:"awsRegion"`
-	EventSource       string            `json:"eventSource"`
-	EventName         string            `json:"eventName"`
-	RequestParameters RequestParameters `json:"requestParameters"`
+	AwsRegion string `json:"awsRegion"`
+	// RecipientAccountId is the account of the function, events of an organization trail come from all the accounts
+	RecipientAccountId string            `json:"recipientAccountId"`
+	EventSource        string            `json:"eventSource"`
+	EventName          string            `json:"eventName"`
+	RequestParameters  RequestParameters `json:"requestParameters"`
+	ResponseElements   ResponseElements  `json:"responseElements"`
 }
 
 type Record struct {
@@ -56,6 +77,21 @@ type Record struct {
 	Id      string `json:"id"`
 }
 
+// ScheduledVerification is the event the verifier invokes itself with for every account and region on a scheduled
+// event, so each of them is re-verified by its own invocation, within its own time limit.
+type ScheduledVerification struct {
+	// Account is the account of the functions, empty for the account of the verifier
+	Account string `json:"account"`
+	Region  string `json:"region"`
+	// After is the ARN of the last function verified by the previous invocation, when the functions of the account and
+	// region didn't fit the time limit of a single invocation
+	After string `json:"after,omitempty"`
+}
+
+type scheduledVerificationEvent struct {
+	ScheduledVerification *ScheduledVerification `json:"scheduledVerification"`
+}
+
 type FilterRecord struct {
 	LogEvents   []Record `json:"logEvents"`
 	MessageType string   `json:"messageType"`
@@ -63,63 +99,432 @@ type FilterRecord struct {
 
 var config *i.AWSInput = nil
 
-func HandleRequest(context context.Context, cloudWatchEvent events.CloudwatchLogsEvent) error {
+var verificationPolicy *policy.Policy = nil
+
+// scheduledVerificationConcurrency is the number of functions verified at the same time on a scheduled event, each
+// verification downloads the function code to the ephemeral storage of the verifier.
+const scheduledVerificationConcurrency = 4
+
+// scheduledVerificationMargin is the time left to an invocation when it stops starting verifications, so the running
+// ones end and the rest of the functions are handed to the next invocation before the time limit.
+const scheduledVerificationMargin = 3 * time.Minute
+
+func HandleRequest(context context.Context, event json.RawMessage) error {
+	if scheduledVerification := parseScheduledVerification(event); scheduledVerification != nil {
+		return handleScheduledVerification(context, *scheduledVerification)
+	}
+	eventBridgeEvent := events.CloudWatchEvent{}
+	if err := json.Unmarshal(event, &eventBridgeEvent); err == nil {
+		if eventBridgeEvent.Source == "aws.events" && eventBridgeEvent.DetailType == "Scheduled Event" {
+			return handleScheduledEvent(context)
+		}
+		if eventBridgeEvent.Source == "aws.lambda" && eventBridgeEvent.DetailType == "AWS API Call via CloudTrail" {
+			return handleApiCallEvent(context, eventBridgeEvent)
+		}
+	}
+	cloudWatchEvent := events.CloudwatchLogsEvent{}
+	if err := json.Unmarshal(event, &cloudWatchEvent); err != nil {
+		return fmt.Errorf("failed to parse event: %w", err)
+	}
 	filterRecord, err := extractDataFromEvent(cloudWatchEvent)
 	if err != nil {
 		log.Printf("Failed to extract data from event: %v", err)
 		return fmt.Errorf("failed to extract data from event: %w", err)
 	}
-	recordMessage := RecordMessage{}
 	logEvents := filterRecord.LogEvents
 	log.Printf("logEvents: %s", logEvents)
-	if config == nil {
-		err := initConfig()
-		if err != nil {
-			return err
-		}
-	}
-	log.Printf("creating folder: %s", utils.FunctionClarityHomeDir)
-	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+	if err := prepareVerifier(); err != nil {
 		return err
 	}
 	for logEvent := range logEvents {
+		// the fields missing from a message must not be taken from the previous one
+		recordMessage := RecordMessage{}
 		err = json.Unmarshal([]byte(logEvents[logEvent].Message), &recordMessage)
 		if err != nil {
 			log.Printf("failed to extract message from event, skipping message. %s", logEvents[logEvent].Message)
 			continue
 		}
-		if shouldHandleEvent(recordMessage) {
-			log.Printf("handling function name: %s, event name: %s, event source: %s, region: %s\n", recordMessage.RequestParameters.FunctionName, recordMessage.EventName, recordMessage.EventSource, recordMessage.AwsRegion)
-			handleFunctionEvent(recordMessage, config.IncludedFuncTagKeys, config.IncludedFuncRegions, context)
-		}
+		handleRecordMessage(recordMessage, context)
 	}
 
 	return nil
 }
 
+// handleApiCallEvent handles a lambda api call delivered by an EventBridge rule, its detail is the CloudTrail record
+// of the call, the same record a log event of the trail holds.
+func handleApiCallEvent(context context.Context, event events.CloudWatchEvent) error {
+	recordMessage, err := parseApiCallEvent(event)
+	if err != nil {
+		return err
+	}
+	if err := prepareVerifier(); err != nil {
+		return err
+	}
+	handleRecordMessage(recordMessage, context)
+	return nil
+}
+
+func parseApiCallEvent(event events.CloudWatchEvent) (RecordMessage, error) {
+	recordMessage := RecordMessage{}
+	if err := json.Unmarshal(event.Detail, &recordMessage); err != nil {
+		return recordMessage, fmt.Errorf("failed to extract api call from event: %w", err)
+	}
+	return recordMessage, nil
+}
+
+// prepareVerifier loads the configuration on the first event, and creates the working folder of the verification.
+func prepareVerifier() error {
+	if config == nil {
+		if err := initConfig(); err != nil {
+			return err
+		}
+	}
+	log.Printf("creating folder: %s", utils.FunctionClarityHomeDir)
+	return os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm)
+}
+
+func handleRecordMessage(recordMessage RecordMessage, context context.Context) {
+	if isLayerEvent(recordMessage) {
+		log.Printf("handling layer version: %s, event name: %s, event source: %s, region: %s\n", recordMessage.ResponseElements.LayerVersionArn, recordMessage.EventName, recordMessage.EventSource, recordMessage.AwsRegion)
+		handleLayerEvent(recordMessage, context)
+	} else if shouldHandleEvent(recordMessage) {
+		log.Printf("handling function name: %s, event name: %s, event source: %s, region: %s\n", recordMessage.RequestParameters.FunctionName, recordMessage.EventName, recordMessage.EventSource, recordMessage.AwsRegion)
+		handleFunctionEvent(recordMessage, config.IncludedFuncTagKeys, config.IncludedFuncRegions, context)
+	}
+}
+
 func shouldHandleEvent(recordMessage RecordMessage) bool {
 	if clients.FunctionClarityLambdaVerierName == recordMessage.RequestParameters.FunctionName || "" == recordMessage.RequestParameters.FunctionName {
 		return false
 	}
+	if strings.Contains(recordMessage.EventName, "UpdateFunctionConfiguration") {
+		return recordMessage.RequestParameters.changesVerifiedConfiguration()
+	}
 	return strings.Contains(recordMessage.EventName, "CreateFunction") || strings.Contains(recordMessage.EventName, "UpdateFunctionCode") ||
-		strings.Contains(recordMessage.EventName, "DeleteFunctionConcurrency") || (strings.Contains(recordMessage.EventName, "PutFunctionConcurrency") && recordMessage.RequestParameters.ReservedConcurrentExecutions != 0)
+		strings.Contains(recordMessage.EventName, "PublishVersion") || strings.Contains(recordMessage.EventName, "DeleteFunctionConcurrency") ||
+		(strings.Contains(recordMessage.EventName, "PutFunctionConcurrency") && recordMessage.RequestParameters.ReservedConcurrentExecutions != 0)
+}
+
+// changesVerifiedConfiguration tells if the configuration update changes what runs in the function: its layers, its
+// runtime or its image, other configuration updates (i.e. memory or timeout) don't require verification.
+func (p RequestParameters) changesVerifiedConfiguration() bool {
+	return p.Layers != nil || p.Runtime != "" || p.ImageUri != "" || len(p.ImageConfig) > 0
+}
+
+func isLayerEvent(recordMessage RecordMessage) bool {
+	return strings.Contains(recordMessage.EventName, "PublishLayerVersion") && recordMessage.ResponseElements.LayerVersionArn != ""
+}
+
+// functionIdentifier returns the function of the event, the qualified ARN of the version when a version was published.
+// A function name is turned into the ARN of the function in the account of the event.
+func (m RecordMessage) functionIdentifier() string {
+	if strings.Contains(m.EventName, "PublishVersion") && m.ResponseElements.FunctionArn != "" {
+		return m.ResponseElements.FunctionArn
+	}
+	return clients.FunctionArnInAccount(m.RequestParameters.FunctionName, m.RecipientAccountId, m.AwsRegion)
+}
+
+// roleArnForAccount returns the role to assume to verify the functions of the account, empty for the account of the
+// verifier itself.
+func roleArnForAccount(ctx context.Context, accountId string) string {
+	return config.RoleArnForAccount(accountId, verifierAccountId(ctx))
+}
+
+func verifierAccountId(ctx context.Context) string {
+	if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+		if verifierArn, err := arn.Parse(lambdaContext.InvokedFunctionArn); err == nil {
+			return verifierArn.AccountID
+		}
+	}
+	return ""
+}
+
+// scheduledAccounts returns the accounts whose functions are re-verified on schedule, the account of the verifier
+// first as an empty account id.
+func scheduledAccounts(verifierAccountId string) []string {
+	accounts := []string{""}
+	for _, accountId := range config.ScheduledAccounts() {
+		if accountId != verifierAccountId {
+			accounts = append(accounts, accountId)
+		}
+	}
+	return accounts
 }
 
 func handleFunctionEvent(recordMessage RecordMessage, tagKeysFilter []string, regionsFilter []string, ctx context.Context) {
-	awsClientForDocker := clients.NewAwsClient("", "", config.Bucket, recordMessage.AwsRegion, recordMessage.AwsRegion)
+	roleArn := roleArnForAccount(ctx, recordMessage.RecipientAccountId)
+	if roleArn != "" {
+		log.Printf("assuming role: %s to verify function of account: %s", roleArn, recordMessage.RecipientAccountId)
+	}
+	awsClientForDocker := clients.NewAwsClientWithRole("", "", recordMessage.AwsRegion, recordMessage.AwsRegion, roleArn)
 	err := integrity.InitDocker(awsClientForDocker)
 	if err != nil {
 		log.Printf("Failed to init docker. %v", err)
 		return
 	}
 	o := getVerifierOptions(config.IsKeyless, config.PublicKey)
+	o.Policy = verificationPolicy
 	log.Printf("about to execute verification with post action: %s.", config.Action)
-	awsClient := clients.NewAwsClient("", "", config.Bucket, config.Region, recordMessage.AwsRegion)
-	_, _, err = verify.Verify(awsClient, recordMessage.RequestParameters.FunctionName, o, ctx, config.Action, config.SnsTopicArn, tagKeysFilter, regionsFilter, "", "")
+	awsClient := clients.NewAwsClientWithRole("", "", config.Region, recordMessage.AwsRegion, roleArn)
+	store, err := newSignatureStore()
+	if err != nil {
+		log.Printf("Failed to create signature store: %v", err)
+		return
+	}
+	functionIdentifier := recordMessage.functionIdentifier()
+	result, err := verify.Verify(awsClient, store, functionIdentifier, o, ctx, config.Action, config.SnsTopicArn, tagKeysFilter, regionsFilter, "", "")
+	logVerificationResult(result)
+	if err != nil {
+		log.Printf("Failed to handle lambda result: %s, %v", functionIdentifier, err)
+	}
+}
+
+// handleLayerEvent verifies a published layer version. It isn't attached to any function yet, so it can't be
+// detected or blocked, an unverified layer version is logged and notified. The layer is matched against the policy
+// rules by its name, account and region.
+func handleLayerEvent(recordMessage RecordMessage, ctx context.Context) {
+	layerVersionArn := recordMessage.ResponseElements.LayerVersionArn
+	layer, err := layerPolicyFunction(layerVersionArn)
+	if err != nil {
+		log.Printf("Failed to parse layer version arn: %s, %v", layerVersionArn, err)
+		return
+	}
+	o := getVerifierOptions(config.IsKeyless, config.PublicKey)
+	var signers []policy.Signer
+	if verificationPolicy != nil {
+		if rule := verificationPolicy.Match(layer); rule != nil {
+			log.Printf("layer: %s matched policy rule: %s", layerVersionArn, rule.Name)
+			signers = rule.Signers
+		}
+	}
+	awsClient := clients.NewAwsClientWithRole("", "", config.Region, recordMessage.AwsRegion, roleArnForAccount(ctx, layer.AccountId))
+	store, err := newSignatureStore()
+	if err != nil {
+		log.Printf("Failed to create signature store: %v", err)
+		return
+	}
+	result, err := verify.VerifyLayer(awsClient, store, layerVersionArn, signers, o, "", "", ctx)
+	serResult, e := json.Marshal(result)
+	if e != nil {
+		log.Printf("failed to serialize layer verification result: %v", e)
+	} else {
+		log.Printf("layer verification result: %s", serResult)
+	}
+	if err != nil && !errors.Is(err, verify.VerifyError{}) {
+		log.Printf("Failed to verify layer: %s, %v", layerVersionArn, err)
+		return
+	}
+	if result.Verified || config.SnsTopicArn == "" {
+		return
+	}
+	notification := clients.Notification{
+		AccountId:          layer.AccountId,
+		FunctionIdentifier: layerVersionArn,
+		Action:             config.Action,
+		Region:             layer.Region,
+		FailureCategory:    result.FailureCategory,
+		Unsigned:           utils.IsUnsignedFailure(result.FailureCategory),
+	}
+	msg, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("failed to serialize notification: %v", err)
+		return
+	}
+	if err = awsClient.Notify(string(msg), config.SnsTopicArn); err != nil {
+		log.Printf("Failed to notify about layer: %s, %v", layerVersionArn, err)
+	}
+}
 
+// layerPolicyFunction returns the layer of the layer version, as matched against the policy rules.
+func layerPolicyFunction(layerVersionArn string) (policy.Function, error) {
+	parsedArn, err := arn.Parse(layerVersionArn)
 	if err != nil {
-		log.Printf("Failed to handle lambda result: %s, %v", recordMessage.RequestParameters.FunctionName, err)
+		return policy.Function{}, err
+	}
+	// the resource of a layer version arn is layer:<name>:<version>
+	layerName := parsedArn.Resource
+	if resource := strings.Split(parsedArn.Resource, ":"); len(resource) > 1 {
+		layerName = resource[1]
+	}
+	return policy.Function{Name: layerName, AccountId: parsedArn.AccountID, Region: parsedArn.Region}, nil
+}
+
+// parseScheduledVerification returns the scheduled verification the event holds, nil for any other event.
+func parseScheduledVerification(event json.RawMessage) *ScheduledVerification {
+	scheduledEvent := scheduledVerificationEvent{}
+	if err := json.Unmarshal(event, &scheduledEvent); err != nil {
+		return nil
 	}
+	return scheduledEvent.ScheduledVerification
+}
+
+// handleScheduledEvent re-verifies all the functions in the included regions of the verifier account and of the
+// scheduled accounts, so functions whose signature was deleted or whose signer is no longer trusted are caught even
+// though their code didn't change. Every account and region is verified by an asynchronous invocation of the verifier
+// of its own, since the time limit of a single invocation doesn't fit all the functions.
+func handleScheduledEvent(ctx context.Context) error {
+	if err := prepareVerifier(); err != nil {
+		return err
+	}
+	verifierArn := ""
+	if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+		verifierArn = lambdaContext.InvokedFunctionArn
+	}
+	if verifierArn == "" {
+		return fmt.Errorf("failed to start scheduled verification: the arn of the verifier is unknown")
+	}
+	regions := scheduledRegions()
+	accounts := scheduledAccounts(verifierAccountId(ctx))
+	log.Printf("scheduled verification of functions in regions: %v, accounts: %v, post action: %s", regions, accounts[1:], config.Action)
+	awsClient := clients.NewAwsClientInit("", "", config.Region)
+	started := 0
+	for _, account := range accounts {
+		if account != "" && roleArnForAccount(ctx, account) == "" {
+			log.Printf("No role to assume in account: %s, skipping its functions", account)
+			continue
+		}
+		for _, region := range regions {
+			if err := invokeScheduledVerification(ctx, awsClient, verifierArn, ScheduledVerification{Account: account, Region: region}); err != nil {
+				log.Printf("Failed to start scheduled verification of: %s, its functions are not verified in this run: %v", scheduledLocation(account, region), err)
+				continue
+			}
+			started++
+		}
+	}
+	log.Printf("scheduled verification started for %d accounts and regions", started)
+	return nil
+}
+
+// handleScheduledVerification re-verifies the functions of a single account and region, in the order of their ARNs.
+// Once the invocation is about to reach its time limit, the rest of the functions are handed to a new invocation.
+func handleScheduledVerification(ctx context.Context, scheduledVerification ScheduledVerification) error {
+	if err := prepareVerifier(); err != nil {
+		return err
+	}
+	location := scheduledLocation(scheduledVerification.Account, scheduledVerification.Region)
+	roleArn := roleArnForAccount(ctx, scheduledVerification.Account)
+	if scheduledVerification.Account != "" && roleArn == "" {
+		log.Printf("No role to assume in account: %s, skipping its functions", scheduledVerification.Account)
+		return nil
+	}
+	store, err := newSignatureStore()
+	if err != nil {
+		return fmt.Errorf("failed to create signature store: %w", err)
+	}
+	concurrency := scheduledVerificationConcurrency
+	if verificationPolicy != nil && verificationPolicy.HasKeylessSigners() {
+		concurrency = 1
+	}
+	region := scheduledVerification.Region
+	if err := integrity.InitDocker(clients.NewAwsClientWithRole("", "", region, region, roleArn)); err != nil {
+		log.Printf("Failed to init docker for: %s. %v", location, err)
+	}
+	awsClient := clients.NewAwsClientWithRole("", "", config.Region, region, roleArn)
+	functionArns, err := awsClient.ListFunctions(ctx)
+	if err != nil {
+		log.Printf("Failed to list functions of: %s: %v", location, err)
+		return nil
+	}
+	functionArns = functionsAfter(functionArns, scheduledVerification.After)
+	var targets []scan.Target
+	for _, functionArn := range functionArns {
+		targets = append(targets, scan.Target{Client: awsClient, Function: functionArn, Region: region})
+	}
+	report := scan.NewReport([]string{region})
+	if scheduledVerification.Account != "" {
+		report.Accounts = []string{scheduledVerification.Account}
+	}
+	// every verification gets its own options, and downloads its signatures to its own directory
+	started := report.RunUntil(targets, concurrency, func() bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) < scheduledVerificationMargin
+	}, func(target scan.Target) (*verify.VerificationResult, error) {
+		o := getVerifierOptions(config.IsKeyless, config.PublicKey)
+		o.Policy = verificationPolicy
+		result, err := verify.Verify(target.Client, store, target.Function, o, ctx, config.Action, config.SnsTopicArn,
+			config.IncludedFuncTagKeys, config.IncludedFuncRegions, "", "")
+		logVerificationResult(result)
+		if err != nil {
+			log.Printf("Failed to handle lambda result: %s, %v", target.Function, err)
+		}
+		return result, err
+	})
+	log.Printf("scheduled verification of: %s finished. total: %d, verified: %d, failed: %d, skipped: %d, errors: %d, failure categories: %v",
+		location, report.Total, report.Verified, report.Failed, report.Skipped, report.Errors, report.FailureCategories)
+	if started == len(functionArns) {
+		return nil
+	}
+	log.Printf("scheduled verification of: %s is partial, %d of %d functions were not verified before the time limit",
+		location, len(functionArns)-started, len(functionArns))
+	if started == 0 {
+		log.Printf("No function of: %s could be verified before the time limit, the rest of the functions are not verified in this run", location)
+		return nil
+	}
+	next := ScheduledVerification{Account: scheduledVerification.Account, Region: region, After: functionArns[started-1]}
+	verifierArn := ""
+	if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+		verifierArn = lambdaContext.InvokedFunctionArn
+	}
+	if err := invokeScheduledVerification(ctx, clients.NewAwsClientInit("", "", config.Region), verifierArn, next); err != nil {
+		log.Printf("Failed to continue scheduled verification of: %s, the rest of the functions are not verified in this run: %v", location, err)
+		return nil
+	}
+	log.Printf("scheduled verification of: %s continues after function: %s", location, next.After)
+	return nil
+}
+
+// invokeScheduledVerification invokes the verifier asynchronously with the scheduled verification as its event.
+func invokeScheduledVerification(ctx context.Context, awsClient *clients.AwsClient, verifierArn string, scheduledVerification ScheduledVerification) error {
+	payload, err := json.Marshal(scheduledVerificationEvent{ScheduledVerification: &scheduledVerification})
+	if err != nil {
+		return fmt.Errorf("failed to serialize scheduled verification: %w", err)
+	}
+	return awsClient.InvokeAsync(ctx, verifierArn, payload)
+}
+
+// functionsAfter returns the functions whose ARNs sort after the given one, all of them if it is empty, sorted, so
+// a scheduled verification continues where the previous invocation stopped even if functions were added or deleted.
+func functionsAfter(functionArns []string, after string) []string {
+	sorted := append([]string(nil), functionArns...)
+	sort.Strings(sorted)
+	start := sort.SearchStrings(sorted, after)
+	if start < len(sorted) && sorted[start] == after {
+		start++
+	}
+	return sorted[start:]
+}
+
+// scheduledRegions returns the regions whose functions are re-verified on schedule, the region of the verifier if no
+// region is included.
+func scheduledRegions() []string {
+	if len(config.IncludedFuncRegions) == 0 {
+		return []string{config.Region}
+	}
+	return config.IncludedFuncRegions
+}
+
+func scheduledLocation(account string, region string) string {
+	if account == "" {
+		return region
+	}
+	return account + "/" + region
+}
+
+func newSignatureStore() (clients.SignatureStore, error) {
+	if config.SignatureStore != "" {
+		return clients.NewSignatureStore(config.SignatureStore, "", "", config.Region)
+	}
+	return clients.NewS3SignatureStore("", "", config.Bucket, "", config.Region), nil
+}
+
+// logVerificationResult logs the result as a single json line, to be queried in the logs of the verifier.
+func logVerificationResult(result *verify.VerificationResult) {
+	serResult, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("failed to serialize verification result: %v", err)
+		return
+	}
+	log.Printf("verification result: %s", serResult)
 }
 
 func initConfig() error {
@@ -133,6 +538,14 @@ func initConfig() error {
 	if err != nil {
 		return err
 	}
+	if config.Policy != "" {
+		verificationPolicy, err = policy.Load(config.Policy)
+		if err != nil {
+			// the configuration is loaded again on the next event, never verifying without the policy
+			config = nil
+			return err
+		}
+	}
 	return nil
 }
 
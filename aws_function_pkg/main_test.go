@@ -0,0 +1,211 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/policy"
+)
+
+func TestScheduledAccounts(t *testing.T) {
+	previous := config
+	defer func() { config = previous }()
+	config = &i.AWSInput{
+		MemberRoleName:   "FunctionClarityMemberRole",
+		ReverifyAccounts: []string{"222222222222", "111111111111", "333333333333"},
+		AccountRoles:     map[string]string{"444444444444": "arn:aws:iam::444444444444:role/custom", "222222222222": "arn:aws:iam::222222222222:role/custom"},
+	}
+	expected := []string{"", "222222222222", "333333333333", "444444444444"}
+	if accounts := scheduledAccounts("111111111111"); !reflect.DeepEqual(accounts, expected) {
+		t.Fatalf("expected accounts: %v, got: %v", expected, accounts)
+	}
+	config = &i.AWSInput{}
+	if accounts := scheduledAccounts("111111111111"); !reflect.DeepEqual(accounts, []string{""}) {
+		t.Fatalf("expected the verifier account only, got: %v", accounts)
+	}
+}
+
+// eventOutcome is how a CloudTrail record of a lambda api call is handled
+type eventOutcome struct {
+	// functionIdentifier is the function to verify, empty if the record is not handled as a function event
+	functionIdentifier string
+	// layerVersionArn is the layer version to verify, empty if the record is not a layer event
+	layerVersionArn string
+}
+
+func outcomeOf(recordMessage RecordMessage) eventOutcome {
+	outcome := eventOutcome{}
+	if isLayerEvent(recordMessage) {
+		outcome.layerVersionArn = recordMessage.ResponseElements.LayerVersionArn
+	} else if shouldHandleEvent(recordMessage) {
+		outcome.functionIdentifier = recordMessage.functionIdentifier()
+	}
+	return outcome
+}
+
+func TestShouldHandleEvent(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected eventOutcome
+	}{
+		{fixture: "cloudtrail_update_function_configuration_layers.json", expected: eventOutcome{functionIdentifier: "arn:aws:lambda:us-east-1:111111111111:function:hello"}},
+		{fixture: "cloudtrail_update_function_configuration_remove_layers.json", expected: eventOutcome{functionIdentifier: "arn:aws:lambda:us-east-1:111111111111:function:hello"}},
+		{fixture: "cloudtrail_update_function_configuration_memory.json", expected: eventOutcome{}},
+		{fixture: "cloudtrail_publish_version.json", expected: eventOutcome{functionIdentifier: "arn:aws:lambda:us-east-1:111111111111:function:hello:3"}},
+		{fixture: "cloudtrail_publish_layer_version.json", expected: eventOutcome{layerVersionArn: "arn:aws:lambda:us-east-1:111111111111:layer:deps:5"}},
+		{fixture: "cloudtrail_org_trail_update_function_code.json", expected: eventOutcome{functionIdentifier: "arn:aws:lambda:eu-west-1:222222222222:function:orders"}},
+		{fixture: "cloudtrail_org_trail_publish_layer_version.json", expected: eventOutcome{layerVersionArn: "arn:aws:lambda:eu-west-1:222222222222:layer:shared:2"}},
+		{fixture: "cloudtrail_update_verifier_code.json", expected: eventOutcome{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recordMessage := RecordMessage{}
+			if err = json.Unmarshal(data, &recordMessage); err != nil {
+				t.Fatal(err)
+			}
+			if outcome := outcomeOf(recordMessage); outcome != tt.expected {
+				t.Fatalf("expected: %+v, got: %+v", tt.expected, outcome)
+			}
+		})
+	}
+}
+
+func TestParseApiCallEvent(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected eventOutcome
+	}{
+		{fixture: "eventbridge_update_function_configuration_layers.json", expected: eventOutcome{functionIdentifier: "arn:aws:lambda:us-east-1:111111111111:function:hello"}},
+		{fixture: "eventbridge_update_function_configuration_memory.json", expected: eventOutcome{}},
+		{fixture: "eventbridge_publish_version.json", expected: eventOutcome{functionIdentifier: "arn:aws:lambda:us-east-1:111111111111:function:hello:3"}},
+		{fixture: "eventbridge_org_trail_publish_layer_version.json", expected: eventOutcome{layerVersionArn: "arn:aws:lambda:eu-west-1:222222222222:layer:shared:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// HandleRequest routes the api calls of EventBridge by their source and detail type
+			event := events.CloudWatchEvent{}
+			if err = json.Unmarshal(data, &event); err != nil {
+				t.Fatal(err)
+			}
+			if event.Source != "aws.lambda" || event.DetailType != "AWS API Call via CloudTrail" {
+				t.Fatalf("expected an api call event, got source: %s, detail type: %s", event.Source, event.DetailType)
+			}
+			recordMessage, err := parseApiCallEvent(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outcome := outcomeOf(recordMessage); outcome != tt.expected {
+				t.Fatalf("expected: %+v, got: %+v", tt.expected, outcome)
+			}
+		})
+	}
+}
+
+func TestParseScheduledVerification(t *testing.T) {
+	scheduledVerification := ScheduledVerification{Account: "222222222222", Region: "eu-west-1", After: "arn:aws:lambda:eu-west-1:222222222222:function:b"}
+	payload, err := json.Marshal(scheduledVerificationEvent{ScheduledVerification: &scheduledVerification})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed := parseScheduledVerification(payload); parsed == nil || *parsed != scheduledVerification {
+		t.Fatalf("expected: %+v, got: %+v", scheduledVerification, parsed)
+	}
+	data, err := os.ReadFile("testdata/eventbridge_publish_version.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed := parseScheduledVerification(data); parsed != nil {
+		t.Fatalf("expected an api call event not to be a scheduled verification, got: %+v", parsed)
+	}
+}
+
+func TestFunctionsAfter(t *testing.T) {
+	functionArns := []string{
+		"arn:aws:lambda:us-east-1:111111111111:function:c",
+		"arn:aws:lambda:us-east-1:111111111111:function:a",
+		"arn:aws:lambda:us-east-1:111111111111:function:d",
+	}
+	if functions := functionsAfter(functionArns, ""); !reflect.DeepEqual(functions, []string{functionArns[1], functionArns[0], functionArns[2]}) {
+		t.Fatalf("expected all the functions sorted, got: %v", functions)
+	}
+	if functions := functionsAfter(functionArns, functionArns[0]); !reflect.DeepEqual(functions, []string{functionArns[2]}) {
+		t.Fatalf("expected the functions after: %s, got: %v", functionArns[0], functions)
+	}
+	// the last verified function was deleted before the next invocation
+	if functions := functionsAfter(functionArns, "arn:aws:lambda:us-east-1:111111111111:function:b"); !reflect.DeepEqual(functions, []string{functionArns[0], functionArns[2]}) {
+		t.Fatalf("expected the functions after the deleted one, got: %v", functions)
+	}
+}
+
+func TestParseApiCallEventInvalid(t *testing.T) {
+	if _, err := parseApiCallEvent(events.CloudWatchEvent{Detail: json.RawMessage("[]")}); err == nil {
+		t.Fatal("expected unmarshal error")
+	}
+}
+
+func TestLayerPolicyFunction(t *testing.T) {
+	p := &policy.Policy{Rules: []policy.Rule{
+		{Name: "member-layers", Accounts: []string{"222222222222"}, Signers: []policy.Signer{{Key: "member.pub"}}},
+		{Name: "deps", Functions: []string{"deps"}, Signers: []policy.Signer{{Key: "deps.pub"}}},
+	}}
+	tests := []struct {
+		fixture      string
+		expected     policy.Function
+		expectedRule string
+	}{
+		{fixture: "cloudtrail_publish_layer_version.json", expected: policy.Function{Name: "deps", AccountId: "111111111111", Region: "us-east-1"}, expectedRule: "deps"},
+		{fixture: "cloudtrail_org_trail_publish_layer_version.json", expected: policy.Function{Name: "shared", AccountId: "222222222222", Region: "eu-west-1"}, expectedRule: "member-layers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recordMessage := RecordMessage{}
+			if err = json.Unmarshal(data, &recordMessage); err != nil {
+				t.Fatal(err)
+			}
+			layer, err := layerPolicyFunction(recordMessage.ResponseElements.LayerVersionArn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(layer, tt.expected) {
+				t.Fatalf("expected layer: %+v, got: %+v", tt.expected, layer)
+			}
+			if rule := p.Match(layer); rule == nil || rule.Name != tt.expectedRule {
+				t.Fatalf("expected layer to match rule: %s, got: %+v", tt.expectedRule, rule)
+			}
+		})
+	}
+	if _, err := layerPolicyFunction("deps:5"); err == nil {
+		t.Fatal("expected invalid arn error")
+	}
+}
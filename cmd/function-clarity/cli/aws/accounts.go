@@ -0,0 +1,125 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/scan"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// accountsFlags select the accounts of the organization whose functions are verified, by assuming the member role in them.
+type accountsFlags struct {
+	accounts           []string
+	organizationalUnit string
+}
+
+func (f *accountsFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&f.accounts, "accounts", []string{}, "ids of the accounts whose functions are verified (default: the account of the credentials)")
+	cmd.Flags().StringVar(&f.organizationalUnit, "organizational-unit", "", "id of an organizational unit, the functions of all its accounts are verified")
+	cmd.Flags().String("assume-role", "", "name of the role to assume in the accounts to verify their functions")
+}
+
+// resolve returns the accounts of the flags, with the accounts of the organizational unit, and the function creating
+// the client of an account. No accounts are returned when neither flag is set, the functions of the account of the
+// credentials are verified then.
+func (f *accountsFlags) resolve(ctx context.Context) ([]string, func(accountId string, lambdaRegion string) *clients.AwsClient, error) {
+	accessKey, secretKey, region := viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region")
+	accounts := append([]string{}, f.accounts...)
+	homeClient := clients.NewAwsClientInit(accessKey, secretKey, region)
+	if f.organizationalUnit != "" {
+		unitAccounts, err := homeClient.ListOrganizationalUnitAccounts(ctx, f.organizationalUnit)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, account := range unitAccounts {
+			if !contains(accounts, account) {
+				accounts = append(accounts, account)
+			}
+		}
+	}
+	input := i.AWSInput{MemberRoleName: viper.GetString("memberrolename"), AccountRoles: viper.GetStringMapString("accountroles")}
+	verifierAccountId := ""
+	if len(accounts) > 0 {
+		if input.MemberRoleName == "" && len(input.AccountRoles) == 0 {
+			return nil, nil, fmt.Errorf("the role to assume in the accounts is required, set the assume-role flag")
+		}
+		var err error
+		if verifierAccountId, err = homeClient.GetAccountId(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return accounts, func(accountId string, lambdaRegion string) *clients.AwsClient {
+		return clients.NewAwsClientWithRole(accessKey, secretKey, region, lambdaRegion, input.RoleArnForAccount(accountId, verifierAccountId))
+	}, nil
+}
+
+// verifyInAccounts verifies the function of the name in each of the accounts, and writes the report of the results.
+func verifyInAccounts(cmd *cobra.Command, functionName string, accounts []string, newAccountClient func(string, string) *clients.AwsClient,
+	lambdaRegion string, store clients.SignatureStore, o *options.VerifyOpts) error {
+	if arn.IsARN(functionName) {
+		return fmt.Errorf("the function must be given by name to verify it in several accounts")
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = "table"
+	}
+	if err = common.ValidateOutputFormat(output); err != nil {
+		return err
+	}
+	stdout, restore := common.RedirectStdout()
+	report := scan.NewReport([]string{lambdaRegion})
+	report.Accounts = accounts
+	var targets []scan.Target
+	for _, account := range accounts {
+		targets = append(targets, scan.Target{Client: newAccountClient(account, lambdaRegion), Function: clients.FunctionArnInAccount(functionName, account, lambdaRegion), Region: lambdaRegion})
+	}
+	report.Run(targets, 1, func(target scan.Target) (*verify.VerificationResult, error) {
+		functionOpts := *o
+		return verify.Verify(target.Client, store, target.Function, &functionOpts, cmd.Context(), viper.GetString("action"),
+			viper.GetString("snsTopicArn"), viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"),
+			"", "")
+	})
+	restore()
+	if err = report.Write(stdout, output); err != nil {
+		return fmt.Errorf("failed to write verification report: %w", err)
+	}
+	if report.Failed+report.Errors > 0 {
+		return fmt.Errorf("%d of %d functions weren't verified", report.Failed+report.Errors, report.Total)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -19,10 +19,14 @@ import (
 	"fmt"
 	"github.com/openclarity/functionclarity/pkg/utils"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
 	opt "github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
 	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/iac"
 	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/options"
 	"github.com/openclarity/functionclarity/pkg/verify"
@@ -37,6 +41,7 @@ func AwsSign() *cobra.Command {
 		Short: "sign code/image and upload to aws",
 	}
 	cmd.AddCommand(AwsSignCode())
+	cmd.AddCommand(AwsSignLayer())
 	cmd.AddCommand(common.SignImage())
 	return cmd
 }
@@ -44,46 +49,39 @@ func AwsSign() *cobra.Command {
 func AwsVerify() *cobra.Command {
 	o := &options.VerifyOpts{}
 	var lambdaRegion string
+	af := &accountsFlags{}
 	cmd := &cobra.Command{
 		Use:   "aws",
 		Short: "verify function identity",
-		Args:  cobra.ExactArgs(1),
+		Long: "verify function identity. with the accounts or organizational-unit flag, the function is verified in each of the " +
+			"accounts, and the results are summarized in a report as by the scan command",
+		Args: cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
-				return fmt.Errorf("error binding accessKey: %w", err)
-			}
-			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
-				return fmt.Errorf("error binding secretKey: %w", err)
-			}
-			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
-				return fmt.Errorf("error binding region: %w", err)
-			}
-			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
-				return fmt.Errorf("error binding bucket: %w", err)
-			}
-			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
-				return fmt.Errorf("error binding publickey: %w", err)
-			}
-			if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
-				return fmt.Errorf("error binding action: %w", err)
+			return bindAwsVerifyFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			var err error
+			if o.Policy, err = common.Policy(); err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("includedfunctagkeys", cmd.Flags().Lookup("included-func-tags")); err != nil {
-				return fmt.Errorf("error binding action: %w", err)
+			store, err := common.SignatureStore(clients.NewS3SignatureStore(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), "", viper.GetString("region")))
+			if err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("includedfuncregions", cmd.Flags().Lookup("included-func-regions")); err != nil {
-				return fmt.Errorf("error binding action: %w", err)
+			accounts, newAccountClient, err := af.resolve(cmd.Context())
+			if err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("snsTopicArn", cmd.Flags().Lookup("sns-topic-arn")); err != nil {
-				return fmt.Errorf("error binding snsTopicArn: %w", err)
+			if len(accounts) > 0 {
+				return verifyInAccounts(cmd, args[0], accounts, newAccountClient, lambdaRegion, store, o)
 			}
-			return nil
-		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			o.Key = viper.GetString("publickey")
-			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion)
-			_, _, err := verify.Verify(awsClient, args[0], o, cmd.Context(), viper.GetString("action"),
-				viper.GetString("snsTopicArn"), viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"),
-				"", "")
+			awsClient := newAccountClient("", lambdaRegion)
+			_, err = common.RunVerify(cmd, func() (*verify.VerificationResult, error) {
+				return verify.Verify(awsClient, store, args[0], o, cmd.Context(), viper.GetString("action"),
+					viper.GetString("snsTopicArn"), viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"),
+					"", "")
+			})
 			return err
 		},
 	}
@@ -91,9 +89,51 @@ func AwsVerify() *cobra.Command {
 	cmd.MarkFlagRequired("function-region") //nolint:errcheck
 	o.AddFlags(cmd)
 	initAwsVerifyFlags(cmd)
+	af.addFlags(cmd)
+	common.AddOutputFlag(cmd)
 	return cmd
 }
 
+func bindAwsVerifyFlags(cmd *cobra.Command) error {
+	if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+		return fmt.Errorf("error binding accessKey: %w", err)
+	}
+	if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+		return fmt.Errorf("error binding secretKey: %w", err)
+	}
+	if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+		return fmt.Errorf("error binding region: %w", err)
+	}
+	if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+		return fmt.Errorf("error binding bucket: %w", err)
+	}
+	if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+		return fmt.Errorf("error binding publickey: %w", err)
+	}
+	if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
+		return fmt.Errorf("error binding action: %w", err)
+	}
+	if err := viper.BindPFlag("includedfunctagkeys", cmd.Flags().Lookup("included-func-tags")); err != nil {
+		return fmt.Errorf("error binding action: %w", err)
+	}
+	if err := viper.BindPFlag("includedfuncregions", cmd.Flags().Lookup("included-func-regions")); err != nil {
+		return fmt.Errorf("error binding action: %w", err)
+	}
+	if err := viper.BindPFlag("snsTopicArn", cmd.Flags().Lookup("sns-topic-arn")); err != nil {
+		return fmt.Errorf("error binding snsTopicArn: %w", err)
+	}
+	if err := viper.BindPFlag("memberrolename", cmd.Flags().Lookup("assume-role")); err != nil {
+		return fmt.Errorf("error binding memberrolename: %w", err)
+	}
+	if err := common.BindSignatureStoreFlag(cmd); err != nil {
+		return err
+	}
+	if err := common.BindPolicyFlag(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
 func initAwsVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
 	cmd.Flags().String("aws-access-key", "", "aws access key")
@@ -105,6 +145,8 @@ func initAwsVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSlice("included-func-tags", []string{}, "function tags to include when verifying")
 	cmd.Flags().StringSlice("included-func-regions", []string{}, "function regions to include when verifying")
 	cmd.Flags().String("sns-topic-arn", "", "SNS topic ARN for notifications")
+	common.AddSignatureStoreFlag(cmd)
+	common.AddPolicyFlag(cmd)
 }
 
 func AwsInit() *cobra.Command {
@@ -113,8 +155,19 @@ func AwsInit() *cobra.Command {
 		Short: "initialize configuration and deploy to aws",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			emit, err := cmd.Flags().GetString("emit")
+			if err != nil {
+				return err
+			}
+			if emit != "" && !contains(iac.Formats, emit) {
+				return fmt.Errorf("unsupported emit format: %s, expected one of: %s", emit, strings.Join(iac.Formats, ", "))
+			}
+			prompter, err := common.InitPrompter(cmd, initParameters)
+			if err != nil {
+				return err
+			}
 			var input i.AWSInput
-			if err := ReceiveParameters(&input); err != nil {
+			if err := ReceiveParameters(&input, prompter); err != nil {
 				return err
 			}
 			if input.Bucket == "" {
@@ -128,17 +181,49 @@ func AwsInit() *cobra.Command {
 			configForDeployment.SnsTopicArn = input.SnsTopicArn
 			configForDeployment.IncludedFuncTagKeys = input.IncludedFuncTagKeys
 			configForDeployment.IncludedFuncRegions = input.IncludedFuncRegions
+			configForDeployment.SignatureStore = input.SignatureStore
+			configForDeployment.Policy = input.Policy
+			configForDeployment.ReverifySchedule = input.ReverifySchedule
+			configForDeployment.Ingestion = input.Ingestion
+			configForDeployment.MemberRoleName = input.MemberRoleName
+			configForDeployment.AccountRoles = input.AccountRoles
+			configForDeployment.ReverifyAccounts = input.ReverifyAccounts
 			onlyCreateConfig, err := cmd.Flags().GetBool("only-create-config")
 			if err != nil {
 				return err
 			}
-			if !onlyCreateConfig {
+			if emit != "" {
+				emitDir, err := cmd.Flags().GetString("emit-dir")
+				if err != nil {
+					return err
+				}
+				binaryPath, err := verifierBinary(cmd)
+				if err != nil {
+					return fmt.Errorf("init command fail: %w", err)
+				}
+				if err = emitInfrastructure(input, configForDeployment, binaryPath, emit, emitDir); err != nil {
+					return fmt.Errorf("init command fail: %w", err)
+				}
+			} else if !onlyCreateConfig {
+				binaryPath, err := verifierBinary(cmd)
+				if err != nil {
+					return fmt.Errorf("failed to deploy function clarity: %w", err)
+				}
 				awsClient := clients.NewAwsClientInit(input.AccessKey, input.SecretKey, input.Region)
-				err = awsClient.DeployFunctionClarity(input.CloudTrail.Name, input.PublicKey, configForDeployment, "")
+				err = awsClient.DeployFunctionClarity(input.CloudTrail.Name, input.PublicKey, binaryPath, configForDeployment, "")
 				if err != nil {
 					return fmt.Errorf("failed to deploy function clarity: %w", err)
 				}
 			}
+			memberRoleTemplate, err := cmd.Flags().GetString("member-role-template")
+			if err != nil {
+				return err
+			}
+			if memberRoleTemplate != "" {
+				if err = writeMemberRoleTemplate(input, memberRoleTemplate); err != nil {
+					return fmt.Errorf("init command fail: %w", err)
+				}
+			}
 			d, err := yaml.Marshal(&input)
 			if err != nil {
 				return fmt.Errorf("init command fail: %w", err)
@@ -157,9 +242,76 @@ func AwsInit() *cobra.Command {
 		},
 	}
 	cmd.Flags().Bool("only-create-config", false, "determine whether to only create config file without deploying")
+	cmd.Flags().String("member-role-template", "", "write the template of the role to assume in the member accounts to the file, to deploy with a StackSet")
+	cmd.Flags().String("emit", "", "write the deployment as infrastructure as code instead of deploying, one of: "+strings.Join(iac.Formats, ", "))
+	cmd.Flags().String("emit-dir", ".", "directory to write the infrastructure as code of --emit to")
+	addVerifierBinaryFlag(cmd)
+	common.AddInitFlags(cmd, initParameters)
 	return cmd
 }
 
+// emitInfrastructure writes the deployment in the format, for a pipeline to deploy it instead of the cli.
+func emitInfrastructure(input i.AWSInput, configForDeployment i.AWSInput, binaryPath string, format string, emitDir string) error {
+	awsClient := clients.NewAwsClientInit(input.AccessKey, input.SecretKey, input.Region)
+	stackTemplate, codeKey, err := awsClient.RenderFunctionClarityTemplate(input.CloudTrail.Name, input.PublicKey, binaryPath, configForDeployment, "")
+	if err != nil {
+		return err
+	}
+	files, err := iac.Emit(format, stackTemplate, input.Region, clients.FunctionClarityStackName(""))
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		filePath := filepath.Join(emitDir, path)
+		if err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filePath, err)
+		}
+		if err = os.WriteFile(filePath, files[path], 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filePath, err)
+		}
+		fmt.Printf("%s written to: %s\n", format, filePath)
+	}
+	printCodeUpload(configForDeployment.Bucket, codeKey)
+	return nil
+}
+
+// printCodeUpload prints how to upload the verifier code a rendered template refers to, before it is deployed.
+func printCodeUpload(bucket string, codeKey string) {
+	if bucket == "" {
+		bucket = clients.FunctionClarityBucketName
+	}
+	fmt.Printf("upload the verifier code before deploying: aws s3 cp %s s3://%s/%s\n", clients.FunctionClarityCodeArchive, bucket, codeKey)
+}
+
+// writeMemberRoleTemplate writes the template of the member account role, trusting the verifier function of the
+// account of the credentials and the trusted principals.
+func writeMemberRoleTemplate(input i.AWSInput, path string) error {
+	if input.MemberRoleName == "" {
+		return fmt.Errorf("the member role template requires the name of the role to assume in the other accounts")
+	}
+	verifierAccountId, err := clients.NewAwsClientInit(input.AccessKey, input.SecretKey, input.Region).GetAccountId()
+	if err != nil {
+		return err
+	}
+	content, err := clients.MemberRoleTemplate(verifierAccountId, input.MemberRoleName, input.TrustedPrincipals)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write member role template: %w", err)
+	}
+	fmt.Printf("member role template written to: %s, deploy it to the organizational units of the member accounts with:\n", path)
+	fmt.Printf("aws cloudformation create-stack-set --stack-set-name FunctionClarityMemberRole --template-body file://%s "+
+		"--permission-model SERVICE_MANAGED --auto-deployment Enabled=true,RetainStacksOnAccountRemoval=false --capabilities CAPABILITY_NAMED_IAM\n", path)
+	fmt.Printf("aws cloudformation create-stack-instances --stack-set-name FunctionClarityMemberRole --deployment-targets OrganizationalUnitIds=<ou ids> --regions %s\n", input.Region)
+	return nil
+}
+
 func AwsDeploy() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "aws",
@@ -175,14 +327,115 @@ func AwsDeploy() *cobra.Command {
 			configForDeployment.SnsTopicArn = viper.GetString("snsTopicArn")
 			configForDeployment.IncludedFuncTagKeys = viper.GetStringSlice("includedfunctagkeys")
 			configForDeployment.IncludedFuncRegions = viper.GetStringSlice("includedfuncregions")
+			configForDeployment.SignatureStore = viper.GetString("signaturestore")
+			configForDeployment.Policy = viper.GetString("policy")
+			configForDeployment.ReverifySchedule = viper.GetString("reverifyschedule")
+			configForDeployment.Ingestion = viper.GetString("ingestion")
+			configForDeployment.MemberRoleName = viper.GetString("memberrolename")
+			configForDeployment.AccountRoles = viper.GetStringMapString("accountroles")
+			configForDeployment.ReverifyAccounts = viper.GetStringSlice("reverifyaccounts")
+			if err := configForDeployment.ValidateIngestion(); err != nil {
+				return fmt.Errorf("failed to deploy function clarity: %w", err)
+			}
+			update, err := cmd.Flags().GetBool("update")
+			if err != nil {
+				return err
+			}
+			templateOut, err := cmd.Flags().GetString("template-out")
+			if err != nil {
+				return err
+			}
+			binaryPath, err := verifierBinary(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to deploy function clarity: %w", err)
+			}
 			awsClient := clients.NewAwsClientInit(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"))
-			err := awsClient.DeployFunctionClarity(viper.GetString("cloudtrail.name"), viper.GetString("publickey"), configForDeployment, "")
+			if templateOut != "" {
+				stackTemplate, codeKey, err := awsClient.RenderFunctionClarityTemplate(viper.GetString("cloudtrail.name"), viper.GetString("publickey"), binaryPath, configForDeployment, "")
+				if err != nil {
+					return fmt.Errorf("failed to render function clarity template: %w", err)
+				}
+				if err = os.WriteFile(templateOut, []byte(stackTemplate), 0600); err != nil {
+					return fmt.Errorf("failed to write function clarity template: %w", err)
+				}
+				fmt.Printf("function clarity template written to: %s\n", templateOut)
+				printCodeUpload(configForDeployment.Bucket, codeKey)
+				return nil
+			}
+			if update {
+				yes, err := cmd.Flags().GetBool("yes")
+				if err != nil {
+					return err
+				}
+				var confirm func() (bool, error)
+				if !yes {
+					confirm = confirmChanges
+				}
+				err = awsClient.UpdateFunctionClarity(viper.GetString("cloudtrail.name"), viper.GetString("publickey"), binaryPath, configForDeployment, "", confirm)
+				if err != nil {
+					return fmt.Errorf("failed to update function clarity: %w", err)
+				}
+				return nil
+			}
+			err = awsClient.DeployFunctionClarity(viper.GetString("cloudtrail.name"), viper.GetString("publickey"), binaryPath, configForDeployment, "")
 			if err != nil {
 				return fmt.Errorf("failed to deploy function clarity: %w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().Bool("update", false, "update the deployed function clarity, showing the changes before applying them")
+	cmd.Flags().Bool("yes", false, "apply the changes of --update without asking for confirmation")
+	cmd.Flags().String("template-out", "", "write the stack template to the file instead of deploying, to review it")
+	addVerifierBinaryFlag(cmd)
+	return cmd
+}
+
+func addVerifierBinaryFlag(cmd *cobra.Command) {
+	cmd.Flags().String("verifier-binary", "", "path of a locally built verifier lambda binary to deploy, "+
+		"defaults to the binary released with this version")
+}
+
+func verifierBinary(cmd *cobra.Command) (string, error) {
+	binaryPath, err := cmd.Flags().GetString("verifier-binary")
+	if err != nil {
+		return "", err
+	}
+	return clients.ResolveVerifierBinary(binaryPath)
+}
+
+func confirmChanges() (bool, error) {
+	var apply bool
+	if err := common.InputYesNoParameter("apply the changes? (y/n): ", &apply, false); err != nil {
+		return false, err
+	}
+	return apply, nil
+}
+
+func AwsDestroy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "delete function clarity from aws using config file",
+		Long: "delete the function clarity stack from aws, this command relies on a configuration file to exist under ~/.fc, " +
+			"the buckets created by the stack are emptied before it is deleted",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deleteCode, err := cmd.Flags().GetBool("delete-code")
+			if err != nil {
+				return err
+			}
+			bucket := viper.GetString("bucket")
+			if bucket == "" {
+				bucket = clients.FunctionClarityBucketName
+			}
+			awsClient := clients.NewAwsClientInit(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"))
+			if err = awsClient.DestroyFunctionClarity("", bucket, deleteCode); err != nil {
+				return fmt.Errorf("failed to destroy function clarity: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("delete-code", false, "also delete the verifier code archives (function-clarity-*.zip) from the deployment bucket, other objects such as signatures are kept")
 	return cmd
 }
 
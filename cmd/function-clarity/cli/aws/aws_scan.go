@@ -0,0 +1,132 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/scan"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AwsScan() *cobra.Command {
+	o := &options.VerifyOpts{}
+	var regions []string
+	var concurrency int
+	var output string
+	af := &accountsFlags{}
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "verify all the existing lambda functions of the account",
+		Long: "verify all the existing lambda functions of the account, or of the accounts given, in every region given, the included function regions, " +
+			"or the region of the configuration. The tag and region filters, the action and the notifications apply to each function, " +
+			"and a summary report is printed to stdout",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindAwsVerifyFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := common.ValidateOutputFormat(output); err != nil {
+				return err
+			}
+			o.Key = viper.GetString("publickey")
+			var err error
+			if o.Policy, err = common.Policy(); err != nil {
+				return err
+			}
+			store, err := common.SignatureStore(clients.NewS3SignatureStore(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), "", viper.GetString("region")))
+			if err != nil {
+				return err
+			}
+			if o.Policy != nil && o.Policy.HasKeylessSigners() && concurrency > 1 {
+				// keyless signers are verified with the process wide experimental mode enabled
+				fmt.Fprintln(os.Stderr, "the policy has keyless signers, functions are verified one at a time")
+				concurrency = 1
+			}
+			if len(regions) == 0 {
+				regions = viper.GetStringSlice("includedfuncregions")
+			}
+			if len(regions) == 0 && viper.GetString("region") != "" {
+				regions = []string{viper.GetString("region")}
+			}
+			if len(regions) == 0 {
+				return fmt.Errorf("no region to scan, set the regions flag or the region of the configuration")
+			}
+
+			accounts, newAccountClient, err := af.resolve(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			stdout, restore := common.RedirectStdout()
+			report := scan.NewReport(regions)
+			report.Accounts = accounts
+			if len(accounts) == 0 {
+				// the account of the credentials
+				accounts = []string{""}
+			}
+			var targets []scan.Target
+			for _, account := range accounts {
+				for _, region := range regions {
+					awsClient := newAccountClient(account, region)
+					functionArns, err := awsClient.ListFunctions(cmd.Context())
+					location := region
+					if account != "" {
+						location = account + "/" + region
+					}
+					if err != nil {
+						fmt.Printf("%v\n", err)
+						report.RegionErrors[location] = err.Error()
+						continue
+					}
+					fmt.Printf("found %d functions in: %s\n", len(functionArns), location)
+					for _, functionArn := range functionArns {
+						targets = append(targets, scan.Target{Client: awsClient, Function: functionArn, Region: region})
+					}
+				}
+			}
+			report.Run(targets, concurrency, func(target scan.Target) (*verify.VerificationResult, error) {
+				// the verification changes the options it is given, so each function gets its own copy
+				functionOpts := *o
+				return verify.Verify(target.Client, store, target.Function, &functionOpts, cmd.Context(), viper.GetString("action"),
+					viper.GetString("snsTopicArn"), viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"),
+					"", "")
+			})
+			restore()
+
+			if err = report.Write(stdout, output); err != nil {
+				return fmt.Errorf("failed to write scan report: %w", err)
+			}
+			if report.Failed+report.Errors > 0 || len(report.RegionErrors) > 0 {
+				return fmt.Errorf("%d of %d functions weren't verified", report.Failed+report.Errors, report.Total)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&regions, "regions", []string{}, "regions to scan (default: the included function regions, or the region)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 10, "maximum number of functions verified at the same time")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "print the scan report to stdout in the format (json|yaml|table), the progress is printed to stderr")
+	o.AddFlags(cmd)
+	initAwsVerifyFlags(cmd)
+	af.addFlags(cmd)
+	return cmd
+}
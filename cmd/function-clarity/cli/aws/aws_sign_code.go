@@ -18,6 +18,7 @@ package aws
 import (
 	"fmt"
 
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
 	"github.com/openclarity/functionclarity/pkg/clients"
 	o "github.com/openclarity/functionclarity/pkg/options"
@@ -51,11 +52,17 @@ func AwsSignCode() *cobra.Command {
 			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding privatekey: %w", err)
 			}
+			if err := common.BindSignatureStoreFlag(cmd); err != nil {
+				return err
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "")
-			return sign.SignAndUploadCode(awsClient, args[0], sbo, ro)
+			store, err := common.SignatureStore(clients.NewS3SignatureStore(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), "", viper.GetString("region")))
+			if err != nil {
+				return err
+			}
+			return sign.SignAndUploadCode(store, args[0], sbo, ro)
 		},
 	}
 	initAwsSignCodeFlags(cmd)
@@ -71,4 +78,5 @@ func initAwsSignCodeFlags(cmd *cobra.Command) {
 	cmd.Flags().String("region", "", "aws region to perform the operation against")
 	cmd.Flags().String("bucket", "", "s3 bucket to work against")
 	cmd.Flags().String("key", "", "private key")
+	common.AddSignatureStoreFlag(cmd)
 }
@@ -0,0 +1,93 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	o "github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/sign"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AwsSignLayer() *cobra.Command {
+	sbo := &o.SignBlobOptions{}
+	ro := &co.RootOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "layer",
+		Short: "sign the content of a lambda layer and upload its signature to aws",
+		Long: "sign the content of a lambda layer and upload its signature to aws. the layer is either a local zip file or folder,\n" +
+			"or the ARN of a published layer version, whose content is downloaded",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding privatekey: %w", err)
+			}
+			if err := common.BindSignatureStoreFlag(cmd); err != nil {
+				return err
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := common.SignatureStore(clients.NewS3SignatureStore(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), "", viper.GetString("region")))
+			if err != nil {
+				return err
+			}
+			codePath, err := getLayerCode(args[0])
+			defer utils.CleanDirectory(codePath)
+			if err != nil {
+				return fmt.Errorf("failed to get layer content: %s: %w", args[0], err)
+			}
+			return sign.SignAndUploadCode(store, codePath, sbo, ro)
+		},
+	}
+	initAwsSignCodeFlags(cmd)
+	sbo.AddFlags(cmd)
+	ro.AddFlags(cmd)
+	return cmd
+}
+
+// getLayerCode extracts the content of the layer to a temporary folder, the layer is downloaded when given by its ARN.
+func getLayerCode(layer string) (string, error) {
+	if !arn.IsARN(layer) {
+		return clients.NewLocalClient().GetFuncCode(layer)
+	}
+	layerArn, err := arn.Parse(layer)
+	if err != nil {
+		return "", err
+	}
+	client := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"), layerArn.Region)
+	return client.GetLayerCode(layer)
+}
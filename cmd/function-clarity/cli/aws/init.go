@@ -16,52 +16,108 @@
 package aws
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
 	"github.com/openclarity/functionclarity/pkg/clients"
 	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 )
 
-func ReceiveParameters(i *i.AWSInput) error {
-	awsClient, err := receiveAndValidateCredentials(i)
+// initParameters are the parameters init asks for, by the keys of their flags.
+var initParameters = []common.InitParameter{
+	{Key: "aws-access-key", Usage: "aws access key"},
+	{Key: "aws-secret-key", Usage: "aws secret key"},
+	{Key: "region", Usage: "aws region to deploy to"},
+	{Key: "bucket", Usage: "default bucket, functionclarity is created when empty"},
+	{Key: "included-func-tags", Usage: "comma separated tag keys of functions to include in the verification, all when empty"},
+	{Key: "included-func-regions", Usage: "comma separated regions of functions to include in the verification, all when empty"},
+	{Key: "action", Usage: "post verification action: detect or block, none when empty"},
+	{Key: "policy", Usage: "path to verification policy file"},
+	{Key: "reverify-schedule", Usage: "schedule expression to re-verify all the functions periodically, i.e: rate(1 day)"},
+	{Key: "sns-topic-arn", Usage: "SNS topic ARN to notify when signature verification fails"},
+	{Key: "member-role-name", Usage: "name of the role to assume in the other accounts of the organization"},
+	{Key: "trusted-principals", Usage: "comma separated ARNs of the users or roles, besides the verifier function, that may assume the role of the other accounts"},
+	{Key: "reverify-accounts", Usage: "comma separated ids of the other accounts of the organization whose functions are re-verified on schedule"},
+	{Key: "ingestion", Usage: "how the verifier receives function events: cloudtrail-logs or eventbridge"},
+	{Key: "cloudtrail-name", Usage: "existing CloudTrail trail to use, a trail is created when empty"},
+	{Key: "keyless", Usage: "work in keyless mode", Bool: true},
+	{Key: "public-key", Usage: "path to custom public key for code signing, a key pair is generated when empty"},
+	{Key: "private-key", Usage: "path to custom private key for code signing, compulsory with a public key"},
+}
+
+func ReceiveParameters(i *i.AWSInput, p *common.Prompter) error {
+	compulsory := []string{"aws-access-key", "aws-secret-key", "region"}
+	if p.Has("public-key") {
+		compulsory = append(compulsory, "private-key")
+	}
+	if err := p.Require(compulsory...); err != nil {
+		return err
+	}
+
+	awsClient, err := receiveAndValidateCredentials(i, p)
 	if err != nil {
 		return err
 	}
 
-	if err := receiveAndValidateBucketName(i, awsClient); err != nil {
+	if err := receiveAndValidateBucketName(i, awsClient, p); err != nil {
+		return err
+	}
+
+	if err := p.StringArray("included-func-tags", "enter tag keys of functions to include in the verification (leave empty to include all): ", &i.IncludedFuncTagKeys, true); err != nil {
+		return err
+	}
+	if err := p.StringArray("included-func-regions", "enter the function regions to include in the verification, i.e: us-east-1,us-west-1 (leave empty to include all): ", &i.IncludedFuncRegions, true); err != nil {
+		return err
+	}
+
+	if err := p.MultipleChoice("action", "post verification action", &i.Action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
 		return err
 	}
 
-	if err := inputStringArrayParameter("enter tag keys of functions to include in the verification (leave empty to include all): ", &i.IncludedFuncTagKeys, true); err != nil {
+	if err := p.Policy("policy", &i.Policy); err != nil {
 		return err
 	}
-	if err := inputStringArrayParameter("enter the function regions to include in the verification, i.e: us-east-1,us-west-1 (leave empty to include all): ", &i.IncludedFuncRegions, true); err != nil {
+
+	if err := p.String("reverify-schedule", "enter a schedule expression to re-verify all the functions periodically, i.e: rate(1 day) (leave empty to verify on function events only): ", &i.ReverifySchedule, true); err != nil {
 		return err
 	}
 
-	if err := inputMultipleChoiceParameter("post verification action", &i.Action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
+	if err := receiveAndValidateSNSTopicArn(i, awsClient, p); err != nil {
 		return err
 	}
 
-	if err := receiveAndValidateSNSTopicArn(i, awsClient); err != nil {
+	if err := p.String("member-role-name", "enter the name of the role to assume in the other accounts of the organization to verify their functions (leave empty to verify the functions of this account only): ", &i.MemberRoleName, true); err != nil {
 		return err
 	}
+	if i.MemberRoleName != "" {
+		if err := p.StringArray("trusted-principals", "enter the ARNs of the users or roles running the verify and scan commands on the other accounts, i.e: arn:aws:iam::111111111111:role/Security (leave empty to trust the verifier function only): ", &i.TrustedPrincipals, true); err != nil {
+			return err
+		}
+	}
+	if i.ReverifySchedule != "" && i.MemberRoleName != "" {
+		if err := p.StringArray("reverify-accounts", "enter the ids of the other accounts of the organization to re-verify on schedule, i.e: 111111111111,222222222222 (leave empty to re-verify the functions of this account only): ", &i.ReverifyAccounts, true); err != nil {
+			return err
+		}
+	}
 
-	if err := receiveAndValidateCloudTrail(i, awsClient); err != nil {
+	if err := receiveAndValidateIngestion(i, p); err != nil {
 		return err
 	}
 
-	if err := inputYesNoParameter("do you want to work in keyless mode (y/n): ", &i.IsKeyless, false); err != nil {
+	if !i.UsesEventBridge() {
+		if err := receiveAndValidateCloudTrail(i, awsClient, p); err != nil {
+			return err
+		}
+	}
+
+	if err := p.YesNo("keyless", "do you want to work in keyless mode (y/n): ", &i.IsKeyless, false); err != nil {
 		return err
 	}
 
 	if !i.IsKeyless {
-		if err := inputKeyPair(i); err != nil {
+		if err := inputKeyPair(i, p); err != nil {
 			return err
 		}
 	}
@@ -83,19 +139,29 @@ func digestParameters(i *i.AWSInput) error {
 	return nil
 }
 
-func receiveAndValidateCloudTrail(i *i.AWSInput, awsClient *clients.AwsClient) error {
-	if err := inputStringParameter("is there existing trail in CloudTrail (in the region selected above) which you would like to use? (if no, please press enter): ", &i.CloudTrail.Name, true); err != nil {
+func receiveAndValidateIngestion(i *i.AWSInput, p *common.Prompter) error {
+	if err := p.String("ingestion", "enter how the verifier receives function events: cloudtrail-logs or eventbridge (leave empty for cloudtrail-logs): ", &i.Ingestion, true); err != nil {
+		return err
+	}
+	if err := i.ValidateIngestion(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	return nil
+}
+
+func receiveAndValidateCloudTrail(i *i.AWSInput, awsClient *clients.AwsClient, p *common.Prompter) error {
+	if err := p.String("cloudtrail-name", "is there existing trail in CloudTrail (in the region selected above) which you would like to use? (if no, please press enter): ", &i.CloudTrail.Name, true); err != nil {
 		return err
 	}
 	trailName := i.CloudTrail.Name
 	if trailName != "" && !awsClient.IsCloudTrailExist(trailName) {
-		return fmt.Errorf("validation error: SNS topic doesn't exist or you don't have permissions")
+		return fmt.Errorf("validation error: trail doesn't exist or you don't have permissions")
 	}
 	return nil
 }
 
-func receiveAndValidateSNSTopicArn(i *i.AWSInput, awsClient *clients.AwsClient) error {
-	if err := inputStringParameter("enter SNS arn if you would like to be notified when signature verification fails, otherwise press enter: ", &i.SnsTopicArn, true); err != nil {
+func receiveAndValidateSNSTopicArn(i *i.AWSInput, awsClient *clients.AwsClient, p *common.Prompter) error {
+	if err := p.String("sns-topic-arn", "enter SNS arn if you would like to be notified when signature verification fails, otherwise press enter: ", &i.SnsTopicArn, true); err != nil {
 		return err
 	}
 	if i.SnsTopicArn != "" && !awsClient.IsSnsTopicExist(i.SnsTopicArn) {
@@ -104,8 +170,8 @@ func receiveAndValidateSNSTopicArn(i *i.AWSInput, awsClient *clients.AwsClient)
 	return nil
 }
 
-func receiveAndValidateBucketName(i *i.AWSInput, awsClient *clients.AwsClient) error {
-	if err := inputStringParameter("enter default bucket (you can leave empty and a bucket with name functionclarity will be created): ", &i.Bucket, true); err != nil {
+func receiveAndValidateBucketName(i *i.AWSInput, awsClient *clients.AwsClient, p *common.Prompter) error {
+	if err := p.String("bucket", "enter default bucket (you can leave empty and a bucket with name functionclarity will be created): ", &i.Bucket, true); err != nil {
 		return err
 	}
 	if i.Bucket != "" && !awsClient.IsBucketExist(i.Bucket) {
@@ -114,14 +180,14 @@ func receiveAndValidateBucketName(i *i.AWSInput, awsClient *clients.AwsClient) e
 	return nil
 }
 
-func receiveAndValidateCredentials(i *i.AWSInput) (*clients.AwsClient, error) {
-	if err := inputStringParameter("enter Access Key: ", &i.AccessKey, false); err != nil {
+func receiveAndValidateCredentials(i *i.AWSInput, p *common.Prompter) (*clients.AwsClient, error) {
+	if err := p.String("aws-access-key", "enter Access Key: ", &i.AccessKey, false); err != nil {
 		return nil, err
 	}
-	if err := inputStringParameter("enter Secret Key: ", &i.SecretKey, false); err != nil {
+	if err := p.String("aws-secret-key", "enter Secret Key: ", &i.SecretKey, false); err != nil {
 		return nil, err
 	}
-	if err := inputStringParameter("enter region: ", &i.Region, false); err != nil {
+	if err := p.String("region", "enter region: ", &i.Region, false); err != nil {
 		return nil, err
 	}
 	awsClient := clients.NewAwsClientInit(i.AccessKey, i.SecretKey, i.Region)
@@ -131,95 +197,14 @@ func receiveAndValidateCredentials(i *i.AWSInput) (*clients.AwsClient, error) {
 	return awsClient, nil
 }
 
-func inputKeyPair(i *i.AWSInput) error {
-	if err := inputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
+func inputKeyPair(i *i.AWSInput, p *common.Prompter) error {
+	if err := p.String("public-key", "enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
 		return err
 	}
 	if i.PublicKey != "" {
-		if err := inputStringParameter("enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
+		if err := p.String("private-key", "enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
 			return err
 		}
 	}
 	return nil
 }
-
-func inputStringParameter(q string, p *string, em bool) error {
-	fmt.Print(q)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	input = strings.TrimSuffix(input, "\n")
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
-	}
-	*p = strings.TrimSuffix(input, "\n")
-	return err
-}
-
-func inputStringArrayParameter(q string, p *[]string, em bool) error {
-	fmt.Print(q)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	input = strings.TrimSuffix(input, "\n")
-	input = strings.TrimSpace(input)
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
-	}
-	if input == "" {
-		return nil
-	}
-	*p = strings.Split(input, ",")
-	for index := range *p {
-		(*p)[index] = strings.TrimSpace((*p)[index])
-	}
-	return err
-}
-
-func inputYesNoParameter(q string, p *bool, em bool) error {
-	fmt.Print(q)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	input = strings.TrimSuffix(input, "\n")
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
-	}
-	input = strings.ToLower(strings.TrimSpace(input))
-	if input == "y" {
-		*p = true
-	} else if input == "n" {
-		*p = false
-	}
-	return err
-}
-
-func inputMultipleChoiceParameter(action string, p *string, m map[string]string, em bool) error {
-	message := "select " + action + " : "
-	for key, element := range m {
-		message = message + "(" + key + ")" + " for " + element + "; "
-	}
-	if em {
-		message = message + "leave empty for no " + action + " to perform: "
-	}
-	fmt.Print(message)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return err
-	}
-	input = strings.TrimSuffix(input, "\n")
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
-	}
-	for key, element := range m {
-		if input == key {
-			*p = element
-		}
-	}
-	if input == "" {
-		if !em {
-			return fmt.Errorf("this is a compulsory parameter")
-		} else {
-			*p = ""
-		}
-	}
-	return nil
-}
@@ -0,0 +1,195 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	opt "github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+func AzureSign() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "sign code/image and upload to azure",
+	}
+	cmd.AddCommand(AzureSignCode())
+	cmd.AddCommand(common.SignImage())
+	return cmd
+}
+
+func AzureVerify() *cobra.Command {
+	o := &options.VerifyOpts{}
+	var kuduEndpoint string
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "verify function app identity",
+		Long:  "verify function app identity, the function app is given by its resource id or by <resource group>/<function app name>",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindAzureFlags(cmd); err != nil {
+				return err
+			}
+			if err := common.BindPolicyFlag(cmd); err != nil {
+				return err
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
+				return fmt.Errorf("error binding action: %w", err)
+			}
+			if err := viper.BindPFlag("blockmode", cmd.Flags().Lookup("block-mode")); err != nil {
+				return fmt.Errorf("error binding blockmode: %w", err)
+			}
+			if err := viper.BindPFlag("includedfunctagkeys", cmd.Flags().Lookup("included-func-tags")); err != nil {
+				return fmt.Errorf("error binding includedfunctagkeys: %w", err)
+			}
+			if err := viper.BindPFlag("includedfuncregions", cmd.Flags().Lookup("included-func-regions")); err != nil {
+				return fmt.Errorf("error binding includedfuncregions: %w", err)
+			}
+			if err := viper.BindPFlag("eventgridtopicendpoint", cmd.Flags().Lookup("eventgrid-topic-endpoint")); err != nil {
+				return fmt.Errorf("error binding eventgridtopicendpoint: %w", err)
+			}
+			if err := viper.BindPFlag("eventgridkey", cmd.Flags().Lookup("eventgrid-key")); err != nil {
+				return fmt.Errorf("error binding eventgridkey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			var err error
+			if o.Policy, err = common.Policy(); err != nil {
+				return err
+			}
+			config := azureConfigFromViper()
+			config.KuduEndpoint = kuduEndpoint
+			config.BlockMode = viper.GetString("blockmode")
+			config.EventGridKey = viper.GetString("eventgridkey")
+			azureClient := clients.NewAzureClient(config)
+			store, err := common.SignatureStore(azureClient)
+			if err != nil {
+				return err
+			}
+			_, err = common.RunVerify(cmd, func() (*verify.VerificationResult, error) {
+				return verify.Verify(azureClient, store, args[0], o, cmd.Context(), viper.GetString("action"),
+					viper.GetString("eventgridtopicendpoint"), viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"),
+					"", "")
+			})
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&kuduEndpoint, "kudu-endpoint", "", "kudu endpoint format of the function app name (default: resolved from the function app)")
+	o.AddFlags(cmd)
+	initAzureFlags(cmd)
+	cmd.Flags().String("key", "", "public key")
+	cmd.Flags().String("action", "", "action to perform upon validation result")
+	cmd.Flags().String("block-mode", clients.AzureBlockModeStop, "how to block a function app: stop (stop the function app) or disable-triggers (set AzureWebJobsDisabled)")
+	cmd.Flags().StringSlice("included-func-tags", []string{}, "function tags to include when verifying")
+	cmd.Flags().StringSlice("included-func-regions", []string{}, "function regions to include when verifying")
+	cmd.Flags().String("eventgrid-topic-endpoint", "", "event grid topic endpoint for notifications")
+	cmd.Flags().String("eventgrid-key", "", "event grid topic access key, an access token is used if empty")
+	common.AddPolicyFlag(cmd)
+	common.AddOutputFlag(cmd)
+	return cmd
+}
+
+func AzureInit() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "initialize configuration for azure",
+		Long:  "initialize configuration for azure, the signatures container is created if it doesn't exist",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input i.AzureInput
+			if err := ReceiveParameters(&input); err != nil {
+				return err
+			}
+			d, err := yaml.Marshal(&input)
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			f, err := os.Create(utils.HomeDir + "/.fc")
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			defer f.Close()
+			if _, err = f.Write(d); err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func azureConfigFromViper() clients.AzureConfig {
+	return clients.AzureConfig{
+		SubscriptionId:     viper.GetString("subscriptionid"),
+		TenantId:           viper.GetString("tenantid"),
+		ClientId:           viper.GetString("clientid"),
+		ClientSecret:       viper.GetString("clientsecret"),
+		StorageAccount:     viper.GetString("storageaccount"),
+		StorageKey:         viper.GetString("storagekey"),
+		Container:          viper.GetString("container"),
+		ManagementEndpoint: viper.GetString("managementendpoint"),
+		StorageEndpoint:    viper.GetString("storageendpoint"),
+	}
+}
+
+func bindAzureFlags(cmd *cobra.Command) error {
+	flags := map[string]string{
+		"subscriptionid":     "subscription-id",
+		"tenantid":           "tenant-id",
+		"clientid":           "client-id",
+		"clientsecret":       "client-secret",
+		"storageaccount":     "storage-account",
+		"storagekey":         "storage-key",
+		"container":          "container",
+		"managementendpoint": "management-endpoint",
+		"storageendpoint":    "storage-endpoint",
+	}
+	for key, flag := range flags {
+		if err := viper.BindPFlag(key, cmd.Flags().Lookup(flag)); err != nil {
+			return fmt.Errorf("error binding %s: %w", key, err)
+		}
+	}
+	return common.BindSignatureStoreFlag(cmd)
+}
+
+func initAzureFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("subscription-id", "", "azure subscription id")
+	cmd.Flags().String("tenant-id", "", "azure tenant id")
+	cmd.Flags().String("client-id", "", "azure service principal client id")
+	cmd.Flags().String("client-secret", "", "azure service principal client secret, the managed identity is used if empty")
+	cmd.Flags().String("storage-account", "", "azure storage account of the signatures container")
+	cmd.Flags().String("storage-key", "", "azure storage account key, an access token is used if empty")
+	cmd.Flags().String("container", "", "blob container to work against (default: functionclarity)")
+	cmd.Flags().String("management-endpoint", "", "azure resource manager endpoint (default: https://management.azure.com)")
+	cmd.Flags().String("storage-endpoint", "", "blob storage endpoint, i.e. of Azurite (default: https://<storage account>.blob.core.windows.net)")
+	common.AddSignatureStoreFlag(cmd)
+}
@@ -0,0 +1,60 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	o "github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/sign"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AzureSignCode() *cobra.Command {
+	sbo := &o.SignBlobOptions{}
+	ro := &co.RootOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "code",
+		Short: "sign code content and upload its signature to azure blob storage",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindAzureFlags(cmd); err != nil {
+				return err
+			}
+			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding privatekey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := common.SignatureStore(clients.NewAzureClient(azureConfigFromViper()))
+			if err != nil {
+				return err
+			}
+			return sign.SignAndUploadCode(store, args[0], sbo, ro)
+		},
+	}
+	initAzureFlags(cmd)
+	cmd.Flags().String("key", "", "private key")
+	sbo.AddFlags(cmd)
+	ro.AddFlags(cmd)
+	return cmd
+}
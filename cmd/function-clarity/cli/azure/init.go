@@ -0,0 +1,155 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+)
+
+func ReceiveParameters(i *i.AzureInput) error {
+	if err := receiveAndValidateCredentials(i); err != nil {
+		return err
+	}
+
+	if err := receiveAndValidateContainer(i); err != nil {
+		return err
+	}
+
+	if err := common.InputStringArrayParameter("enter tag keys of function apps to include in the verification (leave empty to include all): ", &i.IncludedFuncTagKeys, true); err != nil {
+		return err
+	}
+	if err := common.InputStringArrayParameter("enter the function app regions to include in the verification, i.e: eastus,westeurope (leave empty to include all): ", &i.IncludedFuncRegions, true); err != nil {
+		return err
+	}
+
+	if err := common.InputMultipleChoiceParameter("post verification action", &i.Action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
+		return err
+	}
+	if i.Action == "block" {
+		if err := common.InputMultipleChoiceParameter("block mode", &i.BlockMode, map[string]string{"1": clients.AzureBlockModeStop, "2": clients.AzureBlockModeDisableTriggers}, false); err != nil {
+			return err
+		}
+	}
+
+	if err := common.InputStringParameter("enter event grid topic endpoint if you would like to be notified when signature verification fails, otherwise press enter: ", &i.EventGridTopicEndpoint, true); err != nil {
+		return err
+	}
+	if i.EventGridTopicEndpoint != "" {
+		if err := common.InputStringParameter("enter event grid topic access key (leave empty to use the service principal): ", &i.EventGridKey, true); err != nil {
+			return err
+		}
+	}
+
+	if err := common.InputYesNoParameter("do you want to work in keyless mode (y/n): ", &i.IsKeyless, false); err != nil {
+		return err
+	}
+
+	if !i.IsKeyless {
+		if err := inputKeyPair(i); err != nil {
+			return err
+		}
+	}
+
+	if err := digestParameters(i); err != nil {
+		return err
+	}
+	return nil
+}
+
+func digestParameters(i *i.AzureInput) error {
+	if i.PublicKey == "" && !i.IsKeyless {
+		if err := generate.GenerateKeyPairCmd(context.Background(), "", []string{}); err != nil {
+			return err
+		}
+		i.PublicKey = "cosign.pub"
+		i.PrivateKey = "cosign.key"
+	}
+	return nil
+}
+
+func receiveAndValidateContainer(i *i.AzureInput) error {
+	if err := common.InputStringParameter("enter storage account for signatures: ", &i.StorageAccount, false); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter storage account key (leave empty to use the service principal): ", &i.StorageKey, true); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter signatures container (you can leave empty and a container with name functionclarity will be created): ", &i.Container, true); err != nil {
+		return err
+	}
+	if i.Container == "" {
+		i.Container = clients.AzureFunctionClarityContainerName
+	}
+	azureClient := clients.NewAzureClient(clients.AzureConfig{
+		SubscriptionId:     i.SubscriptionId,
+		TenantId:           i.TenantId,
+		ClientId:           i.ClientId,
+		ClientSecret:       i.ClientSecret,
+		StorageAccount:     i.StorageAccount,
+		StorageKey:         i.StorageKey,
+		Container:          i.Container,
+		ManagementEndpoint: i.ManagementEndpoint,
+		StorageEndpoint:    i.StorageEndpoint,
+	})
+	if err := azureClient.CreateContainer(); err != nil {
+		return fmt.Errorf("validation error: failed to create container or you don't have permissions: %w", err)
+	}
+	return nil
+}
+
+func receiveAndValidateCredentials(i *i.AzureInput) error {
+	if err := common.InputStringParameter("enter subscription id: ", &i.SubscriptionId, false); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter tenant id: ", &i.TenantId, false); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter service principal client id: ", &i.ClientId, false); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter service principal client secret: ", &i.ClientSecret, false); err != nil {
+		return err
+	}
+	azureClient := clients.NewAzureClient(clients.AzureConfig{
+		SubscriptionId:     i.SubscriptionId,
+		TenantId:           i.TenantId,
+		ClientId:           i.ClientId,
+		ClientSecret:       i.ClientSecret,
+		ManagementEndpoint: i.ManagementEndpoint,
+	})
+	if credentials := azureClient.ValidateCredentials(); !credentials {
+		return fmt.Errorf("validation error: credentials aren't valid")
+	}
+	return nil
+}
+
+func inputKeyPair(i *i.AzureInput) error {
+	if err := common.InputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
+		return err
+	}
+	if i.PublicKey != "" {
+		if err := common.InputStringParameter("enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -30,10 +30,12 @@ func New() *cobra.Command {
 
 	cmd.AddCommand(Sign())
 	cmd.AddCommand(Verify())
+	cmd.AddCommand(Scan())
 	cmd.AddCommand(cli.GenerateKeyPair())
 	cmd.AddCommand(cli.ImportKeyPair())
 	cmd.AddCommand(Init())
 	cmd.AddCommand(Deploy())
+	cmd.AddCommand(Destroy())
 	cmd.AddCommand(UpdateFuncConfig())
 	cobra.OnInitialize(options.CobraInit)
 	return cmd
@@ -0,0 +1,122 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// InitParameter is a parameter of an init command, answered by its flag, environment variable or answers file entry
+// when set, instead of being asked for.
+type InitParameter struct {
+	Key   string
+	Usage string
+	Bool  bool
+}
+
+// EnvName returns the environment variable of a parameter, i.e. FC_AWS_ACCESS_KEY for aws-access-key.
+func EnvName(key string) string {
+	return "FC_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// AddInitFlags adds the flags of the parameters, and the flags answering them without prompting.
+func AddInitFlags(cmd *cobra.Command, parameters []InitParameter) {
+	for _, parameter := range parameters {
+		usage := fmt.Sprintf("%s (env %s)", parameter.Usage, EnvName(parameter.Key))
+		if parameter.Bool {
+			cmd.Flags().Bool(parameter.Key, false, usage)
+		} else {
+			cmd.Flags().String(parameter.Key, "", usage)
+		}
+	}
+	cmd.Flags().String("answers-file", "", "yaml file answering the parameters by their flag names, i.e. region: us-east-1")
+	cmd.Flags().Bool("non-interactive", false, fmt.Sprintf("fail on missing compulsory parameters instead of asking for them (env %s)", EnvName("non-interactive")))
+}
+
+// InitPrompter returns the prompter of the parameters, answering them from their flags first, then from their
+// environment variables, then from the answers file.
+func InitPrompter(cmd *cobra.Command, parameters []InitParameter) (*Prompter, error) {
+	known := map[string]bool{}
+	for _, parameter := range parameters {
+		known[parameter.Key] = true
+	}
+	fileAnswers := map[string]string{}
+	answersFile, err := cmd.Flags().GetString("answers-file")
+	if err != nil {
+		return nil, err
+	}
+	if answersFile != "" {
+		if fileAnswers, err = readAnswersFile(answersFile, known); err != nil {
+			return nil, err
+		}
+	}
+	answers := func(key string) (string, bool) {
+		if flag := cmd.Flags().Lookup(key); flag != nil && flag.Changed {
+			return flag.Value.String(), true
+		}
+		if value, exist := os.LookupEnv(EnvName(key)); exist {
+			return value, true
+		}
+		value, exist := fileAnswers[key]
+		return value, exist
+	}
+	interactive := true
+	if value, exist := answers("non-interactive"); exist {
+		nonInteractive, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid non-interactive: %s, expected true or false", value)
+		}
+		interactive = !nonInteractive
+	}
+	return StdinPrompter(answers, interactive), nil
+}
+
+// readAnswersFile reads the answers of a yaml file, a list answer is joined with commas as it is typed when asked.
+func readAnswersFile(path string, known map[string]bool) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers file: %w", err)
+	}
+	var values map[string]interface{}
+	if err = yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse answers file: %w", err)
+	}
+	answers := map[string]string{}
+	for key, value := range values {
+		if !known[key] && key != "non-interactive" {
+			return nil, fmt.Errorf("unknown parameter in answers file: %s", key)
+		}
+		switch v := value.(type) {
+		case nil:
+			answers[key] = ""
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			answers[key] = strings.Join(items, ",")
+		default:
+			answers[key] = fmt.Sprint(v)
+		}
+	}
+	return answers, nil
+}
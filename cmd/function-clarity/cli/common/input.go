@@ -0,0 +1,227 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Answers looks up the preset answer of a parameter by its key, i.e. from a flag, an environment variable or a file.
+type Answers func(key string) (string, bool)
+
+// Prompter asks for the parameters of the init commands. A parameter with a preset answer isn't asked for, and a
+// prompter that isn't interactive never reads its input, so a missing compulsory parameter fails instead.
+type Prompter struct {
+	reader      *bufio.Reader
+	out         io.Writer
+	answers     Answers
+	interactive bool
+}
+
+func NewPrompter(in io.Reader, out io.Writer, answers Answers, interactive bool) *Prompter {
+	if answers == nil {
+		answers = func(string) (string, bool) { return "", false }
+	}
+	return &Prompter{reader: bufio.NewReader(in), out: out, answers: answers, interactive: interactive}
+}
+
+// stdinPrompter is shared by the prompts of the commands, since a reader buffers the lines after the one it returns.
+var stdinPrompter = NewPrompter(os.Stdin, os.Stdout, nil, true)
+
+// StdinPrompter returns a prompter of the standard input with the preset answers, reading the shared reader of the
+// prompts when interactive.
+func StdinPrompter(answers Answers, interactive bool) *Prompter {
+	return NewPrompter(stdinPrompter.reader, os.Stdout, answers, interactive)
+}
+
+// Interactive tells if the prompter asks for the parameters without a preset answer.
+func (p *Prompter) Interactive() bool {
+	return p.interactive
+}
+
+// Has tells if the parameter has a preset answer.
+func (p *Prompter) Has(key string) bool {
+	value, exist := p.lookup(key)
+	return exist && value != ""
+}
+
+// Require fails with the list of the parameters without a preset answer, when the prompter isn't interactive.
+func (p *Prompter) Require(keys ...string) error {
+	if p.interactive {
+		return nil
+	}
+	var missing []string
+	for _, key := range keys {
+		if !p.Has(key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		for index, key := range missing {
+			missing[index] = "--" + key + " (" + EnvName(key) + ")"
+		}
+		return fmt.Errorf("missing compulsory parameters: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (p *Prompter) lookup(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	value, exist := p.answers(key)
+	return strings.TrimSpace(value), exist
+}
+
+// answer returns the preset answer of the parameter, or reads it after asking the question.
+func (p *Prompter) answer(key string, question string, em bool) (string, error) {
+	if value, exist := p.lookup(key); exist {
+		if !em && value == "" {
+			return "", fmt.Errorf("this is a compulsory parameter: %s", key)
+		}
+		return value, nil
+	}
+	if !p.interactive {
+		if !em {
+			return "", fmt.Errorf("this is a compulsory parameter: %s", key)
+		}
+		return "", nil
+	}
+	fmt.Fprint(p.out, question)
+	input, err := p.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && input != "") {
+		return "", err
+	}
+	input = strings.TrimSuffix(strings.TrimSuffix(input, "\n"), "\r")
+	if !em && input == "" {
+		return "", fmt.Errorf("this is a compulsory parameter")
+	}
+	return input, nil
+}
+
+func (p *Prompter) String(key string, q string, value *string, em bool) error {
+	input, err := p.answer(key, q, em)
+	if err != nil {
+		return err
+	}
+	*value = input
+	return nil
+}
+
+func (p *Prompter) StringArray(key string, q string, value *[]string, em bool) error {
+	input, err := p.answer(key, q, em)
+	if err != nil {
+		return err
+	}
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil
+	}
+	*value = strings.Split(input, ",")
+	for index := range *value {
+		(*value)[index] = strings.TrimSpace((*value)[index])
+	}
+	return nil
+}
+
+// YesNo asks a yes or no question, a parameter left unset keeps its default when the prompter isn't interactive.
+func (p *Prompter) YesNo(key string, q string, value *bool, em bool) error {
+	_, preset := p.lookup(key)
+	if !preset && !p.interactive {
+		return nil
+	}
+	input, err := p.answer(key, q, em)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "y", "yes", "true":
+		*value = true
+	case "n", "no", "false":
+		*value = false
+	default:
+		if preset {
+			return fmt.Errorf("invalid answer: %s of: %s, expected y or n", input, key)
+		}
+	}
+	return nil
+}
+
+// MultipleChoice asks to select one of the choices by its key, a preset answer may also be the choice itself.
+func (p *Prompter) MultipleChoice(key string, action string, value *string, choices map[string]string, em bool) error {
+	keys := make([]string, 0, len(choices))
+	for choiceKey := range choices {
+		keys = append(keys, choiceKey)
+	}
+	sort.Strings(keys)
+	message := "select " + action + " : "
+	for _, choiceKey := range keys {
+		message = message + "(" + choiceKey + ")" + " for " + choices[choiceKey] + "; "
+	}
+	if em {
+		message = message + "leave empty for no " + action + " to perform: "
+	}
+	_, preset := p.lookup(key)
+	input, err := p.answer(key, message, em)
+	if err != nil {
+		return err
+	}
+	if input == "" {
+		*value = ""
+		return nil
+	}
+	for choiceKey, choice := range choices {
+		if input == choiceKey || (preset && input == choice) {
+			*value = choice
+			return nil
+		}
+	}
+	if preset {
+		return fmt.Errorf("invalid answer: %s of: %s, expected one of: %s", input, key, strings.Join(sortedValues(choices), ", "))
+	}
+	return nil
+}
+
+func sortedValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func InputStringParameter(q string, p *string, em bool) error {
+	return stdinPrompter.String("", q, p, em)
+}
+
+func InputStringArrayParameter(q string, p *[]string, em bool) error {
+	return stdinPrompter.StringArray("", q, p, em)
+}
+
+func InputYesNoParameter(q string, p *bool, em bool) error {
+	return stdinPrompter.YesNo("", q, p, em)
+}
+
+func InputMultipleChoiceParameter(action string, p *string, m map[string]string, em bool) error {
+	return stdinPrompter.MultipleChoice("", action, p, m, em)
+}
@@ -0,0 +1,150 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func presetAnswers(values map[string]string) Answers {
+	return func(key string) (string, bool) {
+		value, exist := values[key]
+		return value, exist
+	}
+}
+
+func TestPrompterReadsInput(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := NewPrompter(strings.NewReader("AKIA\ntag1, tag2\n2\ny\n"), out, nil, true)
+	var accessKey string
+	var tags []string
+	var action string
+	var keyless bool
+	if err := p.String("aws-access-key", "enter Access Key: ", &accessKey, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.StringArray("included-func-tags", "enter tags: ", &tags, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MultipleChoice("action", "post verification action", &action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.YesNo("keyless", "keyless (y/n): ", &keyless, false); err != nil {
+		t.Fatal(err)
+	}
+	if accessKey != "AKIA" || len(tags) != 2 || tags[1] != "tag2" || action != "block" || !keyless {
+		t.Fatalf("unexpected answers: %s %v %s %t", accessKey, tags, action, keyless)
+	}
+	if !strings.Contains(out.String(), "enter Access Key: ") || !strings.Contains(out.String(), "(1) for detect; (2) for block; ") {
+		t.Fatalf("unexpected prompts: %s", out.String())
+	}
+}
+
+func TestPrompterPresetAnswers(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := NewPrompter(strings.NewReader("typed\n"), out, presetAnswers(map[string]string{"region": "us-east-1", "action": "detect"}), true)
+	var region, action, bucket string
+	if err := p.String("region", "enter region: ", &region, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MultipleChoice("action", "post verification action", &action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.String("bucket", "enter bucket: ", &bucket, true); err != nil {
+		t.Fatal(err)
+	}
+	if region != "us-east-1" || action != "detect" || bucket != "typed" {
+		t.Fatalf("unexpected answers: %s %s %s", region, action, bucket)
+	}
+	if out.String() != "enter bucket: " {
+		t.Fatalf("expected only the unanswered parameter to be asked, got: %s", out.String())
+	}
+}
+
+func TestPrompterNonInteractive(t *testing.T) {
+	p := NewPrompter(strings.NewReader("never read\n"), &bytes.Buffer{}, presetAnswers(map[string]string{"region": "us-east-1"}), false)
+	err := p.Require("aws-access-key", "aws-secret-key", "region")
+	if err == nil || err.Error() != "missing compulsory parameters: --aws-access-key (FC_AWS_ACCESS_KEY), --aws-secret-key (FC_AWS_SECRET_KEY)" {
+		t.Fatalf("expected the missing parameters, got: %v", err)
+	}
+	var bucket string
+	if err := p.String("bucket", "enter bucket: ", &bucket, true); err != nil || bucket != "" {
+		t.Fatalf("expected an empty optional parameter, got: %s, %v", bucket, err)
+	}
+	keyless := true
+	if err := p.YesNo("keyless", "keyless (y/n): ", &keyless, false); err != nil || !keyless {
+		t.Fatalf("expected the default to be kept, got: %t, %v", keyless, err)
+	}
+}
+
+func TestPrompterInvalidPresetAnswers(t *testing.T) {
+	p := NewPrompter(strings.NewReader(""), &bytes.Buffer{}, presetAnswers(map[string]string{"keyless": "maybe", "action": "warn"}), false)
+	var keyless bool
+	if err := p.YesNo("keyless", "keyless (y/n): ", &keyless, false); err == nil {
+		t.Fatal("expected an invalid yes or no answer to fail")
+	}
+	var action string
+	err := p.MultipleChoice("action", "post verification action", &action, map[string]string{"1": "detect", "2": "block"}, true)
+	if err == nil || !strings.Contains(err.Error(), "expected one of: block, detect") {
+		t.Fatalf("expected an invalid choice to fail, got: %v", err)
+	}
+}
+
+func TestInitPrompter(t *testing.T) {
+	parameters := []InitParameter{{Key: "region"}, {Key: "bucket"}, {Key: "included-func-tags"}, {Key: "keyless", Bool: true}}
+	answersFile := filepath.Join(t.TempDir(), "answers.yaml")
+	content := "region: eu-west-1\nbucket: from-file\nincluded-func-tags:\n  - tag1\n  - tag2\nnon-interactive: true\n"
+	if err := os.WriteFile(answersFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FC_BUCKET", "from-env")
+	t.Setenv("FC_REGION", "us-west-2")
+	cmd := &cobra.Command{}
+	AddInitFlags(cmd, parameters)
+	if err := cmd.Flags().Parse([]string{"--region", "us-east-1", "--keyless", "--answers-file", answersFile}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := InitPrompter(cmd, parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Interactive() {
+		t.Fatal("expected the answers file to make the prompter non-interactive")
+	}
+	expected := map[string]string{"region": "us-east-1", "bucket": "from-env", "included-func-tags": "tag1,tag2", "keyless": "true"}
+	for key, value := range expected {
+		if answer, exist := p.lookup(key); !exist || answer != value {
+			t.Fatalf("expected %s: %s, got: %s", key, value, answer)
+		}
+	}
+}
+
+func TestReadAnswersFileUnknownParameter(t *testing.T) {
+	answersFile := filepath.Join(t.TempDir(), "answers.yaml")
+	if err := os.WriteFile(answersFile, []byte("regoin: us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readAnswersFile(answersFile, map[string]bool{"region": true})
+	if err == nil || !strings.Contains(err.Error(), "unknown parameter in answers file: regoin") {
+		t.Fatalf("expected an unknown parameter to fail, got: %v", err)
+	}
+}
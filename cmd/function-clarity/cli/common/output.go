@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/cobra"
+)
+
+func AddOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "print the verification result to stdout in the format ("+strings.Join(verify.OutputFormats, "|")+"), the progress is printed to stderr")
+}
+
+// RunVerify runs the verification, and prints its result in the format of the output flag. While the result is
+// printed to stdout, anything else printed during the verification is redirected to stderr.
+func RunVerify(cmd *cobra.Command, verifyFunc func() (*verify.VerificationResult, error)) (*verify.VerificationResult, error) {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return verifyFunc()
+	}
+	if err = ValidateOutputFormat(output); err != nil {
+		return nil, err
+	}
+	stdout, restore := RedirectStdout()
+	result, err := verifyFunc()
+	restore()
+	if result != nil {
+		if e := result.Write(stdout, output); e != nil {
+			return result, fmt.Errorf("failed to write verification result: %w", e)
+		}
+	}
+	return result, err
+}
+
+// RedirectStdout redirects stdout to stderr, and returns the original stdout with a function restoring it.
+func RedirectStdout() (*os.File, func()) {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout, func() {
+		os.Stdout = stdout
+	}
+}
+
+// ValidateOutputFormat makes sure the output is one of the output formats.
+func ValidateOutputFormat(output string) error {
+	if !isOutputFormat(output) {
+		return fmt.Errorf("unsupported output format: %s, expected one of: %s", output, strings.Join(verify.OutputFormats, ", "))
+	}
+	return nil
+}
+
+func isOutputFormat(output string) bool {
+	for _, format := range verify.OutputFormats {
+		if output == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/pkg/policy"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AddPolicyFlag(cmd *cobra.Command) {
+	cmd.Flags().String("policy", "", "verification policy file, selecting the signers and the action of each function by its matched rule")
+}
+
+func BindPolicyFlag(cmd *cobra.Command) error {
+	if err := viper.BindPFlag("policy", cmd.Flags().Lookup("policy")); err != nil {
+		return fmt.Errorf("error binding policy: %w", err)
+	}
+	return nil
+}
+
+// Policy loads the policy file selected in the flags or the config file, it returns nil when none is selected.
+func Policy() (*policy.Policy, error) {
+	policyPath := viper.GetString("policy")
+	if policyPath == "" {
+		return nil, nil
+	}
+	return policy.Load(policyPath)
+}
+
+// InputPolicyParameter asks for the policy file of the deployed verifier, and validates it.
+func InputPolicyParameter(policyPath *string) error {
+	return stdinPrompter.Policy("", policyPath)
+}
+
+// Policy asks for the policy file of the deployed verifier, and validates it.
+func (p *Prompter) Policy(key string, policyPath *string) error {
+	if err := p.String(key, "enter path to verification policy file to select signers and action per function (leave empty to apply the action above to all functions): ", policyPath, true); err != nil {
+		return err
+	}
+	if *policyPath != "" {
+		if _, err := policy.Load(*policyPath); err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+	}
+	return nil
+}
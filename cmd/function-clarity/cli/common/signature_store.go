@@ -0,0 +1,44 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AddSignatureStoreFlag(cmd *cobra.Command) {
+	cmd.Flags().String("signature-store", "", "signature store url: s3://<bucket>[/<prefix>], gs://<bucket>[/<prefix>], oci://<registry>/<repository>, http(s)://<url> or a local folder (default: the bucket of the provider)")
+}
+
+func BindSignatureStoreFlag(cmd *cobra.Command) error {
+	if err := viper.BindPFlag("signaturestore", cmd.Flags().Lookup("signature-store")); err != nil {
+		return fmt.Errorf("error binding signaturestore: %w", err)
+	}
+	return nil
+}
+
+// SignatureStore returns the store selected in the flags or the config file, or the default store of the provider.
+func SignatureStore(defaultStore clients.SignatureStore) (clients.SignatureStore, error) {
+	storeURL := viper.GetString("signaturestore")
+	if storeURL == "" {
+		return defaultStore, nil
+	}
+	return clients.NewSignatureStore(storeURL, viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"))
+}
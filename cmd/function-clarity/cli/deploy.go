@@ -17,6 +17,7 @@ package cli
 
 import (
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/gcp"
 	"github.com/spf13/cobra"
 )
 
@@ -26,5 +27,6 @@ func Deploy() *cobra.Command {
 		Short: "Deploy function clarity to cloud provider",
 	}
 	cmd.AddCommand(aws.AwsDeploy())
+	cmd.AddCommand(gcp.GcpDeploy())
 	return cmd
 }
@@ -17,14 +17,19 @@ package gcp
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
 	opt "github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
 	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
 	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
 	"github.com/openclarity/functionclarity/pkg/verify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 func GcpSign() *cobra.Command {
@@ -54,25 +59,166 @@ func GcpVerify() *cobra.Command {
 			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding publickey: %w", err)
 			}
+			if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
+				return fmt.Errorf("error binding action: %w", err)
+			}
+			if err := viper.BindPFlag("includedfunctagkeys", cmd.Flags().Lookup("included-func-tags")); err != nil {
+				return fmt.Errorf("error binding includedfunctagkeys: %w", err)
+			}
+			if err := viper.BindPFlag("includedfuncregions", cmd.Flags().Lookup("included-func-regions")); err != nil {
+				return fmt.Errorf("error binding includedfuncregions: %w", err)
+			}
+			if err := viper.BindPFlag("pubsubTopic", cmd.Flags().Lookup("pubsub-topic")); err != nil {
+				return fmt.Errorf("error binding pubsubTopic: %w", err)
+			}
+			if err := common.BindSignatureStoreFlag(cmd); err != nil {
+				return err
+			}
+			if err := common.BindPolicyFlag(cmd); err != nil {
+				return err
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			o.Key = viper.GetString("publickey")
+			var err error
+			if o.Policy, err = common.Policy(); err != nil {
+				return err
+			}
+			if functionRegion == "" {
+				// the location is part of the function identifier: projects/<project>/locations/<location>/...
+				if parts := strings.Split(args[0], "/"); len(parts) > 3 && parts[2] == "locations" {
+					functionRegion = parts[3]
+				}
+			}
 			gcpClient := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), functionRegion)
-			_, _, err := verify.Verify(gcpClient, args[0], o, cmd.Context(), "", "", nil, nil, "", "")
+			store, err := common.SignatureStore(clients.NewGCSSignatureStore(viper.GetString("bucket"), ""))
+			if err != nil {
+				return err
+			}
+			_, err = common.RunVerify(cmd, func() (*verify.VerificationResult, error) {
+				return verify.Verify(gcpClient, store, args[0], o, cmd.Context(), viper.GetString("action"),
+					viper.GetString("pubsubTopic"), viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"),
+					"", "")
+			})
 			return err
 		},
 	}
-	cmd.Flags().StringVar(&functionRegion, "function-location", "", "GCP location where the verified function runs")
-	cmd.MarkFlagRequired("function-region") //nolint:errcheck
+	cmd.Flags().StringVar(&functionRegion, "function-location", "", "GCP location where the verified function runs (default: the location of the function identifier)")
 	o.AddFlags(cmd)
 	initGCPVerifyFlags(cmd)
 	return cmd
 }
 
+func GcpInit() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gcp",
+		Short: "initialize configuration and deploy to gcp",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input i.GCPInput
+			if err := ReceiveParameters(&input); err != nil {
+				return err
+			}
+			onlyCreateConfig, err := cmd.Flags().GetBool("only-create-config")
+			if err != nil {
+				return err
+			}
+			if !onlyCreateConfig {
+				sourcePath, err := verifierSource(cmd)
+				if err != nil {
+					return err
+				}
+				gcpClient := clients.NewGCPClientInit(input.Bucket, input.Location, "")
+				if err = gcpClient.DeployFunctionClarity(input.PublicKey, sourcePath, gcpDeploymentConfig(input)); err != nil {
+					return fmt.Errorf("failed to deploy function clarity: %w", err)
+				}
+			}
+			d, err := yaml.Marshal(&input)
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			f, err := os.Create(utils.HomeDir + "/.fc")
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			defer f.Close()
+			if _, err = f.Write(d); err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("only-create-config", false, "determine whether to only create config file without deploying")
+	addVerifierSourceFlag(cmd)
+	return cmd
+}
+
+func GcpDeploy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gcp",
+		Short: "deploy to gcp using config file",
+		Long:  "deploy to gcp, this command relies on a configuration file to exist under ~/.fc, to create a config file run the command: 'init gcp --only-create-config'",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input i.GCPInput
+			input.Project = viper.GetString("project")
+			input.Location = viper.GetString("location")
+			input.Bucket = viper.GetString("bucket")
+			input.Action = viper.GetString("action")
+			input.IsKeyless = viper.GetBool("iskeyless")
+			input.PubSubTopic = viper.GetString("pubsubtopic")
+			input.IncludedFuncTagKeys = viper.GetStringSlice("includedfunctagkeys")
+			input.IncludedFuncRegions = viper.GetStringSlice("includedfuncregions")
+			input.BucketPathToPublicKeys = viper.GetString("bucketpathtopublickeys")
+			input.SignatureStore = viper.GetString("signaturestore")
+			input.Policy = viper.GetString("policy")
+			input.ServiceAccount = viper.GetString("serviceaccount")
+			sourcePath, err := verifierSource(cmd)
+			if err != nil {
+				return err
+			}
+			gcpClient := clients.NewGCPClientInit(input.Bucket, input.Location, "")
+			if err = gcpClient.DeployFunctionClarity(viper.GetString("publickey"), sourcePath, gcpDeploymentConfig(input)); err != nil {
+				return fmt.Errorf("failed to deploy function clarity: %w", err)
+			}
+			return nil
+		},
+	}
+	addVerifierSourceFlag(cmd)
+	return cmd
+}
+
+func addVerifierSourceFlag(cmd *cobra.Command) {
+	cmd.Flags().String("verifier-source", "", "path of a locally built verifier function source archive (make gcp-function) to deploy, "+
+		"defaults to the gcp_function.zip released with this version")
+}
+
+func verifierSource(cmd *cobra.Command) (string, error) {
+	sourcePath, err := cmd.Flags().GetString("verifier-source")
+	if err != nil {
+		return "", err
+	}
+	return clients.ResolveVerifierSource(sourcePath)
+}
+
+// gcpDeploymentConfig returns the configuration of the verifier function, without the local key paths.
+func gcpDeploymentConfig(input i.GCPInput) i.GCPInput {
+	input.PublicKey = ""
+	input.PrivateKey = ""
+	return input
+}
+
 func initGCPVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
 	cmd.Flags().String("location", "", "GCP location to perform the operation against")
 	cmd.Flags().String("bucket", "", "GCP bucket to work against")
 	cmd.Flags().String("key", "", "public key")
+	cmd.Flags().String("action", "", "action to perform upon validation result")
+	cmd.Flags().StringSlice("included-func-tags", []string{}, "function label keys to include when verifying")
+	cmd.Flags().StringSlice("included-func-regions", []string{}, "function locations to include when verifying")
+	cmd.Flags().String("pubsub-topic", "", "Pub/Sub topic for notifications (projects/<project>/topics/<topic>)")
+	common.AddSignatureStoreFlag(cmd)
+	common.AddPolicyFlag(cmd)
+	common.AddOutputFlag(cmd)
 }
@@ -18,6 +18,7 @@ package gcp
 import (
 	"fmt"
 
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
 	"github.com/openclarity/functionclarity/pkg/clients"
 	o "github.com/openclarity/functionclarity/pkg/options"
@@ -45,11 +46,17 @@ func GCPSignCode() *cobra.Command {
 			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding privatekey: %w", err)
 			}
+			if err := common.BindSignatureStoreFlag(cmd); err != nil {
+				return err
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			gcpProperties := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), "")
-			return sign.SignAndUploadCode(gcpProperties, args[0], sbo, ro)
+			store, err := common.SignatureStore(clients.NewGCSSignatureStore(viper.GetString("bucket"), ""))
+			if err != nil {
+				return err
+			}
+			return sign.SignAndUploadCode(store, args[0], sbo, ro)
 		},
 	}
 	initGCPSignCodeFlags(cmd)
@@ -63,4 +70,5 @@ func initGCPSignCodeFlags(cmd *cobra.Command) {
 	cmd.Flags().String("location", "", "GCP location to perform the operation against")
 	cmd.Flags().String("bucket", "", "cloud storage bucket to work against")
 	cmd.Flags().String("key", "", "private key")
+	common.AddSignatureStoreFlag(cmd)
 }
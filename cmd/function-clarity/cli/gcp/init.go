@@ -0,0 +1,101 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+)
+
+func ReceiveParameters(i *i.GCPInput) error {
+	if err := common.InputStringParameter("enter project id: ", &i.Project, false); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter location to deploy the verifier function in, i.e: us-central1: ", &i.Location, false); err != nil {
+		return err
+	}
+	if err := common.InputStringParameter("enter default bucket (you can leave empty and a bucket with name functionclarity-<project id> will be created): ", &i.Bucket, true); err != nil {
+		return err
+	}
+	if i.Bucket == "" {
+		i.Bucket = fmt.Sprintf("functionclarity-%s", i.Project)
+	}
+
+	if err := common.InputStringParameter("enter the service account the verifier function runs as (leave empty for the App Engine default service account): ", &i.ServiceAccount, true); err != nil {
+		return err
+	}
+
+	if err := common.InputStringArrayParameter("enter label keys of functions to include in the verification (leave empty to include all): ", &i.IncludedFuncTagKeys, true); err != nil {
+		return err
+	}
+	if err := common.InputStringArrayParameter("enter the function locations to include in the verification, i.e: us-central1,europe-west1 (leave empty to include all): ", &i.IncludedFuncRegions, true); err != nil {
+		return err
+	}
+
+	if err := common.InputMultipleChoiceParameter("post verification action", &i.Action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
+		return err
+	}
+
+	if err := common.InputPolicyParameter(&i.Policy); err != nil {
+		return err
+	}
+
+	if err := common.InputStringParameter("enter Pub/Sub topic (projects/<project>/topics/<topic>) if you would like to be notified when signature verification fails, otherwise press enter: ", &i.PubSubTopic, true); err != nil {
+		return err
+	}
+
+	if err := common.InputYesNoParameter("do you want to work in keyless mode (y/n): ", &i.IsKeyless, false); err != nil {
+		return err
+	}
+
+	if !i.IsKeyless {
+		if err := inputKeyPair(i); err != nil {
+			return err
+		}
+	}
+
+	if err := digestParameters(i); err != nil {
+		return err
+	}
+	return nil
+}
+
+func digestParameters(i *i.GCPInput) error {
+	if i.PublicKey == "" && !i.IsKeyless {
+		if err := generate.GenerateKeyPairCmd(context.Background(), "", []string{}); err != nil {
+			return err
+		}
+		i.PublicKey = "cosign.pub"
+		i.PrivateKey = "cosign.key"
+	}
+	return nil
+}
+
+func inputKeyPair(i *i.GCPInput) error {
+	if err := common.InputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
+		return err
+	}
+	if i.PublicKey != "" {
+		if err := common.InputStringParameter("enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ package cli
 
 import (
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/azure"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/gcp"
 	"github.com/spf13/cobra"
 )
 
@@ -26,5 +28,7 @@ func Init() *cobra.Command {
 		Short: "init cloud provider configuration",
 	}
 	cmd.AddCommand(aws.AwsInit())
+	cmd.AddCommand(azure.AzureInit())
+	cmd.AddCommand(gcp.GcpInit())
 	return cmd
 }
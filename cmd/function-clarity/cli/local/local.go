@@ -0,0 +1,88 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/common"
+	opt "github.com/openclarity/functionclarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func LocalVerify() *cobra.Command {
+	o := &options.VerifyOpts{}
+	cmd := &cobra.Command{
+		Use:   "local",
+		Short: "verify local function package (zip or tar.gz file, or folder) against signatures in a local folder",
+		Args:  cobra.ExactArgs(1),
+		// a failed verification is an expected outcome in CI gates, not a usage error
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			if err := common.BindSignatureStoreFlag(cmd); err != nil {
+				return err
+			}
+			if err := common.BindPolicyFlag(cmd); err != nil {
+				return err
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			var err error
+			if o.Policy, err = common.Policy(); err != nil {
+				return err
+			}
+			signaturesDir, err := cmd.Flags().GetString("signatures-dir")
+			if err != nil {
+				return err
+			}
+			store, err := common.SignatureStore(clients.NewFileSignatureStore(signaturesDir))
+			if err != nil {
+				return err
+			}
+			result, err := common.RunVerify(cmd, func() (*verify.VerificationResult, error) {
+				return verify.Verify(clients.NewLocalClient(), store, args[0], o, cmd.Context(), "", "", nil, nil, "", "")
+			})
+			if err != nil {
+				return err
+			}
+			if !result.Verified {
+				return fmt.Errorf("verification failed for local package: %s", args[0])
+			}
+			return nil
+		},
+	}
+	o.AddFlags(cmd)
+	initLocalVerifyFlags(cmd)
+	return cmd
+}
+
+func initLocalVerifyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("signatures-dir", ".", "local folder containing <identity>.sig and <identity>.crt.base64 files")
+	cmd.Flags().String("key", "", "public key")
+	common.AddSignatureStoreFlag(cmd)
+	common.AddPolicyFlag(cmd)
+	common.AddOutputFlag(cmd)
+}
@@ -17,6 +17,8 @@ package options
 
 import (
 	"fmt"
+	"os"
+
 	"github.com/openclarity/functionclarity/pkg/utils"
 	"github.com/spf13/viper"
 )
@@ -34,9 +36,9 @@ func CobraInit() {
 		viper.SetConfigType("yaml")
 	}
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Error loading config file: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading config file: %s\n", err)
 	}
 	if viper.ConfigFileUsed() != "" {
-		fmt.Printf("using config file: %s\n", viper.ConfigFileUsed())
+		fmt.Fprintf(os.Stderr, "using config file: %s\n", viper.ConfigFileUsed())
 	}
 }
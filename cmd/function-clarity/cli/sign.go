@@ -17,6 +17,7 @@ package cli
 
 import (
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/azure"
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/gcp"
 	"github.com/spf13/cobra"
 )
@@ -28,5 +29,6 @@ func Sign() *cobra.Command {
 	}
 	cmd.AddCommand(aws.AwsSign())
 	cmd.AddCommand(gcp.GcpSign())
+	cmd.AddCommand(azure.AzureSign())
 	return cmd
 }
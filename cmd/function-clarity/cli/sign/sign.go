@@ -30,8 +30,14 @@ import (
 )
 
 func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (string, error) {
+	return SignPayload(identity, identity, o, ro, isKeyless)
+}
+
+// SignPayload signs the payload, in keyless mode the signature and certificate are written to the function
+// clarity home dir, under the given name.
+func SignPayload(payload string, name string, o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (string, error) {
 	path := utils.FunctionClarityHomeDir + uuid.New().String()
-	if err := integrity.SaveTextToFile(identity, path); err != nil {
+	if err := integrity.SaveTextToFile(payload, path); err != nil {
 		return "", fmt.Errorf("signing identity: %w", err)
 	}
 
@@ -59,8 +65,8 @@ func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isK
 	outputSignature := o.OutputSignature
 	outputCertificate := o.OutputCertificate
 	if isKeyless {
-		outputSignature = utils.FunctionClarityHomeDir + identity + ".sig"
-		outputCertificate = utils.FunctionClarityHomeDir + identity + ".crt.base64"
+		outputSignature = utils.FunctionClarityHomeDir + name + ".sig"
+		outputCertificate = utils.FunctionClarityHomeDir + name + ".crt.base64"
 	}
 
 	sig, err := sign.SignBlobCmd(ro, ko, o.Registry, path, o.Base64Output, outputSignature, outputCertificate)
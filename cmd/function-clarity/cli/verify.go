@@ -17,7 +17,9 @@ package cli
 
 import (
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/azure"
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/gcp"
+	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/local"
 	"github.com/spf13/cobra"
 )
 
@@ -28,5 +30,7 @@ func Verify() *cobra.Command {
 	}
 	cmd.AddCommand(aws.AwsVerify())
 	cmd.AddCommand(gcp.GcpVerify())
+	cmd.AddCommand(local.LocalVerify())
+	cmd.AddCommand(azure.AzureVerify())
 	return cmd
 }
@@ -21,16 +21,24 @@ import (
 	"github.com/google/uuid"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	opts "github.com/openclarity/functionclarity/pkg/options"
-	"github.com/openclarity/functionclarity/pkg/utils"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	"os"
+	"path/filepath"
 )
 
-func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
-	path := utils.FunctionClarityHomeDir + uuid.New().String()
-	if err := integrity.SaveTextToFile(identity, path); err != nil {
+func VerifyIdentity(identity string, dir string, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	return VerifyPayload(identity, identity, dir, o, ctx, isKeyless)
+}
+
+// VerifyPayload verifies the payload against the signature (and certificate in keyless mode) downloaded to the
+// directory under the given name.
+func VerifyPayload(payload string, name string, dir string, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	path := filepath.Join(dir, uuid.New().String())
+	if err := integrity.SaveTextToFile(payload, path); err != nil {
 		return err
 	}
+	defer os.Remove(path) //nolint:errcheck
 
 	ko := options.KeyOpts{
 		KeyRef:     o.Key,
@@ -42,16 +50,16 @@ func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, is
 
 	certRef := o.CertVerify.Cert
 	if isKeyless {
-		certRef = utils.FunctionClarityHomeDir + identity + ".crt.base64"
+		certRef = filepath.Join(dir, name+".crt.base64")
 	}
-	sigRef := utils.FunctionClarityHomeDir + identity + ".sig"
+	sigRef := filepath.Join(dir, name+".sig")
 
 	if err := verify.VerifyBlobCmd(ctx, ko, certRef,
 		o.CertVerify.CertEmail, o.CertVerify.CertIdentity, o.CertVerify.CertOidcIssuer, o.CertVerify.CertChain,
 		sigRef, path, o.CertVerify.CertGithubWorkflowTrigger, o.CertVerify.CertGithubWorkflowSha,
 		o.CertVerify.CertGithubWorkflowName, o.CertVerify.CertGithubWorkflowRepository, o.CertVerify.CertGithubWorkflowRef,
 		o.CertVerify.EnforceSCT); err != nil {
-		return fmt.Errorf("verifying identity %s: %w", identity, err)
+		return fmt.Errorf("verifying %s: %w", name, err)
 	}
 	return nil
 }
@@ -22,9 +22,15 @@ import (
 	"os"
 )
 
+// appVersion is set by the release build flags
+var appVersion string
+
 func main() {
+	utils.Version = appVersion
 	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
 		log.Fatal("Can't create home dir", err)
 	}
-	cli.New().Execute() //nolint:errcheck
+	if err := cli.New().Execute(); err != nil {
+		os.Exit(1)
+	}
 }
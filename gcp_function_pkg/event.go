@@ -0,0 +1,133 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpfunction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+)
+
+// gcfServiceAgentDomain is the domain of the service agent that manages the cloud run services of 2nd gen functions.
+const gcfServiceAgentDomain = "@gcf-admin-robot.iam.gserviceaccount.com"
+
+type PubSubMessage struct {
+	Data []byte `json:"data"`
+}
+
+type AuditLogEntry struct {
+	ProtoPayload AuditLog          `json:"protoPayload"`
+	Resource     MonitoredResource `json:"resource"`
+	Operation    *Operation        `json:"operation"`
+}
+
+type AuditLog struct {
+	ServiceName        string             `json:"serviceName"`
+	MethodName         string             `json:"methodName"`
+	ResourceName       string             `json:"resourceName"`
+	Status             *Status            `json:"status"`
+	AuthenticationInfo AuthenticationInfo `json:"authenticationInfo"`
+}
+
+type AuthenticationInfo struct {
+	PrincipalEmail string `json:"principalEmail"`
+}
+
+type Status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type MonitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+}
+
+type Operation struct {
+	Id    string `json:"id"`
+	First bool   `json:"first"`
+	Last  bool   `json:"last"`
+}
+
+type FunctionEvent struct {
+	MethodName         string
+	FunctionIdentifier string
+	Location           string
+}
+
+// ParseAuditLogEntry returns the function event of an audit log entry, or nil when the entry should not be handled,
+// verifierPrincipal is the service account of the verifier, whose own changes are not handled.
+func ParseAuditLogEntry(data []byte, verifierPrincipal string) (*FunctionEvent, error) {
+	entry := AuditLogEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audit log entry: %w", err)
+	}
+	if !shouldHandleEntry(entry, verifierPrincipal) {
+		return nil, nil
+	}
+	identifier, err := functionIdentifier(entry)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(identifier, "/functions/"+clients.FunctionClarityGCPVerifierName) {
+		return nil, nil
+	}
+	location := strings.Split(identifier, "/")[3]
+	return &FunctionEvent{MethodName: entry.ProtoPayload.MethodName, FunctionIdentifier: identifier, Location: location}, nil
+}
+
+func shouldHandleEntry(entry AuditLogEntry, verifierPrincipal string) bool {
+	methodName := entry.ProtoPayload.MethodName
+	if !strings.HasSuffix(methodName, ".CreateFunction") && !strings.HasSuffix(methodName, ".UpdateFunction") &&
+		!strings.HasSuffix(methodName, ".ReplaceService") {
+		return false
+	}
+	// long-running operations are logged when started and when done, the function can be verified only when done
+	if entry.Operation != nil && !entry.Operation.Last {
+		return false
+	}
+	if entry.ProtoPayload.Status != nil && entry.ProtoPayload.Status.Code != 0 {
+		return false
+	}
+	principal := entry.ProtoPayload.AuthenticationInfo.PrincipalEmail
+	// the labels set by the verifier would trigger it again
+	if verifierPrincipal != "" && principal == verifierPrincipal {
+		return false
+	}
+	// 2nd gen functions are verified by their own events
+	return !strings.HasSuffix(principal, gcfServiceAgentDomain)
+}
+
+// functionIdentifier converts the resource of the entry to projects/<project>/locations/<location>/<functions|services>/<name>.
+func functionIdentifier(entry AuditLogEntry) (string, error) {
+	resourceName := entry.ProtoPayload.ResourceName
+	if strings.HasPrefix(resourceName, "projects/") {
+		parts := strings.Split(resourceName, "/")
+		if len(parts) == 6 && parts[2] == "locations" && (parts[4] == "functions" || parts[4] == "services") {
+			return resourceName, nil
+		}
+	}
+	// cloud run admin api v1 resources are named namespaces/<project>/services/<name>
+	if strings.HasPrefix(resourceName, "namespaces/") {
+		labels := entry.Resource.Labels
+		if labels["project_id"] != "" && labels["location"] != "" && labels["service_name"] != "" {
+			return fmt.Sprintf("projects/%s/locations/%s/services/%s", labels["project_id"], labels["location"], labels["service_name"]), nil
+		}
+	}
+	return "", fmt.Errorf("failed to resolve function identifier from resource: %s", resourceName)
+}
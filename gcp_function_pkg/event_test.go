@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpfunction
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const verifierPrincipal = "fc-project@appspot.gserviceaccount.com"
+
+func TestParseAuditLogEntry(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected *FunctionEvent
+	}{
+		{"functions_v1_create_function_first.json", nil},
+		{"functions_v1_create_function_last.json", &FunctionEvent{
+			MethodName:         "google.cloud.functions.v1.CloudFunctionsService.CreateFunction",
+			FunctionIdentifier: "projects/fc-project/locations/us-central1/functions/hello",
+			Location:           "us-central1",
+		}},
+		{"functions_v1_update_function_failed.json", nil},
+		{"functions_v1_update_verifier.json", nil},
+		{"functions_v1_update_function_verifier_label.json", nil},
+		{"functions_v2_update_function_verifier_label.json", nil},
+		{"functions_v1_delete_function.json", nil},
+		{"functions_v2_update_function_last.json", &FunctionEvent{
+			MethodName:         "google.cloud.functions.v2.FunctionService.UpdateFunction",
+			FunctionIdentifier: "projects/fc-project/locations/europe-west1/functions/orders",
+			Location:           "europe-west1",
+		}},
+		{"run_replace_service.json", &FunctionEvent{
+			MethodName:         "google.cloud.run.v1.Services.ReplaceService",
+			FunctionIdentifier: "projects/fc-project/locations/us-east1/services/checkout",
+			Location:           "us-east1",
+		}},
+		{"run_replace_service_gcf_agent.json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			event, err := ParseAuditLogEntry(data, verifierPrincipal)
+			if err != nil {
+				t.Fatalf("failed to parse audit log entry: %v", err)
+			}
+			if !reflect.DeepEqual(event, tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, event)
+			}
+		})
+	}
+}
+
+func TestParseAuditLogEntryInvalid(t *testing.T) {
+	if _, err := ParseAuditLogEntry([]byte("not json"), verifierPrincipal); err == nil {
+		t.Fatal("expected unmarshal error")
+	}
+	entry := `{"protoPayload": {"methodName": "google.cloud.run.v1.Services.ReplaceService", "resourceName": "namespaces/fc-project/services/checkout"}}`
+	if _, err := ParseAuditLogEntry([]byte(entry), verifierPrincipal); err == nil {
+		t.Fatal("expected unresolved function identifier error")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcpfunction is the verifier cloud function, triggered by cloud audit log entries published to Pub/Sub.
+package gcpfunction
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	opts "github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/policy"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"gopkg.in/yaml.v3"
+)
+
+// sourceDir is where the cloud functions runtime places the deployed source.
+const sourceDir = "serverless_function_source_code"
+
+var config *i.GCPInput = nil
+
+var verificationPolicy *policy.Policy = nil
+
+func HandleRequest(ctx context.Context, m PubSubMessage) error {
+	if config == nil {
+		if err := initConfig(); err != nil {
+			return err
+		}
+	}
+	event, err := ParseAuditLogEntry(m.Data, config.VerifierServiceAccount())
+	if err != nil {
+		log.Printf("Failed to extract data from event: %v", err)
+		return fmt.Errorf("failed to extract data from event: %w", err)
+	}
+	if event == nil {
+		return nil
+	}
+	log.Printf("creating folder: %s", utils.FunctionClarityHomeDir)
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		return err
+	}
+	log.Printf("handling function: %s, method name: %s, location: %s\n", event.FunctionIdentifier, event.MethodName, event.Location)
+	handleFunctionEvent(event, ctx)
+	return nil
+}
+
+func handleFunctionEvent(event *FunctionEvent, ctx context.Context) {
+	o := getVerifierOptions(config.IsKeyless, config.PublicKey)
+	o.Policy = verificationPolicy
+	log.Printf("about to execute verification with post action: %s.", config.Action)
+	gcpClient := clients.NewGCPClientInit(config.Bucket, config.Location, event.Location)
+	var store clients.SignatureStore = clients.NewGCSSignatureStore(config.Bucket, "")
+	if config.SignatureStore != "" {
+		var err error
+		if store, err = clients.NewSignatureStore(config.SignatureStore, "", "", ""); err != nil {
+			log.Printf("Failed to create signature store: %v", err)
+			return
+		}
+	}
+	result, err := verify.Verify(gcpClient, store, event.FunctionIdentifier, o, ctx, config.Action, config.PubSubTopic,
+		config.IncludedFuncTagKeys, config.IncludedFuncRegions, config.BucketPathToPublicKeys, "")
+	logVerificationResult(result)
+	if err != nil {
+		log.Printf("Failed to handle function result: %s, %v", event.FunctionIdentifier, err)
+	}
+}
+
+// logVerificationResult logs the result as a single json line, to be queried in the logs of the verifier.
+func logVerificationResult(result *verify.VerificationResult) {
+	serResult, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("failed to serialize verification result: %v", err)
+		return
+	}
+	log.Printf("verification result: %s", serResult)
+}
+
+func initConfig() error {
+	envConfig := os.Getenv(clients.ConfigEnvVariableName)
+	log.Printf("config: %s", envConfig)
+	decodedConfig, err := base64.StdEncoding.DecodeString(envConfig)
+	if err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(decodedConfig, &config); err != nil {
+		return err
+	}
+	if config.Policy != "" {
+		policyPath := config.Policy
+		if _, err := os.Stat(sourceDir + "/" + policyPath); err == nil {
+			policyPath = sourceDir + "/" + policyPath
+		}
+		if verificationPolicy, err = policy.Load(policyPath); err != nil {
+			// the configuration is loaded again on the next event, never verifying without the policy
+			config = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func getVerifierOptions(isKeyless bool, publicKey string) *opts.VerifyOpts {
+	key := "cosign.pub"
+	if _, err := os.Stat(sourceDir + "/" + key); err == nil {
+		key = sourceDir + "/" + key
+	}
+	if isKeyless && publicKey == "" {
+		key = ""
+		os.Setenv(integrity.ExperimentalEnv, "1")
+	}
+
+	o := &opts.VerifyOpts{
+		VerifyOptions: co.VerifyOptions{
+			Key:         key,
+			CheckClaims: true,
+			Output:      "json",
+			Rekor:       co.RekorOptions{URL: "https://rekor.sigstore.dev"},
+		},
+	}
+	return o
+}
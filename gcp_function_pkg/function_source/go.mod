@@ -0,0 +1,7 @@
+module github.com/openclarity/functionclarity/verifier
+
+go 1.19
+
+require github.com/openclarity/functionclarity v0.0.0
+
+replace github.com/openclarity/functionclarity => ./functionclarity
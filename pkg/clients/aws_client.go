@@ -19,13 +19,17 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	b64 "encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
@@ -39,6 +43,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/google/uuid"
 	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/policy"
 	"github.com/openclarity/functionclarity/pkg/utils"
 	"gopkg.in/yaml.v3"
 	"io"
@@ -47,6 +52,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
@@ -54,24 +60,40 @@ import (
 const FunctionClarityBucketName = "functionclarity"
 const FunctionClarityLambdaVerierName = "FunctionClarityLambda"
 
+// FunctionClarityVerifierRoleName is the prefix of the name of the role of the verifier function, followed by the
+// region and the suffix of the deployment, the role of the member accounts trusts it.
+const FunctionClarityVerifierRoleName = "FunctionClarityLambdaRole"
+const FunctionClarityCodeArchive = "function-clarity.zip"
+
 type AwsClient struct {
 	accessKey    string
 	secretKey    string
-	s3           string
 	region       string
 	lambdaRegion string
+	// roleArn is assumed for the lambda and ecr calls, when verifying the functions of another account
+	roleArn string
+	// roleCredentials are created once, so the role is assumed again only when its credentials expire
+	roleCredentials     *aws.CredentialsCache
+	roleCredentialsOnce sync.Once
 }
 
-func NewAwsClient(accessKey string, secretKey string, s3 string, region string, lambdaRegion string) *AwsClient {
+func NewAwsClient(accessKey string, secretKey string, region string, lambdaRegion string) *AwsClient {
 	p := new(AwsClient)
 	p.accessKey = accessKey
 	p.secretKey = secretKey
-	p.s3 = s3
 	p.region = region
 	p.lambdaRegion = lambdaRegion
 	return p
 }
 
+// NewAwsClientWithRole creates a client of the functions of another account, the role is assumed for the lambda and
+// ecr calls, while the other calls (i.e. notifications) are made with the credentials given.
+func NewAwsClientWithRole(accessKey string, secretKey string, region string, lambdaRegion string, roleArn string) *AwsClient {
+	p := NewAwsClient(accessKey, secretKey, region, lambdaRegion)
+	p.roleArn = roleArn
+	return p
+}
+
 func NewAwsClientInit(accessKey string, secretKey string, region string) *AwsClient {
 	p := new(AwsClient)
 	p.accessKey = accessKey
@@ -93,89 +115,55 @@ func (o *AwsClient) ResolvePackageType(funcIdentifier string) (string, error) {
 	return string(result.Configuration.PackageType), nil
 }
 
-func (o *AwsClient) Upload(signature string, identity string, isKeyless bool) error {
-	cfg := o.getConfig()
-
-	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
-	// Upload the file to S3.
-	_, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
-		Bucket: aws.String(o.s3),
-		Key:    aws.String(identity + ".sig"),
-		Body:   strings.NewReader(signature),
-	})
-	if err != nil {
-		return err
+func (o *AwsClient) GetFuncCode(funcIdentifier string) (string, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
 	}
-
-	if isKeyless {
-		certificatePath := utils.FunctionClarityHomeDir + identity + ".crt.base64"
-		f, err := os.Open(certificatePath)
-		if err != nil {
-			return err
-		}
-
-		result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
-			Bucket: aws.String(o.s3),
-			Key:    aws.String(identity + ".crt.base64"),
-			Body:   f,
-		})
-		if err != nil {
-			return err
-		}
-		fmt.Printf("\ncertificate file uploaded to, %s\n", aws.ToString(&result.Location))
+	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	if err != nil {
+		return "", err
 	}
-	return nil
+	return downloadAndExtractCode(result.Code.Location)
 }
 
-func (o *AwsClient) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string) error {
-	cfg := o.getConfig()
-	downloader := manager.NewDownloader(s3.NewFromConfig(*cfg))
-	fileName = fileName + "." + outputType
-	bucket := o.s3
-	filePath := fileName
-	if bucketPathToSignatures != "" {
-		var err error
-		signatureFullPath := bucketPathToSignatures + fileName
-		bucket, filePath, err = extractBucketAndPath(signatureFullPath)
-		if err != nil {
-			return err
-		}
+// GetFuncLayers returns the ARNs of the layer versions attached to the function (or to its version or alias).
+func (o *AwsClient) GetFuncLayers(funcIdentifier string) ([]string, error) {
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
 	}
-	outputFile := utils.FunctionClarityHomeDir + fileName
-	f, err := os.Create(outputFile)
+	result, err := lambdaClient.GetFunction(context.TODO(), input)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
-
-	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(filePath),
-	})
-
-	if err != nil {
-		var nsk *s3types.NoSuchKey
-		if errors.As(err, &nsk) || strings.Contains(err.Error(), "storage: object doesn't exist") {
-			return fmt.Errorf(utils.FunctionClaritySignatureNotFoundMessage+" : %w", err)
-		}
-		return err
+	var layerArns []string
+	for _, layer := range result.Configuration.Layers {
+		layerArns = append(layerArns, aws.ToString(layer.Arn))
 	}
-	return nil
+	return layerArns, nil
 }
 
-func (o *AwsClient) GetFuncCode(funcIdentifier string) (string, error) {
+// GetLayerCode downloads and extracts the content of the layer version.
+func (o *AwsClient) GetLayerCode(layerVersionArn string) (string, error) {
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
-	input := &lambda.GetFunctionInput{
-		FunctionName: aws.String(funcIdentifier),
+	input := &lambda.GetLayerVersionByArnInput{
+		Arn: aws.String(layerVersionArn),
 	}
-	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	result, err := lambdaClient.GetLayerVersionByArn(context.TODO(), input)
 	if err != nil {
 		return "", err
 	}
+	return downloadAndExtractCode(result.Content.Location)
+}
+
+func downloadAndExtractCode(location *string) (string, error) {
 	contentName := uuid.New().String()
 	zipFileName := contentName + ".zip"
-	if err := utils.DownloadFile(contentName+".zip", result.Code.Location); err != nil {
+	if err := utils.DownloadFile(contentName+".zip", location); err != nil {
 		return "", err
 	}
 	defer utils.CleanDirectory(utils.FunctionClarityHomeDir + zipFileName)
@@ -185,34 +173,77 @@ func (o *AwsClient) GetFuncCode(funcIdentifier string) (string, error) {
 	return utils.FunctionClarityHomeDir + contentName, nil
 }
 
-func (o *AwsClient) IsFuncInRegions(regions []string) bool {
+func (o *AwsClient) IsFuncInRegions(funcIdentifier string, regions []string) (bool, error) {
 	for _, value := range regions {
 		if o.lambdaRegion == value {
-			return true
+			return true, nil
 		}
 	}
-	return false
+	return false, nil
 }
 func (o *AwsClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
+	tags, err := o.GetFuncTags(funcIdentifier)
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range tagKes {
+		if _, exist := tags[tag]; exist {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *AwsClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	err := o.convertToArnIfNeeded(&funcIdentifier)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	funcIdentifier = unqualifiedFunctionArn(funcIdentifier)
 	input := &lambda.ListTagsInput{
 		Resource: aws.String(funcIdentifier),
 	}
 	resp, err := lambdaClient.ListTags(context.TODO(), input)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	for _, tag := range tagKes {
-		if _, exist := resp.Tags[tag]; exist {
-			return true, nil
+	return resp.Tags, nil
+}
+
+// ListFunctions returns the ARNs of the lambda functions in the lambda region of the client, except for the verifier.
+func (o *AwsClient) ListFunctions(ctx context.Context) ([]string, error) {
+	cfg := o.getConfigForLambda()
+	paginator := lambda.NewListFunctionsPaginator(lambda.NewFromConfig(*cfg), &lambda.ListFunctionsInput{})
+	var functionArns []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list functions in region: %s: %w", o.lambdaRegion, err)
+		}
+		for _, function := range page.Functions {
+			if strings.HasPrefix(aws.ToString(function.FunctionName), FunctionClarityLambdaVerierName) {
+				continue
+			}
+			functionArns = append(functionArns, aws.ToString(function.FunctionArn))
 		}
 	}
-	return false, nil
+	return functionArns, nil
+}
+
+// InvokeAsync queues an asynchronous invocation of the function with the payload as its event.
+func (o *AwsClient) InvokeAsync(ctx context.Context, functionArn string, payload []byte) error {
+	cfg := o.getConfig()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	if _, err := lambdaClient.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(functionArn),
+		InvocationType: lambdaTypes.InvocationTypeEvent,
+		Payload:        payload,
+	}); err != nil {
+		return fmt.Errorf("failed to invoke function: %s: %w", functionArn, err)
+	}
+	return nil
 }
 
 func (o *AwsClient) Notify(msg string, topicARN string) error {
@@ -256,17 +287,16 @@ func (o *AwsClient) GetFuncHash(funcIdentifier string) (string, error) {
 	return *result.Configuration.CodeSha256, nil
 }
 
-func (o *AwsClient) HandleDetect(funcIdentifier *string, failed bool) error {
+func (o *AwsClient) HandleDetect(funcIdentifier *string, failureCategory string) error {
 	if err := o.convertToArnIfNeeded(funcIdentifier); err != nil {
 		return err
 	}
-	var tagVerificationString string
-	if failed {
-		tagVerificationString = utils.FunctionNotSignedTagValue
-	} else {
-		tagVerificationString = utils.FunctionSignedTagValue
+	// the tag is of the whole function, a signed version or alias doesn't tell $LATEST is signed
+	if failureCategory == "" && isQualifiedFunctionArn(*funcIdentifier) {
+		fmt.Printf("function: %s is a version or an alias, the result tag of the function is left as is\n", *funcIdentifier)
+		return nil
 	}
-	return o.tagFunction(*funcIdentifier, "Function clarity result", tagVerificationString)
+	return o.tagFunction(unqualifiedFunctionArn(*funcIdentifier), "Function clarity result", detectTagValue(failureCategory))
 }
 
 func (o *AwsClient) tagFunction(funcIdentifier string, tag string, tagValue string) error {
@@ -289,10 +319,17 @@ func (o *AwsClient) HandleBlock(funcIdentifier *string, failed bool) error {
 	if err := o.convertToArnIfNeeded(funcIdentifier); err != nil {
 		return err
 	}
+	// the concurrency is reserved for the function, so blocking a version or an alias blocks the whole function
+	functionArn := unqualifiedFunctionArn(*funcIdentifier)
 	if failed {
-		return o.BlockFunction(funcIdentifier)
+		return o.BlockFunction(&functionArn)
+	}
+	// the function may be blocked for another of its versions, only its own verification unblocks it
+	if isQualifiedFunctionArn(*funcIdentifier) {
+		fmt.Printf("function: %s is a version or an alias, the function is left blocked if it was\n", *funcIdentifier)
+		return nil
 	}
-	return o.UnblockFunction(funcIdentifier)
+	return o.UnblockFunction(&functionArn)
 }
 
 func (o *AwsClient) BlockFunction(funcIdentifier *string) error {
@@ -410,6 +447,44 @@ func (o *AwsClient) convertToArnIfNeeded(funcIdentifier *string) error {
 	return nil
 }
 
+// unqualifiedFunctionArn strips the version or alias from a qualified function ARN, tags and concurrency settings
+// only apply to the function itself.
+func unqualifiedFunctionArn(functionArn string) string {
+	parsedArn, err := arn.Parse(functionArn)
+	if err != nil {
+		return functionArn
+	}
+	resource := strings.Split(parsedArn.Resource, ":")
+	if len(resource) > 2 {
+		parsedArn.Resource = strings.Join(resource[:2], ":")
+	}
+	return parsedArn.String()
+}
+
+// functionNameOfArn returns the bare name of the function, the resource of a function ARN is
+// function:<name>[:<qualifier>].
+func functionNameOfArn(funcArn arn.ARN) string {
+	resource := strings.Split(funcArn.Resource, ":")
+	if len(resource) > 1 && resource[0] == "function" {
+		return resource[1]
+	}
+	return funcArn.Resource
+}
+
+// isQualifiedFunctionArn tells if the ARN is of a version or an alias of the function, $LATEST is the function itself.
+func isQualifiedFunctionArn(functionArn string) bool {
+	return unqualifiedFunctionArn(functionArn) != functionArn && !strings.HasSuffix(functionArn, ":$LATEST")
+}
+
+// FunctionArnInAccount returns the ARN of the function name in the account and region, so the function is looked up
+// in the account it belongs to. An ARN is returned as is.
+func FunctionArnInAccount(functionIdentifier string, accountId string, region string) string {
+	if functionIdentifier == "" || accountId == "" || arn.IsARN(functionIdentifier) {
+		return functionIdentifier
+	}
+	return arn.ARN{Partition: "aws", Service: "lambda", Region: region, AccountID: accountId, Resource: "function:" + functionIdentifier}.String()
+}
+
 func (o *AwsClient) GetConcurrencyLevelTag(funcIdentifier string, tag string) (error, *int32) {
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
@@ -435,7 +510,7 @@ func (o *AwsClient) GetConcurrencyLevelTag(funcIdentifier string, tag string) (e
 }
 
 func (o *AwsClient) GetEcrToken() (*ecr.GetAuthorizationTokenOutput, error) {
-	cfg := o.getConfig()
+	cfg := o.assumeRoleIfNeeded(o.getConfig())
 	ecrClient := ecr.NewFromConfig(*cfg)
 	output, err := ecrClient.GetAuthorizationToken(context.TODO(), &ecr.GetAuthorizationTokenInput{})
 	if err != nil {
@@ -453,6 +528,17 @@ func (o *AwsClient) ValidateCredentials() bool {
 	return true
 }
 
+// GetAccountId returns the id of the account of the credentials.
+func (o *AwsClient) GetAccountId() (string, error) {
+	cfg := o.getConfig()
+	stsClient := sts.NewFromConfig(*cfg)
+	identity, err := stsClient.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get account id: %w", err)
+	}
+	return aws.ToString(identity.Account), nil
+}
+
 func (o *AwsClient) IsBucketExist(bucketName string) bool {
 	cfg := o.getConfig()
 	s3Client := s3.NewFromConfig(*cfg)
@@ -480,65 +566,73 @@ func (o *AwsClient) IsCloudTrailExist(trailName string) bool {
 	return true
 }
 
-func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, deploymentConfig i.AWSInput, suffix string) error {
+// DeployFunctionClarity deploys the function clarity stack, with the verifier binary at binaryPath, see
+// ResolveVerifierBinary.
+func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, binaryPath string, deploymentConfig i.AWSInput, suffix string) error {
 	cfg := o.getConfig()
-	if err := uploadFuncClarityCode(cfg, keyPath, deploymentConfig.Bucket); err != nil {
-		return fmt.Errorf("failed to upload function clarity code: %w", err)
-	}
 	cloudformationClient := cloudformation.NewFromConfig(*cfg)
-	funcClarityStackName := "function-clarity-stack" + suffix
-	stackExists, err := stackExists(funcClarityStackName, cloudformationClient)
+	stackName := FunctionClarityStackName(suffix)
+	stackExists, err := stackExists(stackName, cloudformationClient)
 	if err != nil {
 		return fmt.Errorf("failed to check if stack exists: %w", err)
 	}
 	if stackExists {
-		return fmt.Errorf("function clarity already deployed, please delete stack before you dpeloy")
+		return fmt.Errorf("function clarity already deployed, run deploy with --update to update it")
 	}
-
-	err, stackCalculatedTemplate := calculateStackTemplate(trailName, cfg, deploymentConfig, suffix)
+	stackCalculatedTemplate, _, err := prepareStackTemplate(cfg, trailName, keyPath, binaryPath, deploymentConfig, suffix, true)
 	if err != nil {
 		return err
 	}
-	stackName := funcClarityStackName
-	_, err = cloudformationClient.CreateStack(context.TODO(), &cloudformation.CreateStackInput{
+
+	ctx, cancel := context.WithTimeout(context.Background(), stackOperationTimeout)
+	defer cancel()
+	since := time.Now()
+	stack, err := cloudformationClient.CreateStack(ctx, &cloudformation.CreateStackInput{
 		TemplateBody: &stackCalculatedTemplate,
 		StackName:    &stackName,
-		Capabilities: []types.Capability{types.CapabilityCapabilityIam},
+		Capabilities: []types.Capability{types.CapabilityCapabilityNamedIam},
 	})
 	fmt.Println("deployment request sent to provider")
 	if err != nil {
 		return fmt.Errorf("failed to create stack: %w", err)
 	}
 	fmt.Println("waiting for deployment to complete")
-
-	var timeout bool
-	timer := time.NewTimer(5 * time.Minute)
-	go func() {
-		<-timer.C
-		timeout = true
-	}()
-	defer func() {
-		timer.Stop()
-	}()
-
-	for {
-		stacks, err := cloudformationClient.DescribeStacks(context.TODO(), &cloudformation.DescribeStacksInput{StackName: aws.String(stackName)})
-		if err != nil {
-			return fmt.Errorf("failed to create stack: %w", err)
-		}
-		if len(stacks.Stacks) == 1 && stacks.Stacks[0].StackStatus == types.StackStatusCreateComplete {
-			break
-		}
-		if timeout {
-			return fmt.Errorf("timout on waiting for stack to create")
-		}
-		time.Sleep(30 * time.Second)
+	if err = waitForStackStatus(ctx, cloudformationClient, *stack.StackId, since, types.StackStatusCreateComplete); err != nil {
+		return fmt.Errorf("failed to create stack: %w", err)
 	}
 
 	fmt.Println("deployment finished successfully")
 	return nil
 }
 
+// RenderFunctionClarityTemplate returns the stack template that deploying function clarity would create, and the s3 key
+// the verifier code archive, created locally, must be uploaded to. Nothing is uploaded or deployed.
+func (o *AwsClient) RenderFunctionClarityTemplate(trailName string, keyPath string, binaryPath string, deploymentConfig i.AWSInput, suffix string) (string, string, error) {
+	return prepareStackTemplate(o.getConfig(), trailName, keyPath, binaryPath, deploymentConfig, suffix, false)
+}
+
+// prepareStackTemplate archives the verifier code, uploads it if upload is set, and returns the stack template
+// deploying it, with the s3 key of the code.
+func prepareStackTemplate(cfg *aws.Config, trailName string, keyPath string, binaryPath string, deploymentConfig i.AWSInput, suffix string, upload bool) (string, string, error) {
+	codeKey, err := createFuncClarityArchive(binaryPath, keyPath, deploymentConfig.Policy)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create function clarity code archive: %w", err)
+	}
+	if upload {
+		if err = uploadFuncClarityCode(cfg, codeKey, deploymentConfig.Bucket); err != nil {
+			return "", "", fmt.Errorf("failed to upload function clarity code: %w", err)
+		}
+	}
+	if deploymentConfig.Policy != "" {
+		deploymentConfig.Policy = policy.FileName
+	}
+	err, stackCalculatedTemplate := calculateStackTemplate(trailName, cfg, deploymentConfig, suffix, codeKey)
+	if err != nil {
+		return "", "", err
+	}
+	return stackCalculatedTemplate, codeKey, nil
+}
+
 func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys *[]string, includedFuncRegions *[]string, topic *string) error {
 	cfg := o.getConfig()
 	lambdaClient := lambda.NewFromConfig(*cfg)
@@ -597,18 +691,32 @@ func (o *AwsClient) FillNotificationDetails(notification *Notification, function
 	}
 	notification.AccountId = funcArn.AccountID
 	notification.FunctionIdentifier = funcArn.String()
-	notification.FunctionName = funcArn.Resource
+	notification.FunctionName = functionNameOfArn(funcArn)
 	notification.Region = o.lambdaRegion
 	return nil
 }
 
-func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput, suffix string) (error, string) {
-	templateFile := "unified-template.template"
-	content, err := os.ReadFile(templateFile)
-	if err != nil {
-		return err, ""
+func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput, suffix string, codeKey string) (error, string) {
+	logGroupArn := ""
+	if !config.UsesEventBridge() && trailName != "" {
+		svt := cloudtrail.NewFromConfig(*cfg)
+		trail, err := svt.GetTrail(context.TODO(), &cloudtrail.GetTrailInput{Name: &trailName})
+		if err != nil {
+			return err, ""
+		}
+		if err = trailValid(trail); err != nil {
+			return err, ""
+		}
+		logGroupArn = *trail.Trail.CloudWatchLogsLogGroupArn
 	}
-	templateBody := string(content)
+	stackCalculatedTemplate, err := StackTemplate(config, suffix, codeKey, logGroupArn)
+	return err, stackCalculatedTemplate
+}
+
+// StackTemplate renders the stack template of the configuration, with the verifier code at codeKey in the bucket.
+// The verifier subscribes to the trail logs of logGroupArn, or to the logs of a trail of its own when it is empty,
+// unless the configuration ingests the api calls with eventbridge.
+func StackTemplate(config i.AWSInput, suffix string, codeKey string, logGroupArn string) (string, error) {
 	data := make(map[string]interface{}, 4)
 	data["bucketName"] = FunctionClarityBucketName
 	if config.Bucket != "" {
@@ -617,109 +725,54 @@ func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput
 
 	serConfig, err := yaml.Marshal(config)
 	if err != nil {
-		return fmt.Errorf("failed to create template. %v", err), ""
+		return "", fmt.Errorf("failed to create template. %v", err)
 	}
 	encodedConfig := b64.StdEncoding.EncodeToString(serConfig)
 	data["suffix"] = suffix
+	data["verifierRoleName"] = FunctionClarityVerifierRoleName
+	data["codeKey"] = codeKey
 	data["config"] = encodedConfig
-	if trailName == "" {
+	data["reverifySchedule"] = config.ReverifySchedule
+	if config.UsesEventBridge() {
+		// the rule receives the api calls of the lambda functions directly, no trail logs are subscribed to
+		data["withEventBridge"] = "True"
+	} else if logGroupArn == "" {
 		data["withTrail"] = "True"
 	} else {
-		svt := cloudtrail.NewFromConfig(*cfg)
-		trail, err := svt.GetTrail(context.TODO(), &cloudtrail.GetTrailInput{Name: &trailName})
+		cloudWatchArn, err := arn.Parse(logGroupArn)
 		if err != nil {
-			return err, ""
+			return "", err
 		}
-		if err = trailValid(trail); err != nil {
-			return err, ""
-		}
-		cloudWatchArn, err := arn.Parse(*trail.Trail.CloudWatchLogsLogGroupArn)
-		if err != nil {
-			return err, ""
-		}
-		data["logGroupArn"] = *trail.Trail.CloudWatchLogsLogGroupArn
+		data["logGroupArn"] = logGroupArn
 		data["logGroupName"] = strings.Split(cloudWatchArn.Resource, ":")[1]
 	}
-	tmpl := template.Must(template.New("template.json").Parse(templateBody))
+	tmpl := template.Must(template.New("template.json").Parse(unifiedTemplate))
 	buf := &bytes.Buffer{}
 	if err = tmpl.Execute(buf, data); err != nil {
-		return err, ""
-	}
-	stackCalculatedTemplate := buf.String()
-	return err, stackCalculatedTemplate
-}
-
-func (o *AwsClient) DownloadPublicKeys(bucketPath string) (string, error) {
-	cfg := o.getConfig()
-	s3Client := s3.NewFromConfig(*cfg)
-	bucketName, folder, err := extractBucketAndPath(bucketPath)
-	if err != nil {
-		return "", err
-	}
-	resultFolderName, err := uuid.NewRandom()
-	if err != nil {
-		return "", err
-	}
-
-	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
-	err = os.MkdirAll(folderResultFullPath, os.ModePerm)
-	if err != nil {
-		return "", err
-	}
-	listObjectsV2Response, err := s3Client.ListObjectsV2(context.TODO(),
-		&s3.ListObjectsV2Input{
-			Bucket: &bucketName,
-			Prefix: &folder,
-		})
-	if err != nil {
 		return "", err
 	}
-	for {
-		for _, item := range listObjectsV2Response.Contents {
-			if !strings.HasSuffix(*item.Key, "/") {
-				err = o.downloadFile(*item.Key, folderResultFullPath, bucketName)
-				if err != nil {
-					return "", err
-				}
-			}
-		}
-		if listObjectsV2Response.IsTruncated {
-			listObjectsV2Response, _ = s3Client.ListObjectsV2(context.TODO(),
-				&s3.ListObjectsV2Input{
-					Bucket:            &bucketName,
-					Prefix:            &folder,
-					ContinuationToken: listObjectsV2Response.ContinuationToken,
-				})
-		} else {
-			break
-		}
-	}
-	return folderResultFullPath, nil
+	return buf.String(), nil
 }
 
-func (o *AwsClient) downloadFile(filePath string, folderToSave string, bucketName string) error {
-	cfg := o.getConfig()
-	downloader := manager.NewDownloader(s3.NewFromConfig(*cfg))
-	fileName := filePath
-	outputFile := folderToSave + "/" + strings.ReplaceAll(fileName, "/", "-")
-	f, err := os.Create(outputFile)
+// MemberRoleTemplate returns the template of the role the verifier assumes in the member accounts of the
+// organization, to be deployed to them with a StackSet. The role trusts the verifier functions of the verifier
+// account, in any region, and the trusted principals.
+func MemberRoleTemplate(verifierAccountId string, roleName string, trustedPrincipals []string) (string, error) {
+	principals := append([]string{fmt.Sprintf("arn:aws:iam::%s:role/%s-*", verifierAccountId, FunctionClarityVerifierRoleName)}, trustedPrincipals...)
+	serPrincipals, err := json.Marshal(principals)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to create member role template: %w", err)
 	}
-	defer f.Close()
-	if bucketName == "" {
-		bucketName = o.s3
-	}
-
-	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(filePath),
-	})
-
+	data := map[string]interface{}{"verifierAccountId": verifierAccountId, "roleName": roleName, "trustedPrincipals": string(serPrincipals)}
+	tmpl, err := template.New("member-role-template.json").Parse(memberRoleTemplate)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to create member role template: %w", err)
 	}
-	return nil
+	buf := &bytes.Buffer{}
+	if err = tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("failed to create member role template: %w", err)
+	}
+	return buf.String(), nil
 }
 
 func extractBucketAndPath(bucketPath string) (string, string, error) {
@@ -765,67 +818,112 @@ func (o *AwsClient) getConfigForLambda() *aws.Config {
 	if err != nil {
 		panic(fmt.Sprintf("failed loading config, %v", err))
 	}
-	return &cfg
+	return o.assumeRoleIfNeeded(&cfg)
 }
 
-func uploadFuncClarityCode(cfg *aws.Config, keyPath string, bucket string) error {
-	s3Client := s3.NewFromConfig(*cfg)
-	var err error
-	if cfg.Region != "us-east-1" {
-		_, err = s3Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
-			Bucket:                    aws.String(bucket),
-			CreateBucketConfiguration: &s3types.CreateBucketConfiguration{LocationConstraint: s3types.BucketLocationConstraint(cfg.Region)},
-		})
-	} else {
-		_, err = s3Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
-			Bucket: aws.String(bucket),
-		})
+// assumeRoleIfNeeded replaces the credentials of the config with the credentials of the role of the client, if any.
+func (o *AwsClient) assumeRoleIfNeeded(cfg *aws.Config) *aws.Config {
+	if o.roleArn == "" {
+		return cfg
 	}
+	o.roleCredentialsOnce.Do(func() {
+		o.roleCredentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(*cfg), o.roleArn, func(options *stscreds.AssumeRoleOptions) {
+			options.RoleSessionName = "function-clarity"
+		}))
+	})
+	roleCfg := cfg.Copy()
+	roleCfg.Credentials = o.roleCredentials
+	return &roleCfg
+}
 
-	var bne *s3types.BucketAlreadyOwnedByYou
-	if err != nil && !errors.As(err, &bne) {
-		return err
-	}
-	archive, err := os.Create("function-clarity.zip")
+// createFuncClarityArchive zips the verifier binary together with the public key and the policy, and returns the s3 key
+// of the archive, named after its content so the stack updates the verifier code only when it changed.
+func createFuncClarityArchive(binaryPath string, keyPath string, policyPath string) (string, error) {
+	archive, err := os.Create(FunctionClarityCodeArchive)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer archive.Close()
 	zipWriter := zip.NewWriter(archive)
-	binaryFile, err := os.Open("aws_function")
+	binaryFile, err := os.Open(binaryPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer binaryFile.Close()
 
 	w1, err := zipWriter.Create("function-clarity")
 	if err != nil {
-		return err
+		return "", err
 	}
 	if _, err := io.Copy(w1, binaryFile); err != nil {
-		return err
+		return "", err
 	}
 
 	if keyPath != "" {
 		publicKey, err := os.Open(keyPath)
 		if err != nil {
-			return err
+			return "", err
 		}
 		defer publicKey.Close()
 
 		w2, err := zipWriter.Create("cosign.pub")
 		if err != nil {
-			return err
+			return "", err
 		}
 		if _, err := io.Copy(w2, publicKey); err != nil {
-			return err
+			return "", err
+		}
+	}
+	if policyPath != "" {
+		policyFile, err := os.Open(policyPath)
+		if err != nil {
+			return "", err
+		}
+		defer policyFile.Close()
+
+		w3, err := zipWriter.Create(policy.FileName)
+		if err != nil {
+			return "", err
 		}
+		if _, err := io.Copy(w3, policyFile); err != nil {
+			return "", err
+		}
+	}
+	if err = zipWriter.Close(); err != nil {
+		return "", err
+	}
+	if _, err = archive.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err = io.Copy(hash, archive); err != nil {
+		return "", err
+	}
+	return "function-clarity-" + hex.EncodeToString(hash.Sum(nil))[:16] + ".zip", nil
+}
+
+func uploadFuncClarityCode(cfg *aws.Config, codeKey string, bucket string) error {
+	s3Client := s3.NewFromConfig(*cfg)
+	var err error
+	if cfg.Region != "us-east-1" {
+		_, err = s3Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
+			Bucket:                    aws.String(bucket),
+			CreateBucketConfiguration: &s3types.CreateBucketConfiguration{LocationConstraint: s3types.BucketLocationConstraint(cfg.Region)},
+		})
+	} else {
+		_, err = s3Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
+			Bucket: aws.String(bucket),
+		})
+	}
+
+	var bne *s3types.BucketAlreadyOwnedByYou
+	if err != nil && !errors.As(err, &bne) {
+		return err
 	}
-	zipWriter.Close()
 	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
 	// Upload the file to S3.
 	//p := mpb.New()
-	file, err := os.Open("function-clarity.zip")
+	file, err := os.Open(FunctionClarityCodeArchive)
 	//fileInfo, err := file.Stat()
 	//reader := &utils.ProgressBarReader{
 	//	Fp:      file,
@@ -842,10 +940,11 @@ func uploadFuncClarityCode(cfg *aws.Config, keyPath string, bucket string) error
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 	fmt.Println("Uploading function-clarity function code to s3 bucket, this may take a few minutes")
 	_, err = uploader.Upload(context.TODO(), &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
-		Key:    aws.String("function-clarity.zip"),
+		Key:    aws.String(codeKey),
 		Body:   file,
 	})
 	if err != nil {
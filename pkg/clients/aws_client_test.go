@@ -0,0 +1,156 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnqualifiedFunctionArn(t *testing.T) {
+	functionArn := "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	for identifier, expected := range map[string]string{
+		functionArn:           functionArn,
+		functionArn + ":7":    functionArn,
+		functionArn + ":prod": functionArn,
+		"orders":              "orders",
+		"orders:prod":         "orders:prod",
+	} {
+		if actual := unqualifiedFunctionArn(identifier); actual != expected {
+			t.Errorf("Error. unqualified arn of: %s, expected: %s, got: %s", identifier, expected, actual)
+		}
+	}
+}
+
+func TestIsQualifiedFunctionArn(t *testing.T) {
+	functionArn := "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	for identifier, expected := range map[string]bool{
+		functionArn:              false,
+		functionArn + ":$LATEST": false,
+		functionArn + ":7":       true,
+		functionArn + ":prod":    true,
+	} {
+		if actual := isQualifiedFunctionArn(identifier); actual != expected {
+			t.Errorf("Error. qualified arn: %s, expected: %t, got: %t", identifier, expected, actual)
+		}
+	}
+}
+
+func TestHandleVerifiedQualifiedFunction(t *testing.T) {
+	// a signed version or alias must leave the function, which may be blocked for an unsigned $LATEST, as is. The
+	// client has no credentials, so any call to lambda fails.
+	client := NewAwsClient("", "", "us-east-1", "us-east-1")
+	for _, identifier := range []string{"arn:aws:lambda:us-east-1:123456789012:function:orders:7", "arn:aws:lambda:us-east-1:123456789012:function:orders:prod"} {
+		if err := client.HandleDetect(&identifier, ""); err != nil {
+			t.Errorf("Error. expected the function of: %s not to be tagged, got: %v", identifier, err)
+		}
+		if err := client.HandleBlock(&identifier, false); err != nil {
+			t.Errorf("Error. expected the function of: %s not to be unblocked, got: %v", identifier, err)
+		}
+	}
+}
+
+func TestFunctionArnInAccount(t *testing.T) {
+	functionArn := "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	if actual := FunctionArnInAccount("orders", "123456789012", "us-east-1"); actual != functionArn {
+		t.Errorf("Error. expected: %s, got: %s", functionArn, actual)
+	}
+	if actual := FunctionArnInAccount("orders:prod", "123456789012", "us-east-1"); actual != functionArn+":prod" {
+		t.Errorf("Error. expected qualified arn, got: %s", actual)
+	}
+	if actual := FunctionArnInAccount(functionArn, "210987654321", "eu-west-1"); actual != functionArn {
+		t.Errorf("Error. expected arn to be returned as is, got: %s", actual)
+	}
+	if actual := FunctionArnInAccount("orders", "", "us-east-1"); actual != "orders" {
+		t.Errorf("Error. expected name without account to be returned as is, got: %s", actual)
+	}
+}
+
+func TestListOrganizationalUnitAccounts(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	pages := map[string]string{
+		"ListAccountsForParent ou-root":             `{"Accounts":[{"Id":"111111111111","Status":"ACTIVE"}],"NextToken":"next"}`,
+		"ListAccountsForParent ou-root next":        `{"Accounts":[{"Id":"222222222222","Status":"SUSPENDED"}]}`,
+		"ListOrganizationalUnitsForParent ou-root":  `{"OrganizationalUnits":[{"Id":"ou-child"}]}`,
+		"ListAccountsForParent ou-child":            `{"Accounts":[{"Id":"333333333333","Status":"ACTIVE"}]}`,
+		"ListOrganizationalUnitsForParent ou-child": `{"OrganizationalUnits":[]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("Error. expected a signed request, got authorization: %s", r.Header.Get("Authorization"))
+		}
+		input := organizationsListInput{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatal(err)
+		}
+		key := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), organizationsTargetPrefix) + " " + input.ParentId
+		if input.NextToken != nil {
+			key += " " + *input.NextToken
+		}
+		page, exist := pages[key]
+		if !exist {
+			t.Errorf("Error. unexpected request: %s", key)
+		}
+		w.Write([]byte(page)) //nolint:errcheck
+	}))
+	defer server.Close()
+	previousEndpoint := organizationsEndpoint
+	organizationsEndpoint = server.URL
+	defer func() { organizationsEndpoint = previousEndpoint }()
+
+	accountIds, err := NewAwsClientInit("", "", "us-east-1").ListOrganizationalUnitAccounts(context.Background(), "ou-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"111111111111", "333333333333"}; !reflect.DeepEqual(accountIds, expected) {
+		t.Errorf("Error. expected accounts: %v, got: %v", expected, accountIds)
+	}
+}
+
+func TestMemberRoleTemplate(t *testing.T) {
+	content, err := MemberRoleTemplate("111111111111", "FunctionClarityMemberRole", []string{"arn:aws:iam::111111111111:role/Security"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Resources map[string]struct {
+			Properties struct {
+				AssumeRolePolicyDocument struct {
+					Statement []struct {
+						Condition map[string]map[string][]string
+					}
+				}
+			}
+		}
+	}
+	if err = json.Unmarshal([]byte(content), &parsed); err != nil {
+		t.Fatalf("invalid member role template: %v", err)
+	}
+	statements := parsed.Resources["FunctionClarityMemberRole"].Properties.AssumeRolePolicyDocument.Statement
+	if len(statements) != 1 {
+		t.Fatalf("expected a single trust statement, got: %d", len(statements))
+	}
+	expected := []string{"arn:aws:iam::111111111111:role/FunctionClarityLambdaRole-*", "arn:aws:iam::111111111111:role/Security"}
+	if principals := statements[0].Condition["ArnLike"]["aws:PrincipalArn"]; !reflect.DeepEqual(principals, expected) {
+		t.Fatalf("expected trusted principals: %v, got: %v", expected, principals)
+	}
+}
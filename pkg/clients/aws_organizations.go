@@ -0,0 +1,124 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// The organizations api is called directly through its json protocol, listing the accounts of an organizational unit
+// takes two operations only, which doesn't justify the dependency on the organizations sdk module.
+const (
+	organizationsTargetPrefix = "AWSOrganizationsV20161128."
+	organizationsRegion       = "us-east-1"
+)
+
+var organizationsEndpoint = "https://organizations.us-east-1.amazonaws.com/"
+
+type organizationsListInput struct {
+	ParentId  string
+	NextToken *string `json:",omitempty"`
+}
+
+type organizationsListOutput struct {
+	Accounts []struct {
+		Id     string
+		Status string
+	}
+	OrganizationalUnits []struct {
+		Id string
+	}
+	NextToken *string
+}
+
+// ListOrganizationalUnitAccounts returns the ids of the active accounts in the organizational unit and in all of its
+// child units. The credentials must be of the management account, or of a delegated administrator.
+func (o *AwsClient) ListOrganizationalUnitAccounts(ctx context.Context, organizationalUnitId string) ([]string, error) {
+	cfg := o.getConfig()
+	var accountIds []string
+	parentIds := []string{organizationalUnitId}
+	for len(parentIds) > 0 {
+		parentId := parentIds[0]
+		parentIds = parentIds[1:]
+		for _, operation := range []string{"ListAccountsForParent", "ListOrganizationalUnitsForParent"} {
+			input := organizationsListInput{ParentId: parentId}
+			for {
+				output := organizationsListOutput{}
+				if err := callOrganizations(ctx, cfg, operation, input, &output); err != nil {
+					return nil, fmt.Errorf("failed to list accounts of organizational unit: %s: %w", organizationalUnitId, err)
+				}
+				for _, account := range output.Accounts {
+					if account.Status == "ACTIVE" {
+						accountIds = append(accountIds, account.Id)
+					}
+				}
+				for _, unit := range output.OrganizationalUnits {
+					parentIds = append(parentIds, unit.Id)
+				}
+				if output.NextToken == nil || *output.NextToken == "" {
+					break
+				}
+				input.NextToken = output.NextToken
+			}
+		}
+	}
+	return accountIds, nil
+}
+
+func callOrganizations(ctx context.Context, cfg *aws.Config, operation string, input interface{}, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, organizationsEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", organizationsTargetPrefix+operation)
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err = v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), "organizations", organizationsRegion, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: %s: %s", operation, resp.Status, content)
+	}
+	return json.Unmarshal(content, output)
+}
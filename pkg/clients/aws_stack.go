@@ -0,0 +1,389 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	i "github.com/openclarity/functionclarity/pkg/init"
+)
+
+// stackOperationTimeout is the time a stack creation, update or deletion is waited for.
+const stackOperationTimeout = 30 * time.Minute
+
+// stackPollInterval is the interval the stack and its events are polled at while waiting for an operation.
+var stackPollInterval = 5 * time.Second
+
+// stackAPI is the part of the cloudformation client the stack operations are waited with.
+type stackAPI interface {
+	DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error)
+	DescribeStackEvents(ctx context.Context, params *cloudformation.DescribeStackEventsInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error)
+}
+
+// FunctionClarityStackName returns the name of the function clarity stack deployed with the suffix.
+func FunctionClarityStackName(suffix string) string {
+	return "function-clarity-stack" + suffix
+}
+
+// UpdateFunctionClarity updates the deployed stack to the current template, configuration and verifier code. The
+// changes are computed with a change set and shown, then applied if confirm approves them, confirm may be nil to
+// apply them without asking.
+func (o *AwsClient) UpdateFunctionClarity(trailName string, keyPath string, binaryPath string, deploymentConfig i.AWSInput, suffix string, confirm func() (bool, error)) error {
+	cfg := o.getConfig()
+	cloudformationClient := cloudformation.NewFromConfig(*cfg)
+	stackName := FunctionClarityStackName(suffix)
+	exists, err := stackExists(stackName, cloudformationClient)
+	if err != nil {
+		return fmt.Errorf("failed to check if stack exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("function clarity isn't deployed, deploy it without --update first")
+	}
+	stackCalculatedTemplate, _, err := prepareStackTemplate(cfg, trailName, keyPath, binaryPath, deploymentConfig, suffix, true)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stackOperationTimeout)
+	defer cancel()
+	changeSetName := "function-clarity-update-" + strconv.FormatInt(time.Now().Unix(), 10)
+	changeSet, err := cloudformationClient.CreateChangeSet(ctx, &cloudformation.CreateChangeSetInput{
+		ChangeSetName: aws.String(changeSetName),
+		StackName:     aws.String(stackName),
+		TemplateBody:  aws.String(stackCalculatedTemplate),
+		ChangeSetType: types.ChangeSetTypeUpdate,
+		Capabilities:  []types.Capability{types.CapabilityCapabilityNamedIam},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create change set: %w", err)
+	}
+	fmt.Println("calculating the changes to deploy")
+	changes, err := waitForChangeSet(ctx, cloudformationClient, changeSet.Id)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("function clarity is up to date, no changes to deploy")
+		_, err = cloudformationClient.DeleteChangeSet(ctx, &cloudformation.DeleteChangeSetInput{ChangeSetName: changeSet.Id})
+		if err != nil {
+			return fmt.Errorf("failed to delete change set: %w", err)
+		}
+		return nil
+	}
+	if err = writeChanges(os.Stdout, changes); err != nil {
+		return err
+	}
+	if confirm != nil {
+		apply, err := confirm()
+		if err != nil {
+			return err
+		}
+		if !apply {
+			fmt.Println("changes weren't applied")
+			_, err = cloudformationClient.DeleteChangeSet(ctx, &cloudformation.DeleteChangeSetInput{ChangeSetName: changeSet.Id})
+			if err != nil {
+				return fmt.Errorf("failed to delete change set: %w", err)
+			}
+			return nil
+		}
+	}
+
+	since := time.Now()
+	if _, err = cloudformationClient.ExecuteChangeSet(ctx, &cloudformation.ExecuteChangeSetInput{ChangeSetName: changeSet.Id}); err != nil {
+		return fmt.Errorf("failed to execute change set: %w", err)
+	}
+	fmt.Println("waiting for update to complete")
+	if err = waitForStackStatus(ctx, cloudformationClient, *changeSet.StackId, since, types.StackStatusUpdateComplete); err != nil {
+		return fmt.Errorf("failed to update stack: %w", err)
+	}
+	fmt.Println("update finished successfully")
+	return nil
+}
+
+// DestroyFunctionClarity deletes the deployed stack, and the verifier code archives uploaded to the deployment bucket
+// if deleteCode is set. The other objects of the deployment bucket, such as signatures, are kept.
+// The buckets created by the stack are emptied first, since a bucket with objects can't be deleted.
+func (o *AwsClient) DestroyFunctionClarity(suffix string, bucket string, deleteCode bool) error {
+	cfg := o.getConfig()
+	cloudformationClient := cloudformation.NewFromConfig(*cfg)
+	s3Client := s3.NewFromConfig(*cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), stackOperationTimeout)
+	defer cancel()
+
+	stackName := FunctionClarityStackName(suffix)
+	stacks, err := cloudformationClient.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(stackName)})
+	if err != nil && !strings.Contains(err.Error(), "does not exist") {
+		return fmt.Errorf("failed to check if stack exists: %w", err)
+	}
+	if err == nil && len(stacks.Stacks) == 1 {
+		stackId := *stacks.Stacks[0].StackId
+		resources, err := cloudformationClient.DescribeStackResources(ctx, &cloudformation.DescribeStackResourcesInput{StackName: aws.String(stackId)})
+		if err != nil {
+			return fmt.Errorf("failed to list stack resources: %w", err)
+		}
+		for _, resource := range resources.StackResources {
+			if aws.ToString(resource.ResourceType) == "AWS::S3::Bucket" && resource.PhysicalResourceId != nil {
+				if err = emptyS3Bucket(ctx, s3Client, *resource.PhysicalResourceId); err != nil {
+					return fmt.Errorf("failed to empty stack bucket: %s: %w", *resource.PhysicalResourceId, err)
+				}
+			}
+		}
+		since := time.Now()
+		if _, err = cloudformationClient.DeleteStack(ctx, &cloudformation.DeleteStackInput{StackName: aws.String(stackId)}); err != nil {
+			return fmt.Errorf("failed to delete stack: %w", err)
+		}
+		fmt.Println("waiting for stack deletion to complete")
+		if err = waitForStackStatus(ctx, cloudformationClient, stackId, since, types.StackStatusDeleteComplete); err != nil {
+			return fmt.Errorf("failed to delete stack: %w", err)
+		}
+		fmt.Println("stack deleted successfully")
+	} else {
+		fmt.Println("function clarity stack doesn't exist, nothing to delete")
+	}
+
+	if deleteCode {
+		deleted, err := deleteS3Objects(ctx, s3Client, bucket, verifierCodePrefix, isVerifierCodeKey)
+		if err != nil {
+			return fmt.Errorf("failed to delete verifier code from bucket: %s: %w", bucket, err)
+		}
+		fmt.Printf("deleted %d verifier code archives from bucket: %s\n", deleted, bucket)
+	}
+	return nil
+}
+
+// waitForChangeSet waits for the change set to be calculated, and returns its changes.
+func waitForChangeSet(ctx context.Context, client *cloudformation.Client, changeSetId *string) ([]types.Change, error) {
+	for {
+		changeSet, err := client.DescribeChangeSet(ctx, &cloudformation.DescribeChangeSetInput{ChangeSetName: changeSetId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe change set: %w", err)
+		}
+		switch changeSet.Status {
+		case types.ChangeSetStatusCreateComplete:
+			changes := changeSet.Changes
+			for changeSet.NextToken != nil {
+				changeSet, err = client.DescribeChangeSet(ctx, &cloudformation.DescribeChangeSetInput{ChangeSetName: changeSetId, NextToken: changeSet.NextToken})
+				if err != nil {
+					return nil, fmt.Errorf("failed to describe change set: %w", err)
+				}
+				changes = append(changes, changeSet.Changes...)
+			}
+			return changes, nil
+		case types.ChangeSetStatusFailed:
+			reason := aws.ToString(changeSet.StatusReason)
+			// a change set without changes fails to create
+			if strings.Contains(reason, "didn't contain changes") || strings.Contains(reason, "No updates are to be performed") {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to create change set: %s", reason)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout on waiting for change set: %w", ctx.Err())
+		case <-time.After(stackPollInterval):
+		}
+	}
+}
+
+// writeChanges writes the resource changes of a change set as a table.
+func writeChanges(w io.Writer, changes []types.Change) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tRESOURCE\tTYPE\tREPLACEMENT\tPROPERTIES")
+	for _, change := range changes {
+		resourceChange := change.ResourceChange
+		if resourceChange == nil {
+			continue
+		}
+		var properties []string
+		for _, detail := range resourceChange.Details {
+			if detail.Target == nil {
+				continue
+			}
+			property := aws.ToString(detail.Target.Name)
+			if property == "" {
+				property = string(detail.Target.Attribute)
+			}
+			if property != "" && !containsString(properties, property) {
+				properties = append(properties, property)
+			}
+		}
+		sort.Strings(properties)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", resourceChange.Action, aws.ToString(resourceChange.LogicalResourceId),
+			aws.ToString(resourceChange.ResourceType), resourceChange.Replacement, strings.Join(properties, ","))
+	}
+	return tw.Flush()
+}
+
+// waitForStackStatus waits for the stack operation to end, printing the stack events since the operation started, and
+// fails unless the stack ends in the expected status.
+func waitForStackStatus(ctx context.Context, client stackAPI, stackId string, since time.Time, expected types.StackStatus) error {
+	stack, err := waitForStack(ctx, client, stackId, since, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if stack.StackStatus != expected {
+		if reason := aws.ToString(stack.StackStatusReason); reason != "" {
+			return fmt.Errorf("stack operation ended with status: %s: %s", stack.StackStatus, reason)
+		}
+		return fmt.Errorf("stack operation ended with status: %s", stack.StackStatus)
+	}
+	return nil
+}
+
+// waitForStack polls the stack until it is no longer in progress, writing its new events meanwhile, and returns it.
+// The stack must be given by id, since a deleted stack can't be described by name.
+func waitForStack(ctx context.Context, client stackAPI, stackId string, since time.Time, w io.Writer) (*types.Stack, error) {
+	seen := map[string]bool{}
+	for {
+		stacks, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(stackId)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stack: %w", err)
+		}
+		if len(stacks.Stacks) != 1 {
+			return nil, fmt.Errorf("failed to describe stack: %s not found", stackId)
+		}
+		events, err := newStackEvents(ctx, client, stackId, since, seen)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			fmt.Fprintf(w, "%s %s %s %s %s\n", event.Timestamp.Local().Format("15:04:05"), aws.ToString(event.LogicalResourceId),
+				aws.ToString(event.ResourceType), event.ResourceStatus, aws.ToString(event.ResourceStatusReason))
+		}
+		stack := stacks.Stacks[0]
+		if !strings.HasSuffix(string(stack.StackStatus), "_IN_PROGRESS") {
+			return &stack, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout on waiting for stack: %w", ctx.Err())
+		case <-time.After(stackPollInterval):
+		}
+	}
+}
+
+// newStackEvents returns the events of the stack since the given time that weren't seen yet, oldest first.
+func newStackEvents(ctx context.Context, client stackAPI, stackId string, since time.Time, seen map[string]bool) ([]types.StackEvent, error) {
+	var events []types.StackEvent
+	var nextToken *string
+	for {
+		output, err := client.DescribeStackEvents(ctx, &cloudformation.DescribeStackEventsInput{StackName: aws.String(stackId), NextToken: nextToken})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stack events: %w", err)
+		}
+		// the events are listed newest first, so the listing stops at the first event already seen or too old
+		done := output.NextToken == nil
+		for _, event := range output.StackEvents {
+			id := aws.ToString(event.EventId)
+			if seen[id] || (event.Timestamp != nil && event.Timestamp.Before(since)) {
+				done = true
+				break
+			}
+			seen[id] = true
+			events = append(events, event)
+		}
+		if done {
+			break
+		}
+		nextToken = output.NextToken
+	}
+	for left, right := 0, len(events)-1; left < right; left, right = left+1, right-1 {
+		events[left], events[right] = events[right], events[left]
+	}
+	return events, nil
+}
+
+// verifierCodePrefix is the key prefix of the verifier code archives uploaded to the deployment bucket.
+const verifierCodePrefix = "function-clarity"
+
+// s3ObjectsAPI is the part of the s3 client the objects of a bucket are deleted with.
+type s3ObjectsAPI interface {
+	s3.ListObjectsV2APIClient
+	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+}
+
+// isVerifierCodeKey reports whether the key is a verifier code archive, function-clarity-<hash>.zip, or
+// function-clarity.zip uploaded by older versions.
+func isVerifierCodeKey(key string) bool {
+	if key == FunctionClarityCodeArchive {
+		return true
+	}
+	return strings.HasPrefix(key, verifierCodePrefix+"-") && strings.HasSuffix(key, ".zip") && !strings.Contains(key, "/")
+}
+
+// emptyS3Bucket deletes all the objects of the bucket, a bucket that doesn't exist is considered empty.
+func emptyS3Bucket(ctx context.Context, client s3ObjectsAPI, bucket string) error {
+	_, err := deleteS3Objects(ctx, client, bucket, "", nil)
+	return err
+}
+
+// deleteS3Objects deletes the objects of the bucket under the prefix which match, all of them if match is nil,
+// and returns the number of deleted objects. A bucket that doesn't exist is considered empty.
+func deleteS3Objects(ctx context.Context, client s3ObjectsAPI, bucket string, prefix string, match func(key string) bool) (int, error) {
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	deleted := 0
+	paginator := s3.NewListObjectsV2Paginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			var noSuchBucket *s3types.NoSuchBucket
+			if errors.As(err, &noSuchBucket) {
+				return deleted, nil
+			}
+			return deleted, err
+		}
+		objects := make([]s3types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			if match == nil || match(aws.ToString(object.Key)) {
+				objects = append(objects, s3types.ObjectIdentifier{Key: object.Key})
+			}
+		}
+		if len(objects) == 0 {
+			continue
+		}
+		if _, err = client.DeleteObjects(ctx, &s3.DeleteObjectsInput{Bucket: aws.String(bucket), Delete: &s3types.Delete{Objects: objects, Quiet: true}}); err != nil {
+			return deleted, err
+		}
+		deleted += len(objects)
+	}
+	return deleted, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
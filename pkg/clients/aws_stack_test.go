@@ -0,0 +1,194 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeStackAPI returns the next of its statuses on every describe, with the events added up to that poll.
+type fakeStackAPI struct {
+	statuses []types.StackStatus
+	events   [][]types.StackEvent
+	polls    int
+}
+
+func (f *fakeStackAPI) DescribeStacks(_ context.Context, params *cloudformation.DescribeStacksInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+	status := f.statuses[f.polls]
+	f.polls++
+	return &cloudformation.DescribeStacksOutput{Stacks: []types.Stack{{StackId: params.StackName, StackStatus: status,
+		StackStatusReason: aws.String("reason of " + string(status))}}}, nil
+}
+
+func (f *fakeStackAPI) DescribeStackEvents(_ context.Context, params *cloudformation.DescribeStackEventsInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error) {
+	var events []types.StackEvent
+	for poll := 0; poll < f.polls; poll++ {
+		events = append(append([]types.StackEvent{}, f.events[poll]...), events...)
+	}
+	// two events a page, newest first
+	start := 0
+	if params.NextToken != nil {
+		start = len(*params.NextToken)
+	}
+	end := start + 2
+	var nextToken *string
+	if end < len(events) {
+		nextToken = aws.String(strings.Repeat("x", end))
+	} else {
+		end = len(events)
+	}
+	return &cloudformation.DescribeStackEventsOutput{StackEvents: events[start:end], NextToken: nextToken}, nil
+}
+
+func stackEvent(id string, timestamp time.Time, status types.ResourceStatus) types.StackEvent {
+	return types.StackEvent{EventId: aws.String(id), LogicalResourceId: aws.String(id), ResourceType: aws.String("AWS::Lambda::Function"),
+		ResourceStatus: status, Timestamp: aws.Time(timestamp)}
+}
+
+func TestWaitForStack(t *testing.T) {
+	stackPollInterval = time.Millisecond
+	since := time.Now()
+	api := &fakeStackAPI{
+		statuses: []types.StackStatus{types.StackStatusUpdateInProgress, types.StackStatusUpdateInProgress, types.StackStatusUpdateComplete},
+		events: [][]types.StackEvent{
+			{stackEvent("second", since.Add(2*time.Second), types.ResourceStatusUpdateInProgress), stackEvent("first", since.Add(time.Second), types.ResourceStatusUpdateInProgress),
+				stackEvent("previous", since.Add(-time.Minute), types.ResourceStatusCreateComplete)},
+			{},
+			{stackEvent("fourth", since.Add(4*time.Second), types.ResourceStatusUpdateComplete), stackEvent("third", since.Add(3*time.Second), types.ResourceStatusUpdateComplete)},
+		},
+	}
+	out := &bytes.Buffer{}
+	stack, err := waitForStack(context.Background(), api, "stack-id", since, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stack.StackStatus != types.StackStatusUpdateComplete {
+		t.Fatalf("unexpected stack status: %s", stack.StackStatus)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []string{"first", "second", "third", "fourth"}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected the events: %v, got: %s", expected, out.String())
+	}
+	for index, line := range lines {
+		if !strings.Contains(line, " "+expected[index]+" ") {
+			t.Fatalf("expected event: %s at line %d, got: %s", expected[index], index, line)
+		}
+	}
+}
+
+func TestWaitForStackStatus(t *testing.T) {
+	stackPollInterval = time.Millisecond
+	api := &fakeStackAPI{statuses: []types.StackStatus{types.StackStatusRollbackInProgress, types.StackStatusRollbackComplete}, events: [][]types.StackEvent{{}, {}}}
+	err := waitForStackStatus(context.Background(), api, "stack-id", time.Now(), types.StackStatusCreateComplete)
+	if err == nil || !strings.Contains(err.Error(), "ROLLBACK_COMPLETE: reason of ROLLBACK_COMPLETE") {
+		t.Fatalf("expected the rollback to fail, got: %v", err)
+	}
+}
+
+func TestWaitForStackTimeout(t *testing.T) {
+	stackPollInterval = time.Hour
+	api := &fakeStackAPI{statuses: []types.StackStatus{types.StackStatusCreateInProgress}, events: [][]types.StackEvent{{}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := waitForStack(ctx, api, "stack-id", time.Now(), &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected a timeout, got: %v", err)
+	}
+}
+
+func TestWriteChanges(t *testing.T) {
+	changes := []types.Change{{ResourceChange: &types.ResourceChange{
+		Action:            types.ChangeActionModify,
+		LogicalResourceId: aws.String("FunctionClarityLambda"),
+		ResourceType:      aws.String("AWS::Lambda::Function"),
+		Replacement:       types.ReplacementFalse,
+		Details: []types.ResourceChangeDetail{
+			{Target: &types.ResourceTargetDefinition{Attribute: types.ResourceAttributeProperties, Name: aws.String("Code")}},
+			{Target: &types.ResourceTargetDefinition{Attribute: types.ResourceAttributeProperties, Name: aws.String("Environment")}},
+			{Target: &types.ResourceTargetDefinition{Attribute: types.ResourceAttributeProperties, Name: aws.String("Code")}},
+		},
+	}}}
+	out := &bytes.Buffer{}
+	if err := writeChanges(out, changes); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"ACTION", "Modify", "FunctionClarityLambda", "AWS::Lambda::Function", "False", "Code,Environment"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected: %s in the changes, got: %s", expected, out.String())
+		}
+	}
+}
+
+// fakeS3ObjectsAPI lists its keys under the requested prefix, and records the deleted keys.
+type fakeS3ObjectsAPI struct {
+	keys    []string
+	deleted []string
+}
+
+func (f *fakeS3ObjectsAPI) ListObjectsV2(_ context.Context, params *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	var contents []s3types.Object
+	for _, key := range f.keys {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			contents = append(contents, s3types.Object{Key: aws.String(key)})
+		}
+	}
+	return &s3.ListObjectsV2Output{Contents: contents}, nil
+}
+
+func (f *fakeS3ObjectsAPI) DeleteObjects(_ context.Context, params *s3.DeleteObjectsInput, _ ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	for _, object := range params.Delete.Objects {
+		f.deleted = append(f.deleted, aws.ToString(object.Key))
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func TestDeleteVerifierCode(t *testing.T) {
+	client := &fakeS3ObjectsAPI{keys: []string{
+		"function-clarity-0123456789abcdef.zip",
+		"function-clarity.zip",
+		"function-clarity-notes.txt",
+		"function-clarity-0123456789abcdef.zip/identity",
+		"0123456789abcdef.manifest.json",
+		"my-func.function.manifest.json",
+		"code.zip",
+	}}
+	deleted, err := deleteS3Objects(context.Background(), client, "bucket", verifierCodePrefix, isVerifierCodeKey)
+	if err != nil {
+		t.Fatalf("failed to delete verifier code: %v", err)
+	}
+	expected := []string{"function-clarity-0123456789abcdef.zip", "function-clarity.zip"}
+	if deleted != len(expected) || strings.Join(client.deleted, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected deleted keys %v, got %d: %v", expected, deleted, client.deleted)
+	}
+
+	client.deleted = nil
+	if err = emptyS3Bucket(context.Background(), client, "bucket"); err != nil {
+		t.Fatalf("failed to empty bucket: %v", err)
+	}
+	if len(client.deleted) != len(client.keys) {
+		t.Fatalf("expected all the %d keys deleted, got %v", len(client.keys), client.deleted)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const verifierBinaryName = "aws_function"
+
+// verifierReleaseUrl is the url of the verifier binary archive released with a version.
+var verifierReleaseUrl = "https://github.com/openclarity/functionclarity/releases/download/%s/aws_function.tar.gz"
+
+// ResolveVerifierBinary returns the path of the verifier binary to deploy: binaryPath when set, else the binary released
+// with the version of the cli, downloaded and checked against its published checksum once, under the home dir.
+func ResolveVerifierBinary(binaryPath string) (string, error) {
+	if binaryPath != "" {
+		if _, err := os.Stat(binaryPath); err != nil {
+			return "", fmt.Errorf("failed to find verifier binary: %w", err)
+		}
+		return binaryPath, nil
+	}
+	if utils.Version == "" {
+		return "", fmt.Errorf("failed to find verifier binary: a development build has no released verifier, build it with: "+
+			"GOOS=linux GOARCH=amd64 go build -o %s ./aws_function_pkg and set its path with --verifier-binary", verifierBinaryName)
+	}
+	cachedPath := filepath.Join(utils.FunctionClarityHomeDir, "verifier", utils.Version, verifierBinaryName)
+	if _, err := os.Stat(cachedPath); err == nil {
+		return cachedPath, nil
+	}
+	if err := downloadVerifierBinary(fmt.Sprintf(verifierReleaseUrl, utils.Version), cachedPath); err != nil {
+		return "", fmt.Errorf("failed to download verifier binary of version: %s: %w", utils.Version, err)
+	}
+	return cachedPath, nil
+}
+
+// downloadVerifierBinary extracts the verifier binary of the released archive to path.
+func downloadVerifierBinary(url string, path string) error {
+	archivePath := path + ".tar.gz"
+	if err := downloadReleaseAsset(url, archivePath); err != nil {
+		return err
+	}
+	defer os.Remove(archivePath) //nolint:errcheck
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	gzipReader, err := gzip.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s not found in the archive", verifierBinaryName)
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != verifierBinaryName {
+			continue
+		}
+		// the binary is written aside and renamed, so an interrupted extraction isn't taken for the binary
+		tmpPath := path + ".download"
+		file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(file, tarReader); err != nil {
+			file.Close()
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmpPath, path)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func TestResolveVerifierBinary(t *testing.T) {
+	binary := []byte("verifier binary")
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	if err := tarWriter.WriteHeader(&tar.Header{Name: "aws_function", Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len(binary))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tarWriter.Write(binary); err != nil {
+		t.Fatal(err)
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+	checksum := sha256.Sum256(archive.Bytes())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/v1.2.3/aws_function.tar.gz", "/v1.2.4/aws_function.tar.gz":
+			w.Write(archive.Bytes()) //nolint:errcheck
+		case "/v1.2.3/aws_function.tar.gz.sha256":
+			fmt.Fprintf(w, "%s  aws_function.tar.gz\n", hex.EncodeToString(checksum[:]))
+		case "/v1.2.4/aws_function.tar.gz.sha256":
+			fmt.Fprintf(w, "%s\n", strings.Repeat("0", sha256.Size*2))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	previousUrl, previousVersion, previousHomeDir := verifierReleaseUrl, utils.Version, utils.FunctionClarityHomeDir
+	defer func() {
+		verifierReleaseUrl, utils.Version, utils.FunctionClarityHomeDir = previousUrl, previousVersion, previousHomeDir
+	}()
+	verifierReleaseUrl = server.URL + "/%s/aws_function.tar.gz"
+	utils.FunctionClarityHomeDir = t.TempDir()
+
+	utils.Version = ""
+	if _, err := ResolveVerifierBinary(""); err == nil {
+		t.Fatal("expected a development build without a binary path to fail")
+	}
+
+	utils.Version = "v1.2.3"
+	for i := 0; i < 2; i++ {
+		path, err := ResolveVerifierBinary("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := filepath.Join(utils.FunctionClarityHomeDir, "verifier", "v1.2.3", "aws_function"); path != expected {
+			t.Fatalf("expected the released binary at: %s, got: %s", expected, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != string(binary) {
+			t.Fatalf("unexpected binary content: %s", content)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected the released binary and its checksum to be downloaded once, got %d downloads", requests)
+	}
+
+	utils.Version = "v1.2.4"
+	if _, err := ResolveVerifierBinary(""); err == nil {
+		t.Fatal("expected a checksum mismatch to fail")
+	}
+	if _, err := os.Stat(filepath.Join(utils.FunctionClarityHomeDir, "verifier", "v1.2.4", "aws_function")); err == nil {
+		t.Fatal("expected a binary of a checksum mismatch not to be cached")
+	}
+
+	utils.Version = "v0.0.0"
+	if _, err := ResolveVerifierBinary(""); err == nil {
+		t.Fatal("expected a missing release to fail")
+	}
+
+	localPath := filepath.Join(t.TempDir(), "aws_function")
+	if err := os.WriteFile(localPath, binary, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := ResolveVerifierBinary(localPath); err != nil || path != localPath {
+		t.Fatalf("expected the local binary, got: %s, %v", path, err)
+	}
+	if path, err := ResolveVerifierBinary("other"); err == nil {
+		t.Fatalf("expected a missing binary path to fail, got: %s", path)
+	}
+}
@@ -0,0 +1,866 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const AzureFunctionClarityContainerName = "functionclarity"
+
+const (
+	AzureBlockModeStop            = "stop"
+	AzureBlockModeDisableTriggers = "disable-triggers"
+)
+
+const (
+	azureManagementEndpoint    = "https://management.azure.com"
+	azureStorageEndpointFormat = "https://%s.blob.core.windows.net"
+	azureKuduEndpointFormat    = "https://%s.scm.azurewebsites.net"
+	azureAuthorityHost         = "https://login.microsoftonline.com"
+	azureStorageScope          = "https://storage.azure.com/.default"
+	azureEventGridScope        = "https://eventgrid.azure.net/.default"
+	azureWebApiVersion         = "2022-03-01"
+	azureTagsApiVersion        = "2021-04-01"
+	azureSubscriptionsVersion  = "2020-01-01"
+	azureEventGridApiVersion   = "2018-01-01"
+	azureWebJobsDisabled       = "AzureWebJobsDisabled"
+	azureRunFromPackage        = "WEBSITE_RUN_FROM_PACKAGE"
+	azureClientName            = "functionclarity.AzureClient"
+)
+
+var errAzureBlobNotFound = errors.New("blob not found")
+
+var azureModuleVersionPattern = regexp.MustCompile(`^v\d+\.\d+\.\d+(-[a-zA-Z0-9_.-]+)?$`)
+
+// AzureConfig configures the AzureClient. Endpoints default to the public Azure cloud, they can be overridden to
+// work against sovereign clouds or local emulators (i.e. Azurite for blob storage).
+type AzureConfig struct {
+	SubscriptionId string
+	TenantId       string
+	ClientId       string
+	ClientSecret   string
+	// AccessToken is used as is for all requests when set, instead of requesting tokens from the authority
+	AccessToken    string
+	StorageAccount string
+	// StorageKey signs blob storage requests with a shared key, an access token is used if empty
+	StorageKey string
+	Container  string
+	// BlockMode is either stop (stop the function app) or disable-triggers (set AzureWebJobsDisabled)
+	BlockMode          string
+	EventGridKey       string
+	ManagementEndpoint string
+	StorageEndpoint    string
+	// KuduEndpoint is a format string of the function app name, i.e. https://%s.scm.azurewebsites.net
+	KuduEndpoint  string
+	AuthorityHost string
+}
+
+type AzureClient struct {
+	config     AzureConfig
+	httpClient *http.Client
+	// the credential and the sdk clients are created on first use
+	lock             sync.Mutex
+	credential       azcore.TokenCredential
+	managementClient *arm.Client
+	blobClient       *azblob.Client
+}
+
+// azureStaticCredential returns the configured access token, which the caller renews.
+type azureStaticCredential struct {
+	token string
+}
+
+func (c azureStaticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: c.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+type azureSite struct {
+	Location   string            `json:"location"`
+	Tags       map[string]string `json:"tags"`
+	Properties struct {
+		State            string   `json:"state"`
+		EnabledHostNames []string `json:"enabledHostNames"`
+	} `json:"properties"`
+}
+
+type azureSiteConfig struct {
+	Properties struct {
+		LinuxFxVersion   string `json:"linuxFxVersion"`
+		WindowsFxVersion string `json:"windowsFxVersion"`
+	} `json:"properties"`
+}
+
+type azureAppSettings struct {
+	Properties map[string]string `json:"properties"`
+}
+
+type azureEvent struct {
+	Id          string      `json:"id"`
+	EventType   string      `json:"eventType"`
+	Subject     string      `json:"subject"`
+	EventTime   string      `json:"eventTime"`
+	Data        interface{} `json:"data"`
+	DataVersion string      `json:"dataVersion"`
+}
+
+func NewAzureClient(config AzureConfig) *AzureClient {
+	if config.ManagementEndpoint == "" {
+		config.ManagementEndpoint = azureManagementEndpoint
+	}
+	if config.StorageEndpoint == "" && config.StorageAccount != "" {
+		config.StorageEndpoint = fmt.Sprintf(azureStorageEndpointFormat, config.StorageAccount)
+	}
+	if config.AuthorityHost == "" {
+		config.AuthorityHost = azureAuthorityHost
+	}
+	if config.Container == "" {
+		config.Container = AzureFunctionClarityContainerName
+	}
+	if config.BlockMode == "" {
+		config.BlockMode = AzureBlockModeStop
+	}
+	config.ManagementEndpoint = strings.TrimSuffix(config.ManagementEndpoint, "/")
+	config.StorageEndpoint = strings.TrimSuffix(config.StorageEndpoint, "/")
+	config.AuthorityHost = strings.TrimSuffix(config.AuthorityHost, "/")
+	p := new(AzureClient)
+	p.config = config
+	p.httpClient = &http.Client{Timeout: 5 * time.Minute}
+	return p
+}
+
+func (o *AzureClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	fxVersion, err := o.getFxVersion(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(strings.ToUpper(fxVersion), "DOCKER|") {
+		return "Image", nil
+	}
+	return "Zip", nil
+}
+
+func (o *AzureClient) GetFuncCode(funcIdentifier string) (string, error) {
+	resourceId, err := o.resourceId(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	settings, err := o.listAppSettings(resourceId)
+	if err != nil {
+		return "", err
+	}
+	contentName := uuid.New().String()
+	zipFileName := contentName + ".zip"
+	defer utils.CleanDirectory(utils.FunctionClarityHomeDir + zipFileName)
+
+	// a package mounted from a url isn't extracted to wwwroot, it is downloaded from its source instead
+	packageUrl := settings.Properties[azureRunFromPackage]
+	if strings.HasPrefix(strings.ToLower(packageUrl), "http") {
+		if err = utils.DownloadFile(zipFileName, &packageUrl); err != nil {
+			return "", fmt.Errorf("failed to download function package: %w", err)
+		}
+	} else {
+		kuduEndpoint, err := o.kuduEndpoint(resourceId)
+		if err != nil {
+			return "", err
+		}
+		if err = o.downloadKuduZip(kuduEndpoint+"/api/zip/site/wwwroot/", utils.FunctionClarityHomeDir+zipFileName); err != nil {
+			return "", fmt.Errorf("failed to download function package: %w", err)
+		}
+	}
+	if err := utils.ExtractZip(utils.FunctionClarityHomeDir+zipFileName, utils.FunctionClarityHomeDir+contentName); err != nil {
+		return "", err
+	}
+	return utils.FunctionClarityHomeDir + contentName, nil
+}
+
+func (o *AzureClient) GetFuncImageURI(funcIdentifier string) (string, error) {
+	fxVersion, err := o.getFxVersion(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(strings.ToUpper(fxVersion), "DOCKER|") {
+		return "", fmt.Errorf("function app: %s doesn't run a container image", funcIdentifier)
+	}
+	return fxVersion[len("DOCKER|"):], nil
+}
+
+func (o *AzureClient) GetFuncHash(funcIdentifier string) (string, error) {
+	imageURI, err := o.GetFuncImageURI(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if index := strings.LastIndex(imageURI, "@"); index >= 0 {
+		return imageURI[index+1:], nil
+	}
+	return imageURI, nil
+}
+
+func (o *AzureClient) IsFuncInRegions(funcIdentifier string, regions []string) (bool, error) {
+	resourceId, err := o.resourceId(funcIdentifier)
+	if err != nil {
+		return false, err
+	}
+	site, err := o.getSite(resourceId)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range regions {
+		if normalizeAzureRegion(site.Location) == normalizeAzureRegion(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *AzureClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
+	tags, err := o.GetFuncTags(funcIdentifier)
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range tagKes {
+		if _, exist := tags[tag]; exist {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *AzureClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	resourceId, err := o.resourceId(funcIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	site, err := o.getSite(resourceId)
+	if err != nil {
+		return nil, err
+	}
+	return site.Tags, nil
+}
+
+func (o *AzureClient) Upload(signature string, identity string, isKeyless bool) error {
+	if err := o.putBlob(o.config.Container, identity+".sig", []byte(signature)); err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded %v to: %v\n", identity+".sig", o.config.Container)
+	if isKeyless {
+		certificate, err := os.ReadFile(utils.FunctionClarityHomeDir + identity + ".crt.base64")
+		if err != nil {
+			return err
+		}
+		if err = o.putBlob(o.config.Container, identity+".crt.base64", certificate); err != nil {
+			return err
+		}
+		fmt.Printf("Certificate %v, uploaded to: %v\n", identity+".crt.base64", o.config.Container)
+	}
+	return nil
+}
+
+func (o *AzureClient) UploadFile(content string, fileName string) error {
+	return o.putBlob(o.config.Container, fileName, []byte(content))
+}
+
+func (o *AzureClient) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	fileName = fileName + "." + outputType
+	container := o.config.Container
+	blobName := fileName
+	if bucketPathToSignatures != "" {
+		var err error
+		container, blobName, err = extractBucketAndPath(bucketPathToSignatures + fileName)
+		if err != nil {
+			return err
+		}
+	}
+	content, err := o.getBlob(container, blobName)
+	if errors.Is(err, errAzureBlobNotFound) {
+		return fmt.Errorf("%w : %v", utils.ErrSignatureNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, fileName), content, 0600)
+}
+
+func (o *AzureClient) HandleBlock(funcIdentifier *string, failed bool) error {
+	resourceId, err := o.resourceId(*funcIdentifier)
+	if err != nil {
+		return err
+	}
+	if failed {
+		return o.blockFunction(resourceId)
+	}
+	return o.unblockFunction(resourceId)
+}
+
+func (o *AzureClient) HandleDetect(funcIdentifier *string, failureCategory string) error {
+	resourceId, err := o.resourceId(*funcIdentifier)
+	if err != nil {
+		return err
+	}
+	return o.tagFunction(resourceId, "Merge", map[string]string{utils.FunctionVerifyResultTagKey: detectTagValue(failureCategory)})
+}
+
+// Notify publishes the message as an event to the Event Grid topic endpoint.
+func (o *AzureClient) Notify(msg string, topicEndpoint string) error {
+	event := azureEvent{
+		Id:          uuid.New().String(),
+		EventType:   "FunctionClarity.VerificationFailed",
+		Subject:     "functionclarity/verification",
+		EventTime:   time.Now().UTC().Format(time.RFC3339),
+		Data:        msg,
+		DataVersion: "1.0",
+	}
+	if json.Valid([]byte(msg)) {
+		event.Data = json.RawMessage(msg)
+	}
+	body, err := json.Marshal([]azureEvent{event})
+	if err != nil {
+		return err
+	}
+	endpoint := topicEndpoint
+	if !strings.Contains(endpoint, "api-version=") {
+		endpoint = addQueryParameter(endpoint, "api-version", azureEventGridApiVersion)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.config.EventGridKey != "" {
+		req.Header.Set("aeg-sas-key", o.config.EventGridKey)
+	} else if err = o.authorize(req, azureEventGridScope); err != nil {
+		return err
+	}
+	if _, err = o.send(req); err != nil {
+		return fmt.Errorf("error publishing the message: %s to topic: %s: %w", msg, topicEndpoint, err)
+	}
+	return nil
+}
+
+func (o *AzureClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
+	resourceId, err := o.resourceId(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to fill notification details: %w", err)
+	}
+	subscriptionId, _, name, err := parseAzureResourceId(resourceId)
+	if err != nil {
+		return fmt.Errorf("failed to fill notification details: %w", err)
+	}
+	site, err := o.getSite(resourceId)
+	if err != nil {
+		return fmt.Errorf("failed to fill notification details: %w", err)
+	}
+	notification.AccountId = subscriptionId
+	notification.FunctionIdentifier = resourceId
+	notification.FunctionName = name
+	notification.Region = site.Location
+	return nil
+}
+
+func (o *AzureClient) DownloadPublicKeys(path string) (string, error) {
+	container, prefix, err := extractBucketAndPath(path)
+	if err != nil {
+		return "", err
+	}
+	blobs, err := o.listBlobs(container, prefix)
+	if err != nil {
+		return "", err
+	}
+	resultFolderName, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
+	if err = os.MkdirAll(folderResultFullPath, os.ModePerm); err != nil {
+		return "", err
+	}
+	for _, blob := range blobs {
+		if strings.HasSuffix(blob, "/") {
+			continue
+		}
+		content, err := o.getBlob(container, blob)
+		if err != nil {
+			return "", err
+		}
+		if err = os.WriteFile(folderResultFullPath+"/"+strings.ReplaceAll(blob, "/", "-"), content, 0600); err != nil {
+			return "", err
+		}
+	}
+	return folderResultFullPath, nil
+}
+
+func (o *AzureClient) ValidateCredentials() bool {
+	return o.managementRequest(http.MethodGet, "/subscriptions/"+o.config.SubscriptionId, azureSubscriptionsVersion, nil, nil) == nil
+}
+
+// CreateContainer creates the signatures container, an existing container is left as is.
+func (o *AzureClient) CreateContainer() error {
+	client, err := o.blobs()
+	if err != nil {
+		return err
+	}
+	if _, err = client.CreateContainer(context.Background(), o.config.Container, nil); err != nil && !hasAzureStatusCode(err, http.StatusConflict) {
+		return fmt.Errorf("failed to create container: %s: %w", o.config.Container, err)
+	}
+	return nil
+}
+
+func (o *AzureClient) blockFunction(resourceId string) error {
+	site, err := o.getSite(resourceId)
+	if err != nil {
+		return err
+	}
+	// the state before the first block is kept, so unblocking restores it
+	if _, blocked := site.Tags[utils.FunctionClarityPreviousStateTagKey]; blocked {
+		return nil
+	}
+	previousState := ""
+	switch o.config.BlockMode {
+	case AzureBlockModeStop:
+		previousState = site.Properties.State
+	case AzureBlockModeDisableTriggers:
+		settings, err := o.listAppSettings(resourceId)
+		if err != nil {
+			return err
+		}
+		previousState = "nil"
+		if value, exist := settings.Properties[azureWebJobsDisabled]; exist {
+			previousState = value
+		}
+	default:
+		return fmt.Errorf("unsupported block mode: %s", o.config.BlockMode)
+	}
+	if err = o.tagFunction(resourceId, "Merge", map[string]string{utils.FunctionClarityPreviousStateTagKey: o.config.BlockMode + ":" + previousState}); err != nil {
+		return fmt.Errorf("failed to tag function with previous state: %w", err)
+	}
+	if o.config.BlockMode == AzureBlockModeStop {
+		if err = o.managementRequest(http.MethodPost, resourceId+"/stop", azureWebApiVersion, nil, nil); err != nil {
+			return fmt.Errorf("failed to stop function app: %w", err)
+		}
+		return nil
+	}
+	if err = o.setAppSetting(resourceId, azureWebJobsDisabled, "true"); err != nil {
+		return fmt.Errorf("failed to disable function triggers: %w", err)
+	}
+	return nil
+}
+
+func (o *AzureClient) unblockFunction(resourceId string) error {
+	site, err := o.getSite(resourceId)
+	if err != nil {
+		return err
+	}
+	previous, blocked := site.Tags[utils.FunctionClarityPreviousStateTagKey]
+	if !blocked {
+		return nil
+	}
+	blockMode, previousState, _ := strings.Cut(previous, ":")
+	switch blockMode {
+	case AzureBlockModeStop:
+		if !strings.EqualFold(previousState, "Stopped") {
+			if err = o.managementRequest(http.MethodPost, resourceId+"/start", azureWebApiVersion, nil, nil); err != nil {
+				return fmt.Errorf("failed to start function app: %w", err)
+			}
+		}
+	case AzureBlockModeDisableTriggers:
+		if previousState == "nil" {
+			previousState = ""
+		}
+		if err = o.setAppSetting(resourceId, azureWebJobsDisabled, previousState); err != nil {
+			return fmt.Errorf("failed to restore function triggers: %w", err)
+		}
+	}
+	if err = o.tagFunction(resourceId, "Delete", map[string]string{utils.FunctionClarityPreviousStateTagKey: previous}); err != nil {
+		return fmt.Errorf("failed to untag function previous state: %w", err)
+	}
+	return nil
+}
+
+func (o *AzureClient) getSite(resourceId string) (*azureSite, error) {
+	site := &azureSite{}
+	if err := o.managementRequest(http.MethodGet, resourceId, azureWebApiVersion, nil, site); err != nil {
+		return nil, fmt.Errorf("failed to get function app: %w", err)
+	}
+	return site, nil
+}
+
+func (o *AzureClient) getFxVersion(funcIdentifier string) (string, error) {
+	resourceId, err := o.resourceId(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	siteConfig := &azureSiteConfig{}
+	if err = o.managementRequest(http.MethodGet, resourceId+"/config/web", azureWebApiVersion, nil, siteConfig); err != nil {
+		return "", fmt.Errorf("failed to get function app configuration: %w", err)
+	}
+	if siteConfig.Properties.LinuxFxVersion != "" {
+		return siteConfig.Properties.LinuxFxVersion, nil
+	}
+	return siteConfig.Properties.WindowsFxVersion, nil
+}
+
+func (o *AzureClient) listAppSettings(resourceId string) (*azureAppSettings, error) {
+	settings := &azureAppSettings{}
+	if err := o.managementRequest(http.MethodPost, resourceId+"/config/appsettings/list", azureWebApiVersion, nil, settings); err != nil {
+		return nil, fmt.Errorf("failed to list function app settings: %w", err)
+	}
+	if settings.Properties == nil {
+		settings.Properties = make(map[string]string)
+	}
+	return settings, nil
+}
+
+// setAppSetting sets the setting, or removes it when the value is empty.
+func (o *AzureClient) setAppSetting(resourceId string, name string, value string) error {
+	settings, err := o.listAppSettings(resourceId)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		delete(settings.Properties, name)
+	} else {
+		settings.Properties[name] = value
+	}
+	return o.managementRequest(http.MethodPut, resourceId+"/config/appsettings", azureWebApiVersion, settings, nil)
+}
+
+func (o *AzureClient) tagFunction(resourceId string, operation string, tags map[string]string) error {
+	body := map[string]interface{}{
+		"operation":  operation,
+		"properties": map[string]interface{}{"tags": tags},
+	}
+	if err := o.managementRequest(http.MethodPatch, resourceId+"/providers/Microsoft.Resources/tags/default", azureTagsApiVersion, body, nil); err != nil {
+		return fmt.Errorf("failed to tag function. %v", err)
+	}
+	return nil
+}
+
+func (o *AzureClient) kuduEndpoint(resourceId string) (string, error) {
+	_, _, name, err := parseAzureResourceId(resourceId)
+	if err != nil {
+		return "", err
+	}
+	if o.config.KuduEndpoint != "" {
+		return strings.TrimSuffix(fmt.Sprintf(o.config.KuduEndpoint, name), "/"), nil
+	}
+	site, err := o.getSite(resourceId)
+	if err != nil {
+		return "", err
+	}
+	for _, hostName := range site.Properties.EnabledHostNames {
+		if strings.Contains(hostName, ".scm.") {
+			return "https://" + hostName, nil
+		}
+	}
+	return fmt.Sprintf(azureKuduEndpointFormat, name), nil
+}
+
+func (o *AzureClient) downloadKuduZip(zipUrl string, outputFile string) error {
+	req, err := http.NewRequest(http.MethodGet, zipUrl, nil)
+	if err != nil {
+		return err
+	}
+	if err = o.authorize(req, o.config.ManagementEndpoint+"/.default"); err != nil {
+		return err
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError(req, resp)
+	}
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
+// resourceId accepts a function app resource id, or <resource group>/<function app name> in the configured subscription.
+func (o *AzureClient) resourceId(funcIdentifier string) (string, error) {
+	if strings.HasPrefix(strings.ToLower(funcIdentifier), "/subscriptions/") {
+		return strings.TrimSuffix(funcIdentifier, "/"), nil
+	}
+	parts := strings.Split(funcIdentifier, "/")
+	if len(parts) != 2 || o.config.SubscriptionId == "" {
+		return "", fmt.Errorf("invalid function app identifier: %s, expected a resource id or <resource group>/<function app name>", funcIdentifier)
+	}
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Web/sites/%s", o.config.SubscriptionId, parts[0], parts[1]), nil
+}
+
+func parseAzureResourceId(resourceId string) (string, string, string, error) {
+	parts := strings.Split(strings.Trim(resourceId, "/"), "/")
+	if len(parts) != 8 || !strings.EqualFold(parts[0], "subscriptions") || !strings.EqualFold(parts[2], "resourceGroups") ||
+		!strings.EqualFold(parts[5], "Microsoft.Web") || !strings.EqualFold(parts[6], "sites") {
+		return "", "", "", fmt.Errorf("invalid function app resource id: %s", resourceId)
+	}
+	return parts[1], parts[3], parts[7], nil
+}
+
+func normalizeAzureRegion(region string) string {
+	return strings.ToLower(strings.ReplaceAll(region, " ", ""))
+}
+
+func (o *AzureClient) managementRequest(method string, path string, apiVersion string, body interface{}, result interface{}) error {
+	client, err := o.management()
+	if err != nil {
+		return err
+	}
+	req, err := runtime.NewRequest(context.Background(), method, runtime.JoinPaths(client.Endpoint(), path))
+	if err != nil {
+		return err
+	}
+	query := req.Raw().URL.Query()
+	query.Set("api-version", apiVersion)
+	req.Raw().URL.RawQuery = query.Encode()
+	req.Raw().Header.Set("Accept", "application/json")
+	if body != nil {
+		if err = runtime.MarshalAsJSON(req, body); err != nil {
+			return err
+		}
+	}
+	resp, err := client.Pipeline().Do(req)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent) {
+		return runtime.NewResponseError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	return runtime.UnmarshalAsJSON(resp, result)
+}
+
+func (o *AzureClient) send(req *http.Request) ([]byte, error) {
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError(req, resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func responseError(req *http.Request, resp *http.Response) error {
+	content, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("request: %s %s failed: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(content)))
+}
+
+func hasAzureStatusCode(err error, statusCode int) bool {
+	var responseErr *azcore.ResponseError
+	return errors.As(err, &responseErr) && responseErr.StatusCode == statusCode
+}
+
+func (o *AzureClient) authorize(req *http.Request, scope string) error {
+	credential, err := o.tokenCredential()
+	if err != nil {
+		return err
+	}
+	token, err := credential.GetToken(req.Context(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		return fmt.Errorf("failed to get azure access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	return nil
+}
+
+// tokenCredential returns the credential of the client secret if configured, or of the managed identity of the
+// function app the verifier runs in. The tokens are cached and renewed by their expiration.
+func (o *AzureClient) tokenCredential() (azcore.TokenCredential, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.lockedTokenCredential()
+}
+
+func (o *AzureClient) lockedTokenCredential() (azcore.TokenCredential, error) {
+	if o.credential != nil {
+		return o.credential, nil
+	}
+	clientOptions := azcore.ClientOptions{
+		Transport: o.httpClient,
+		Cloud:     cloud.Configuration{ActiveDirectoryAuthorityHost: o.config.AuthorityHost + "/"},
+	}
+	var err error
+	switch {
+	case o.config.AccessToken != "":
+		o.credential = azureStaticCredential{token: o.config.AccessToken}
+	case o.config.ClientSecret != "":
+		o.credential, err = azidentity.NewClientSecretCredential(o.config.TenantId, o.config.ClientId, o.config.ClientSecret,
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions})
+	default:
+		options := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if o.config.ClientId != "" {
+			options.ID = azidentity.ClientID(o.config.ClientId)
+		}
+		o.credential, err = azidentity.NewManagedIdentityCredential(options)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure credential: %w", err)
+	}
+	return o.credential, nil
+}
+
+func (o *AzureClient) management() (*arm.Client, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.managementClient != nil {
+		return o.managementClient, nil
+	}
+	credential, err := o.lockedTokenCredential()
+	if err != nil {
+		return nil, err
+	}
+	options := &arm.ClientOptions{ClientOptions: azcore.ClientOptions{
+		Transport: o.httpClient,
+		Cloud: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: o.config.AuthorityHost + "/",
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {Endpoint: o.config.ManagementEndpoint, Audience: o.config.ManagementEndpoint},
+			},
+		},
+	}}
+	if o.managementClient, err = arm.NewClient(azureClientName, azureModuleVersion(), credential, options); err != nil {
+		return nil, fmt.Errorf("failed to create azure management client: %w", err)
+	}
+	return o.managementClient, nil
+}
+
+// blobs returns the blob storage client, signing requests with the shared key when set, or with an access token.
+func (o *AzureClient) blobs() (*azblob.Client, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.blobClient != nil {
+		return o.blobClient, nil
+	}
+	options := &azblob.ClientOptions{ClientOptions: azcore.ClientOptions{Transport: o.httpClient}}
+	var err error
+	if o.config.StorageKey != "" {
+		sharedKey, err := azblob.NewSharedKeyCredential(o.config.StorageAccount, o.config.StorageKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage key: %w", err)
+		}
+		o.blobClient, err = azblob.NewClientWithSharedKeyCredential(o.config.StorageEndpoint, sharedKey, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+		}
+		return o.blobClient, nil
+	}
+	credential, err := o.lockedTokenCredential()
+	if err != nil {
+		return nil, err
+	}
+	if o.blobClient, err = azblob.NewClient(o.config.StorageEndpoint, credential, options); err != nil {
+		return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+	}
+	return o.blobClient, nil
+}
+
+// azureModuleVersion is the version reported by the sdk telemetry, which requires a semantic version.
+func azureModuleVersion() string {
+	if azureModuleVersionPattern.MatchString(utils.Version) {
+		return utils.Version
+	}
+	return "v0.0.0"
+}
+
+func addQueryParameter(endpoint string, key string, value string) string {
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+	return endpoint + separator + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
+func (o *AzureClient) putBlob(container string, blobName string, content []byte) error {
+	client, err := o.blobs()
+	if err != nil {
+		return err
+	}
+	if _, err = client.UploadBuffer(context.Background(), container, blobName, content, nil); err != nil {
+		return fmt.Errorf("failed to upload blob: %s: %w", blobName, err)
+	}
+	return nil
+}
+
+func (o *AzureClient) getBlob(container string, blobName string) ([]byte, error) {
+	client, err := o.blobs()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DownloadStream(context.Background(), container, blobName, nil)
+	if hasAzureStatusCode(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("%w: %s/%s", errAzureBlobNotFound, container, blobName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob: %s: %w", blobName, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (o *AzureClient) listBlobs(container string, prefix string) ([]string, error) {
+	client, err := o.blobs()
+	if err != nil {
+		return nil, err
+	}
+	var blobs []string
+	pager := client.NewListBlobsFlatPager(container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", err)
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if blob.Name != nil {
+				blobs = append(blobs, *blob.Name)
+			}
+		}
+	}
+	return blobs, nil
+}
@@ -0,0 +1,350 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"archive/zip"
+	"bytes"
+	b64 "encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const (
+	testAzureAccount      = "devstoreaccount1"
+	testAzureSubscription = "00000000-0000-0000-0000-000000000000"
+	testAzureFunctionId   = "/subscriptions/" + testAzureSubscription + "/resourceGroups/rg/providers/Microsoft.Web/sites/app"
+)
+
+var testAzureKey = b64.StdEncoding.EncodeToString([]byte("function clarity test storage key"))
+
+type azureBlobList struct {
+	Blobs struct {
+		Blob []struct {
+			Name string `xml:"Name"`
+		} `xml:"Blob"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// fakeAzure stands in for the management, kudu, blob storage and event grid endpoints.
+type fakeAzure struct {
+	t        *testing.T
+	lock     sync.Mutex
+	blobs    map[string][]byte
+	tags     map[string]string
+	state    string
+	settings map[string]string
+	events   []azureEvent
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/"+testAzureAccount+"/"):
+		f.serveBlob(w, r)
+	case strings.HasPrefix(r.URL.Path, "/kudu/app/api/zip/site/wwwroot"):
+		if !f.checkBearer(w, r) {
+			return
+		}
+		_, _ = w.Write(testZip(f.t, map[string]string{"main.py": "print('hello')"}))
+	case r.URL.Path == "/api/events":
+		if r.Header.Get("aeg-sas-key") != "event-grid-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var events []azureEvent
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.events = append(f.events, events...)
+	case strings.HasPrefix(r.URL.Path, testAzureFunctionId):
+		if !f.checkBearer(w, r) {
+			return
+		}
+		f.serveFunctionApp(w, r, strings.TrimPrefix(r.URL.Path, testAzureFunctionId))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeAzure) checkBearer(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (f *fakeAzure) serveBlob(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+testAzureAccount+":") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/"+testAzureAccount+"/")
+	switch {
+	case r.URL.Query().Get("comp") == "list":
+		var list azureBlobList
+		for blob := range f.blobs {
+			if strings.HasPrefix(blob, name+"/"+r.URL.Query().Get("prefix")) {
+				list.Blobs.Blob = append(list.Blobs.Blob, struct {
+					Name string `xml:"Name"`
+				}{Name: strings.TrimPrefix(blob, name+"/")})
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(struct {
+			XMLName xml.Name `xml:"EnumerationResults"`
+			azureBlobList
+		}{azureBlobList: list})
+	case r.Method == http.MethodPut:
+		content, _ := io.ReadAll(r.Body)
+		f.blobs[name] = content
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet:
+		content, exist := f.blobs[name]
+		if !exist {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(content)
+	}
+}
+
+func (f *fakeAzure) serveFunctionApp(w http.ResponseWriter, r *http.Request, path string) {
+	switch path {
+	case "":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"location": "East US", "tags": f.tags, "properties": map[string]interface{}{"state": f.state}})
+	case "/config/web":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"properties": map[string]string{"linuxFxVersion": "PYTHON|3.9"}})
+	case "/config/appsettings/list":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"properties": f.settings})
+	case "/config/appsettings":
+		var settings azureAppSettings
+		_ = json.NewDecoder(r.Body).Decode(&settings)
+		f.settings = settings.Properties
+	case "/stop":
+		f.state = "Stopped"
+	case "/start":
+		f.state = "Running"
+	case "/providers/Microsoft.Resources/tags/default":
+		var body struct {
+			Operation  string
+			Properties struct{ Tags map[string]string }
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for key, value := range body.Properties.Tags {
+			if body.Operation == "Delete" {
+				delete(f.tags, key)
+			} else {
+				f.tags[key] = value
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(buf)
+	for name, content := range files {
+		f, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestAzureClient(t *testing.T) (*AzureClient, *fakeAzure) {
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeAzure{t: t, blobs: map[string][]byte{}, tags: map[string]string{"team": "a"}, state: "Running", settings: map[string]string{"FUNCTIONS_WORKER_RUNTIME": "python"}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := NewAzureClient(AzureConfig{
+		SubscriptionId:     testAzureSubscription,
+		AccessToken:        "token",
+		StorageAccount:     testAzureAccount,
+		StorageKey:         testAzureKey,
+		EventGridKey:       "event-grid-key",
+		ManagementEndpoint: server.URL,
+		StorageEndpoint:    server.URL + "/" + testAzureAccount,
+		KuduEndpoint:       server.URL + "/kudu/%s",
+	})
+	return client, fake
+}
+
+func TestAzureClientSignatures(t *testing.T) {
+	client, fake := newTestAzureClient(t)
+	if err := client.Upload("signature", "identity", false); err != nil {
+		t.Fatalf("failed to upload signature: %v", err)
+	}
+	outputDir := t.TempDir()
+	if err := client.DownloadSignature("identity", "sig", "", outputDir); err != nil {
+		t.Fatalf("failed to download signature: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "identity.sig"))
+	if err != nil || string(content) != "signature" {
+		t.Fatalf("unexpected downloaded signature: %s: %v", content, err)
+	}
+	err = client.DownloadSignature("missing", "sig", "", outputDir)
+	if err == nil || !strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
+		t.Fatalf("expected signature not found error, got: %v", err)
+	}
+
+	fake.blobs["keys/public/a.pub"] = []byte("a")
+	fake.blobs["keys/public/b.pub"] = []byte("b")
+	keysFolder, err := client.DownloadPublicKeys("az://keys/public/")
+	if err != nil {
+		t.Fatalf("failed to download public keys: %v", err)
+	}
+	defer utils.CleanDirectory(keysFolder)
+	keys, err := os.ReadDir(keysFolder)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("expected 2 public keys, got: %d: %v", len(keys), err)
+	}
+}
+
+func TestAzureClientFunctionApp(t *testing.T) {
+	client, fake := newTestAzureClient(t)
+	functionIdentifier := "rg/app"
+	packageType, err := client.ResolvePackageType(functionIdentifier)
+	if err != nil || packageType != "Zip" {
+		t.Fatalf("expected Zip package type, got: %s: %v", packageType, err)
+	}
+	codePath, err := client.GetFuncCode(functionIdentifier)
+	if err != nil {
+		t.Fatalf("failed to get function code: %v", err)
+	}
+	defer utils.CleanDirectory(codePath)
+	if _, err = os.Stat(filepath.Join(codePath, "main.py")); err != nil {
+		t.Fatalf("function code not extracted: %v", err)
+	}
+	if contains, err := client.FuncContainsTags(functionIdentifier, []string{"team"}); err != nil || !contains {
+		t.Fatalf("expected function to contain tag: %v", err)
+	}
+	if inRegions, err := client.IsFuncInRegions(functionIdentifier, []string{"eastus"}); err != nil || !inRegions {
+		t.Fatalf("expected function to be in region: %v", err)
+	}
+	if inRegions, err := client.IsFuncInRegions(functionIdentifier, []string{"westeurope"}); err != nil || inRegions {
+		t.Fatalf("expected function not to be in region: %v", err)
+	}
+
+	if err = client.HandleDetect(&functionIdentifier, utils.FailureSignatureNotFound); err != nil {
+		t.Fatalf("failed to tag function: %v", err)
+	}
+	if err = client.HandleBlock(&functionIdentifier, true); err != nil {
+		t.Fatalf("failed to block function: %v", err)
+	}
+	if fake.state != "Stopped" || fake.tags[utils.FunctionVerifyResultTagKey] != utils.FunctionNotSignedTagValue ||
+		fake.tags[utils.FunctionClarityPreviousStateTagKey] != "stop:Running" {
+		t.Fatalf("function not blocked, state: %s, tags: %v", fake.state, fake.tags)
+	}
+	if err = client.HandleBlock(&functionIdentifier, false); err != nil {
+		t.Fatalf("failed to unblock function: %v", err)
+	}
+	if _, blocked := fake.tags[utils.FunctionClarityPreviousStateTagKey]; fake.state != "Running" || blocked {
+		t.Fatalf("function not unblocked, state: %s, tags: %v", fake.state, fake.tags)
+	}
+
+	client.config.BlockMode = AzureBlockModeDisableTriggers
+	if err = client.HandleBlock(&functionIdentifier, true); err != nil || fake.settings[azureWebJobsDisabled] != "true" {
+		t.Fatalf("function triggers not disabled, settings: %v: %v", fake.settings, err)
+	}
+	if err = client.HandleBlock(&functionIdentifier, false); err != nil {
+		t.Fatalf("failed to unblock function: %v", err)
+	}
+	if _, disabled := fake.settings[azureWebJobsDisabled]; disabled || fake.settings["FUNCTIONS_WORKER_RUNTIME"] != "python" {
+		t.Fatalf("function triggers not restored, settings: %v", fake.settings)
+	}
+
+	notification := Notification{}
+	if err = client.FillNotificationDetails(&notification, functionIdentifier); err != nil || notification.FunctionName != "app" ||
+		notification.Region != "East US" {
+		t.Fatalf("unexpected notification details: %+v: %v", notification, err)
+	}
+	msg, _ := json.Marshal(notification)
+	if err = client.Notify(string(msg), client.config.ManagementEndpoint+"/api/events"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	if len(fake.events) != 1 || fake.events[0].EventType != "FunctionClarity.VerificationFailed" {
+		t.Fatalf("unexpected events: %+v", fake.events)
+	}
+}
+
+func TestAzureClientTokenExpiration(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		expiresIn        time.Duration
+		expectedRequests int
+	}{
+		{"valid token is reused", time.Hour, 1},
+		{"expired token is renewed", -time.Minute, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRequests := 0
+			identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-IDENTITY-HEADER") != "identity-header" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				tokenRequests++
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"access_token": "token",
+					"expires_on":   strconv.FormatInt(time.Now().Add(tt.expiresIn).Unix(), 10),
+					"resource":     r.URL.Query().Get("resource"),
+					"token_type":   "Bearer",
+				})
+			}))
+			defer identity.Close()
+			t.Setenv("IDENTITY_ENDPOINT", identity.URL)
+			t.Setenv("IDENTITY_HEADER", "identity-header")
+
+			client, _ := newTestAzureClient(t)
+			client.config.AccessToken = ""
+			for i := 0; i < 2; i++ {
+				if _, err := client.GetFuncTags("rg/app"); err != nil {
+					t.Fatalf("failed to get function tags: %v", err)
+				}
+			}
+			if tokenRequests != tt.expectedRequests {
+				t.Fatalf("expected %d token requests, got: %d", tt.expectedRequests, tokenRequests)
+			}
+		})
+	}
+}
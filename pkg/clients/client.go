@@ -15,12 +15,24 @@
 
 package clients
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
 type Notification struct {
 	AccountId          string
 	FunctionName       string
 	FunctionIdentifier string
 	Action             string
 	Region             string
+	// FailureCategory is the category of the failed verification, Unsigned tells the function has no signature at all,
+	// rather than a signature it doesn't match
+	FailureCategory string
+	Unsigned        bool
 }
 
 const ConfigEnvVariableName = "CONFIGURATION"
@@ -30,13 +42,82 @@ type Client interface {
 	GetFuncCode(funcIdentifier string) (string, error)
 	GetFuncImageURI(funcIdentifier string) (string, error)
 	GetFuncHash(funcIdentifier string) (string, error)
-	IsFuncInRegions(regions []string) bool
+	IsFuncInRegions(funcIdentifier string, regions []string) (bool, error)
 	FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error)
-	Upload(signature string, identity string, isKeyless bool) error
-	DownloadSignature(fileName string, outputType string, bucketPathToSignatures string) error
+	GetFuncTags(funcIdentifier string) (map[string]string, error)
 	HandleBlock(funcIdentifier *string, failed bool) error
-	HandleDetect(funcIdentifier *string, failed bool) error
+	// HandleDetect marks the function with the verification result, the failure category is empty when it was verified
+	HandleDetect(funcIdentifier *string, failureCategory string) error
 	Notify(msg string, snsArn string) error
 	FillNotificationDetails(notification *Notification, functionIdentifier string) error
+}
+
+// LayersClient is implemented by the clients of providers whose functions run attached layers, the code of each
+// layer version is verified against its own signature.
+type LayersClient interface {
+	GetFuncLayers(funcIdentifier string) ([]string, error)
+	GetLayerCode(layerVersionArn string) (string, error)
+}
+
+// SignatureStore keeps the signatures, certificates and manifests of function identities, and the public keys to verify them with.
+// It is independent of the runtime Client, so functions of one provider can be verified against signatures kept in another.
+type SignatureStore interface {
+	Upload(signature string, identity string, isKeyless bool) error
+	UploadFile(content string, fileName string) error
+	// DownloadSignature downloads the file of an identity to the output directory, each verification downloads to its own
+	// directory since functions with the same code share their identity
+	DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error
 	DownloadPublicKeys(path string) (string, error)
 }
+
+// NewSignatureStore creates the store of the url: s3://<bucket>[/<prefix>], gs://<bucket>[/<prefix>],
+// oci://<registry>/<repository>, http(s)://<base url> or a local folder (file://<path> or a plain path).
+// The aws credentials and region are used by s3 stores only, the default credentials chain is used when they are empty.
+func NewSignatureStore(storeURL string, accessKey string, secretKey string, region string) (SignatureStore, error) {
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature store: %s: %w", storeURL, err)
+	}
+	prefix := strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	switch u.Scheme {
+	case "s3":
+		return NewS3SignatureStore(accessKey, secretKey, u.Host, prefix, region), nil
+	case "gs":
+		return NewGCSSignatureStore(u.Host, prefix), nil
+	case "oci":
+		return NewOCISignatureStore(u.Host + u.Path), nil
+	case "http", "https":
+		return NewHTTPSignatureStore(storeURL), nil
+	case "file":
+		return NewFileSignatureStore(u.Host + u.Path), nil
+	case "":
+		return NewFileSignatureStore(storeURL), nil
+	}
+	return nil, fmt.Errorf("unsupported signature store: %s, expected s3://, gs://, oci://, http(s)://, file:// or a local folder", storeURL)
+}
+
+// detectTagValue returns the value of the verification result tag: unsigned functions and functions that are signed
+// but failed verification are told apart, so alerts on the tag can treat them differently.
+func detectTagValue(failureCategory string) string {
+	switch {
+	case failureCategory == "":
+		return utils.FunctionSignedTagValue
+	case utils.IsUnsignedFailure(failureCategory):
+		return utils.FunctionNotSignedTagValue
+	}
+	return utils.FunctionVerificationFailedTagValue + ": " + failureCategory
+}
+
+// detectLabelValue is the GCP label counterpart of detectTagValue, the failure categories are valid label values.
+func detectLabelValue(failureCategory string) string {
+	switch {
+	case failureCategory == "":
+		return utils.FunctionSignedLabelValue
+	case utils.IsUnsignedFailure(failureCategory):
+		return utils.FunctionNotSignedLabelValue
+	}
+	return failureCategory
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+// FileSignatureStore keeps the signatures in a local directory.
+type FileSignatureStore struct {
+	signaturesDir string
+}
+
+func NewFileSignatureStore(signaturesDir string) *FileSignatureStore {
+	p := new(FileSignatureStore)
+	p.signaturesDir = signaturesDir
+	return p
+}
+
+func (o *FileSignatureStore) Upload(signature string, identity string, isKeyless bool) error {
+	if err := os.MkdirAll(o.signaturesDir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(o.signaturesDir, identity+".sig"), []byte(signature), 0600); err != nil {
+		return err
+	}
+	if isKeyless {
+		certificatePath := utils.FunctionClarityHomeDir + identity + ".crt.base64"
+		if err := copyFile(certificatePath, filepath.Join(o.signaturesDir, identity+".crt.base64")); err != nil {
+			return err
+		}
+		fmt.Printf("certificate file saved to %s\n", o.signaturesDir)
+	}
+	return nil
+}
+
+func (o *FileSignatureStore) UploadFile(content string, fileName string) error {
+	if err := os.MkdirAll(o.signaturesDir, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(o.signaturesDir, fileName), []byte(content), 0600)
+}
+
+func (o *FileSignatureStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	signaturesDir := o.signaturesDir
+	if bucketPathToSignatures != "" {
+		signaturesDir = bucketPathToSignatures
+	}
+	fileName = fileName + "." + outputType
+	err := copyFile(filepath.Join(signaturesDir, fileName), filepath.Join(outputDir, fileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w : %v", utils.ErrSignatureNotFound, err)
+	}
+	return err
+}
+
+func (o *FileSignatureStore) DownloadPublicKeys(path string) (string, error) {
+	resultFolderName, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return folderResultFullPath, copyDirectory(path, folderResultFullPath)
+	}
+	if err = os.MkdirAll(folderResultFullPath, os.ModePerm); err != nil {
+		return "", err
+	}
+	return folderResultFullPath, copyFile(path, filepath.Join(folderResultFullPath, filepath.Base(path)))
+}
@@ -0,0 +1,46 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func TestFileSignatureStoreDownloadSignature(t *testing.T) {
+	signaturesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(signaturesDir, "identity.sig"), []byte("signature"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileSignatureStore(signaturesDir)
+	outputDir := t.TempDir()
+	if err := store.DownloadSignature("identity", "sig", "", outputDir); err != nil {
+		t.Fatalf("failed to download existing signature: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "identity.sig"))
+	if err != nil || string(content) != "signature" {
+		t.Fatalf("signature not copied to the output dir: %v", err)
+	}
+
+	err = store.DownloadSignature("missing", "sig", "", outputDir)
+	if err == nil || !strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
+		t.Fatalf("expected signature not found error, got: %v", err)
+	}
+}
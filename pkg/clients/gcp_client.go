@@ -16,9 +16,14 @@
 package clients
 
 import (
+	"archive/zip"
 	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -31,9 +36,35 @@ import (
 	"cloud.google.com/go/run/apiv2/runpb"
 	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
+	"github.com/googleapis/gax-go/v2"
+	i "github.com/openclarity/functionclarity/pkg/init"
+	"github.com/openclarity/functionclarity/pkg/policy"
 	"github.com/openclarity/functionclarity/pkg/utils"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/logging/v2"
+	"google.golang.org/api/pubsub/v1"
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gopkg.in/yaml.v3"
 )
 
+const FunctionClarityGCPVerifierName = "function-clarity-verifier"
+
+const gcpFunctionSourceArchive = "gcp_function.zip"
+
+const gcpAuditEventsTopicName = "function-clarity-audit-events"
+
+const gcpAuditSinkName = "function-clarity-audit-sink"
+
+// gcpAuditSinkFilter returns the filter of the audit log entries of function changes, without the changes of the
+// verifier itself, whose labels would otherwise trigger it again.
+func gcpAuditSinkFilter(verifierServiceAccount string) string {
+	return `logName:"cloudaudit.googleapis.com%2Factivity" AND ` +
+		`protoPayload.methodName:("CreateFunction" OR "UpdateFunction" OR "ReplaceService") AND ` +
+		fmt.Sprintf(`NOT protoPayload.authenticationInfo.principalEmail="%s"`, verifierServiceAccount)
+}
+
 type GCPClient struct {
 	bucket         string
 	functionRegion string
@@ -46,50 +77,6 @@ func NewGCPClientInit(bucket string, location string, functionRegion string) *GC
 	return p
 }
 
-func (p *GCPClient) Upload(signature string, identity string, isKeyless bool) error {
-	ctx := context.Background()
-
-	client, err := storage.NewClient(ctx)
-	if err != nil {
-		return fmt.Errorf("storage.NewClient: %w", err)
-	}
-	defer client.Close()
-
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
-	defer cancel()
-
-	o := client.Bucket(p.bucket).Object(identity + ".sig")
-
-	wc := o.NewWriter(ctx)
-	if _, err = io.Copy(wc, strings.NewReader(signature)); err != nil {
-		return fmt.Errorf("io.Copy: %w", err)
-	}
-	if err := wc.Close(); err != nil {
-		return fmt.Errorf("Writer.Close: %w", err)
-	}
-	fmt.Printf("Uploaded %v to: %v\n", identity+".sig", p.bucket)
-
-	if isKeyless {
-		certificatePath := utils.FunctionClarityHomeDir + identity + ".crt.base64"
-		f, err := os.Open(certificatePath)
-		if err != nil {
-			return err
-		}
-
-		o := client.Bucket(p.bucket).Object(identity + ".crt.base64")
-
-		wc := o.NewWriter(ctx)
-		if _, err = io.Copy(wc, f); err != nil {
-			return fmt.Errorf("io.Copy: %w", err)
-		}
-		if err := wc.Close(); err != nil {
-			return fmt.Errorf("Writer.Close: %w", err)
-		}
-		fmt.Printf("Certificate %v, uploaded to: %v\n", identity+".crt.base64", p.bucket)
-	}
-	return nil
-}
-
 func (p *GCPClient) ResolvePackageType(funcIdentifier string) (string, error) {
 	if strings.Contains(funcIdentifier, "services") {
 		return "Image", nil
@@ -175,68 +162,572 @@ func (p *GCPClient) GetFuncImageURI(funcIdentifier string) (string, error) {
 	return "", fmt.Errorf("there are no image connected to service: %v\n", funcIdentifier)
 }
 
-func (p *GCPClient) IsFuncInRegions(regions []string) bool {
-	panic("not yet supported")
+func (p *GCPClient) IsFuncInRegions(funcIdentifier string, regions []string) (bool, error) {
+	for _, value := range regions {
+		if p.functionRegion == value {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (p *GCPClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
-	panic("not yet supported")
+	labels, err := p.getLabels(funcIdentifier)
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range tagKes {
+		if _, exist := labels[tag]; exist {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *GCPClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	return p.getLabels(funcIdentifier)
 }
 
-func (p *GCPClient) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string) error {
+func (p *GCPClient) HandleBlock(funcIdentifier *string, failed bool) error {
+	if failed {
+		return p.blockFunction(*funcIdentifier)
+	}
+	return p.unblockFunction(*funcIdentifier)
+}
+
+func (p *GCPClient) HandleDetect(funcIdentifier *string, failureCategory string) error {
+	return p.setLabel(*funcIdentifier, utils.FunctionVerifyResultLabelKey, detectLabelValue(failureCategory))
+}
+
+// Notify publishes the message to the Pub/Sub topic, given as projects/<project>/topics/<topic>.
+func (p *GCPClient) Notify(msg string, topic string) error {
 	ctx := context.Background()
-	client, err := storage.NewClient(ctx)
+	service, err := pubsub.NewService(ctx)
 	if err != nil {
-		return fmt.Errorf("storage.NewClient: %v", err)
+		return fmt.Errorf("pubsub.NewService: %w", err)
+	}
+	result, err := service.Projects.Topics.Publish(topic, &pubsub.PublishRequest{
+		Messages: []*pubsub.PubsubMessage{{Data: b64.StdEncoding.EncodeToString([]byte(msg))}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("error publishing the message: %s to topic: %s: %w", msg, topic, err)
+	}
+	fmt.Println("Message ID: " + strings.Join(result.MessageIds, ","))
+	return nil
+}
+
+func (p *GCPClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
+	project, location, _, name, err := parseGCPResourceName(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to fill notification details: %w", err)
+	}
+	notification.AccountId = project
+	notification.FunctionIdentifier = functionIdentifier
+	notification.FunctionName = name
+	notification.Region = location
+	return nil
+}
+
+func (p *GCPClient) GetFuncHash(funcIdentifier string) (string, error) {
+	ctx := context.Background()
+	client, err := run.NewServicesClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cloud run.NewClient: %w", err)
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute)
-	defer cancel()
+	service, err := client.GetService(ctx, &runpb.GetServiceRequest{Name: funcIdentifier})
+	if err != nil {
+		return "", err
+	}
+	containers := service.GetTemplate().GetContainers()
+	if len(containers) == 0 {
+		return "", fmt.Errorf("there are no image connected to service: %v", funcIdentifier)
+	}
+	image := containers[0].Image
+	if index := strings.LastIndex(image, "@"); index >= 0 {
+		return image[index+1:], nil
+	}
+	return image, nil
+}
 
-	outputFile := utils.FunctionClarityHomeDir + fileName + "." + outputType
+func downloadObject(ctx context.Context, client *storage.Client, bucketName string, objectName string, outputFile string) error {
+	rc, err := client.Bucket(bucketName).Object(objectName).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("Object(%q).NewReader: %w", objectName, err)
+	}
+	defer rc.Close()
 	f, err := os.Create(outputFile)
 	if err != nil {
-		return fmt.Errorf("os.Create: %v", err)
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	defer f.Close()
+	if _, err = io.Copy(f, rc); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
 	}
+	return nil
+}
 
-	objectName := fileName + "." + outputType
-	rc, err := client.Bucket(p.bucket).Object(objectName).NewReader(ctx)
+// parseGCPResourceName parses projects/<project>/locations/<location>/<functions|services>/<name>.
+func parseGCPResourceName(resourceName string) (string, string, string, string, error) {
+	parts := strings.Split(strings.Trim(resourceName, "/"), "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || (parts[4] != "functions" && parts[4] != "services") {
+		return "", "", "", "", fmt.Errorf("invalid function identifier: %s, expected projects/<project>/locations/<location>/<functions|services>/<name>", resourceName)
+	}
+	return parts[1], parts[3], parts[4], parts[5], nil
+}
+
+const gcpBlockedMembersFolder = "function-clarity-blocked/"
+
+// gcpIamClient is implemented by both the cloud functions and the cloud run clients.
+type gcpIamClient interface {
+	GetIamPolicy(ctx context.Context, req *iampb.GetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error)
+	SetIamPolicy(ctx context.Context, req *iampb.SetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error)
+	Close() error
+}
+
+func (p *GCPClient) getLabels(funcIdentifier string) (map[string]string, error) {
+	ctx := context.Background()
+	_, _, kind, _, err := parseGCPResourceName(funcIdentifier)
 	if err != nil {
-		return fmt.Errorf("Object(%q).NewReader: %v", objectName, err)
+		return nil, err
 	}
-	defer rc.Close()
+	if kind == "services" {
+		client, err := run.NewServicesClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cloud run.NewClient: %w", err)
+		}
+		defer client.Close()
+		service, err := client.GetService(ctx, &runpb.GetServiceRequest{Name: funcIdentifier})
+		if err != nil {
+			return nil, err
+		}
+		return service.Labels, nil
+	}
+	gen1Function, gen2Function, err := getFunction(ctx, funcIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if gen1Function != nil {
+		return gen1Function.Labels, nil
+	}
+	return gen2Function.Labels, nil
+}
 
-	if _, err := io.Copy(f, rc); err != nil {
-		return fmt.Errorf("io.Copy: %v", err)
+func (p *GCPClient) setLabel(funcIdentifier string, key string, value string) error {
+	ctx := context.Background()
+	_, _, kind, _, err := parseGCPResourceName(funcIdentifier)
+	if err != nil {
+		return err
+	}
+	if kind == "services" {
+		client, err := run.NewServicesClient(ctx)
+		if err != nil {
+			return fmt.Errorf("cloud run.NewClient: %w", err)
+		}
+		defer client.Close()
+		service, err := client.GetService(ctx, &runpb.GetServiceRequest{Name: funcIdentifier})
+		if err != nil {
+			return err
+		}
+		if service.Labels == nil {
+			service.Labels = make(map[string]string)
+		}
+		service.Labels[key] = value
+		op, err := client.UpdateService(ctx, &runpb.UpdateServiceRequest{Service: service})
+		if err == nil {
+			_, err = op.Wait(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to label service. %v", err)
+		}
+		return nil
 	}
-	if err = f.Close(); err != nil {
-		return fmt.Errorf("f.Close: %v", err)
+
+	gen1Function, gen2Function, err := getFunction(ctx, funcIdentifier)
+	if err != nil {
+		return err
+	}
+	updateMask := &fieldmaskpb.FieldMask{Paths: []string{"labels"}}
+	if gen1Function != nil {
+		client, err := funcv1.NewCloudFunctionsClient(ctx)
+		if err != nil {
+			return fmt.Errorf("cloud functions.NewClient: %w", err)
+		}
+		defer client.Close()
+		labels := withLabel(gen1Function.Labels, key, value)
+		op, err := client.UpdateFunction(ctx, &funcpb1.UpdateFunctionRequest{Function: &funcpb1.CloudFunction{Name: funcIdentifier, Labels: labels}, UpdateMask: updateMask})
+		if err == nil {
+			_, err = op.Wait(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to label function. %v", err)
+		}
+		return nil
+	}
+	client, err := funcv2.NewFunctionClient(ctx)
+	if err != nil {
+		return fmt.Errorf("cloud functions.NewClient: %w", err)
+	}
+	defer client.Close()
+	labels := withLabel(gen2Function.Labels, key, value)
+	op, err := client.UpdateFunction(ctx, &funcpb2.UpdateFunctionRequest{Function: &funcpb2.Function{Name: funcIdentifier, Labels: labels}, UpdateMask: updateMask})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to label function. %v", err)
 	}
-	fmt.Printf("Downloaded %v to: %v\n", objectName, outputFile)
 	return nil
 }
 
-func (p *GCPClient) HandleBlock(funcIdentifier *string, failed bool) error {
-	panic("not yet supported")
+func withLabel(labels map[string]string, key string, value string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[key] = value
+	return result
 }
 
-func (p *GCPClient) HandleDetect(funcIdentifier *string, failed bool) error {
-	panic("not yet supported")
+// getFunction returns either the 1st gen function or the 2nd gen function with the name.
+func getFunction(ctx context.Context, funcIdentifier string) (*funcpb1.CloudFunction, *funcpb2.Function, error) {
+	gen1Client, err := funcv1.NewCloudFunctionsClient(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cloud functions.NewClient: %w", err)
+	}
+	defer gen1Client.Close()
+	if gen1Function, err := gen1Client.GetFunction(ctx, &funcpb1.GetFunctionRequest{Name: funcIdentifier}); err == nil {
+		return gen1Function, nil, nil
+	}
+	gen2Client, err := funcv2.NewFunctionClient(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cloud functions.NewClient: %w", err)
+	}
+	defer gen2Client.Close()
+	gen2Function, err := gen2Client.GetFunction(ctx, &funcpb2.GetFunctionRequest{Name: funcIdentifier})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get function: %w", err)
+	}
+	return nil, gen2Function, nil
 }
 
-func (p *GCPClient) Notify(msg string, snsArn string) error {
-	panic("not yet supported")
+// invokerPolicy resolves the resource whose invoker role grants invoking the function: the function itself for
+// 1st gen functions, and the underlying cloud run service for 2nd gen functions and services.
+func invokerPolicy(ctx context.Context, funcIdentifier string) (gcpIamClient, string, string, error) {
+	_, _, kind, _, err := parseGCPResourceName(funcIdentifier)
+	if err != nil {
+		return nil, "", "", err
+	}
+	resource := funcIdentifier
+	if kind == "functions" {
+		gen1Function, gen2Function, err := getFunction(ctx, funcIdentifier)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if gen1Function != nil {
+			client, err := funcv1.NewCloudFunctionsClient(ctx)
+			if err != nil {
+				return nil, "", "", fmt.Errorf("cloud functions.NewClient: %w", err)
+			}
+			return client, funcIdentifier, "roles/cloudfunctions.invoker", nil
+		}
+		resource = gen2Function.GetServiceConfig().GetService()
+	}
+	client, err := run.NewServicesClient(ctx)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("cloud run.NewClient: %w", err)
+	}
+	return client, resource, "roles/run.invoker", nil
 }
 
-func (p *GCPClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
-	panic("not yet supported")
+// blockFunction removes all the members of the invoker role, they are kept in the bucket to be restored when the
+// function is verified again.
+func (p *GCPClient) blockFunction(funcIdentifier string) error {
+	ctx := context.Background()
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer storageClient.Close()
+	blockedObject := storageClient.Bucket(p.bucket).Object(blockedMembersObjectName(funcIdentifier))
+	if _, err = blockedObject.Attrs(ctx); err == nil {
+		return nil
+	} else if !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to check whether function is blocked: %w", err)
+	}
+
+	iamClient, resource, role, err := invokerPolicy(ctx, funcIdentifier)
+	if err != nil {
+		return err
+	}
+	defer iamClient.Close()
+	policy, err := iamClient.GetIamPolicy(ctx, &iampb.GetIamPolicyRequest{Resource: resource})
+	if err != nil {
+		return fmt.Errorf("failed to get iam policy of: %s: %w", resource, err)
+	}
+	var removedMembers []string
+	var bindings []*iampb.Binding
+	for _, binding := range policy.Bindings {
+		if binding.Role == role && binding.Condition == nil {
+			removedMembers = append(removedMembers, binding.Members...)
+			continue
+		}
+		bindings = append(bindings, binding)
+	}
+	content, err := json.Marshal(removedMembers)
+	if err != nil {
+		return err
+	}
+	wc := blockedObject.NewWriter(ctx)
+	if _, err = wc.Write(content); err != nil {
+		return fmt.Errorf("failed to save invokers of function: %w", err)
+	}
+	if err = wc.Close(); err != nil {
+		return fmt.Errorf("failed to save invokers of function: %w", err)
+	}
+	policy.Bindings = bindings
+	if _, err = iamClient.SetIamPolicy(ctx, &iampb.SetIamPolicyRequest{Resource: resource, Policy: policy}); err != nil {
+		return fmt.Errorf("failed to remove invokers of: %s: %w", resource, err)
+	}
+	return nil
 }
 
-func (o *GCPClient) DownloadPublicKeys(path string) (string, error) {
-	panic("not yet supported")
+func (p *GCPClient) unblockFunction(funcIdentifier string) error {
+	ctx := context.Background()
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer storageClient.Close()
+	blockedObject := storageClient.Bucket(p.bucket).Object(blockedMembersObjectName(funcIdentifier))
+	rc, err := blockedObject.NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read invokers of function: %w", err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read invokers of function: %w", err)
+	}
+	var removedMembers []string
+	if err = json.Unmarshal(content, &removedMembers); err != nil {
+		return fmt.Errorf("failed to parse invokers of function: %w", err)
+	}
+
+	if len(removedMembers) > 0 {
+		iamClient, resource, role, err := invokerPolicy(ctx, funcIdentifier)
+		if err != nil {
+			return err
+		}
+		defer iamClient.Close()
+		policy, err := iamClient.GetIamPolicy(ctx, &iampb.GetIamPolicyRequest{Resource: resource})
+		if err != nil {
+			return fmt.Errorf("failed to get iam policy of: %s: %w", resource, err)
+		}
+		restoreInvokers(policy, role, removedMembers)
+		if _, err = iamClient.SetIamPolicy(ctx, &iampb.SetIamPolicyRequest{Resource: resource, Policy: policy}); err != nil {
+			return fmt.Errorf("failed to restore invokers of: %s: %w", resource, err)
+		}
+	}
+	if err = blockedObject.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete saved invokers of function: %w", err)
+	}
+	return nil
 }
 
-func (o *GCPClient) GetFuncHash(funcIdentifier string) (string, error) {
-	panic("not yet supported")
+func restoreInvokers(policy *iampb.Policy, role string, members []string) {
+	for _, binding := range policy.Bindings {
+		if binding.Role == role && binding.Condition == nil {
+			existing := make(map[string]bool, len(binding.Members))
+			for _, member := range binding.Members {
+				existing[member] = true
+			}
+			for _, member := range members {
+				if !existing[member] {
+					binding.Members = append(binding.Members, member)
+				}
+			}
+			return
+		}
+	}
+	policy.Bindings = append(policy.Bindings, &iampb.Binding{Role: role, Members: members})
+}
+
+func blockedMembersObjectName(funcIdentifier string) string {
+	return gcpBlockedMembersFolder + strings.ReplaceAll(strings.Trim(funcIdentifier, "/"), "/", "-") + ".json"
+}
+
+// DeployFunctionClarity deploys the verifier function, with the source archive at sourcePath, see ResolveVerifierSource.
+func (p *GCPClient) DeployFunctionClarity(keyPath string, sourcePath string, deploymentConfig i.GCPInput) error {
+	ctx := context.Background()
+	project, location := deploymentConfig.Project, deploymentConfig.Location
+	functionName := fmt.Sprintf("projects/%s/locations/%s/functions/%s", project, location, FunctionClarityGCPVerifierName)
+	functionsClient, err := funcv1.NewCloudFunctionsClient(ctx)
+	if err != nil {
+		return fmt.Errorf("cloud functions.NewClient: %w", err)
+	}
+	defer functionsClient.Close()
+	if _, err = functionsClient.GetFunction(ctx, &funcpb1.GetFunctionRequest{Name: functionName}); err == nil {
+		return fmt.Errorf("function clarity already deployed, please delete function %s before you deploy", functionName)
+	}
+
+	sourceArchiveUrl, err := p.uploadFuncClarityCode(ctx, project, location, sourcePath, keyPath, deploymentConfig.Policy)
+	if err != nil {
+		return fmt.Errorf("failed to upload function clarity code: %w", err)
+	}
+	if deploymentConfig.Policy != "" {
+		deploymentConfig.Policy = policy.FileName
+	}
+	topic := fmt.Sprintf("projects/%s/topics/%s", project, gcpAuditEventsTopicName)
+	if err = createAuditLogSink(ctx, project, topic, deploymentConfig.VerifierServiceAccount()); err != nil {
+		return fmt.Errorf("failed to create audit log sink: %w", err)
+	}
+
+	serConfig, err := yaml.Marshal(deploymentConfig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize configuration: %w", err)
+	}
+	op, err := functionsClient.CreateFunction(ctx, &funcpb1.CreateFunctionRequest{
+		Location: fmt.Sprintf("projects/%s/locations/%s", project, location),
+		Function: &funcpb1.CloudFunction{
+			Name:                functionName,
+			SourceCode:          &funcpb1.CloudFunction_SourceArchiveUrl{SourceArchiveUrl: sourceArchiveUrl},
+			Trigger:             &funcpb1.CloudFunction_EventTrigger{EventTrigger: &funcpb1.EventTrigger{EventType: "google.pubsub.topic.publish", Resource: topic}},
+			EntryPoint:          "HandleRequest",
+			Runtime:             "go119",
+			Timeout:             durationpb.New(5 * time.Minute),
+			AvailableMemoryMb:   512,
+			ServiceAccountEmail: deploymentConfig.VerifierServiceAccount(),
+			EnvironmentVariables: map[string]string{
+				ConfigEnvVariableName: b64.StdEncoding.EncodeToString(serConfig),
+				// the runtime file system is read only except for /tmp
+				"HOME": "/tmp",
+			},
+		},
+	})
+	fmt.Println("deployment request sent to provider")
+	if err != nil {
+		return fmt.Errorf("failed to create function: %w", err)
+	}
+	fmt.Println("waiting for deployment to complete")
+	if _, err = op.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to create function: %w", err)
+	}
+	fmt.Println("deployment finished successfully")
+	return nil
+}
+
+// uploadFuncClarityCode uploads the verifier function source, together with the public key and the policy, and returns its gs:// url.
+func (p *GCPClient) uploadFuncClarityCode(ctx context.Context, project string, location string, sourcePath string, keyPath string, policyPath string) (string, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+	bucket := client.Bucket(p.bucket)
+	var apiErr *googleapi.Error
+	if err = bucket.Create(ctx, project, &storage.BucketAttrs{Location: location}); err != nil && !(errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict) {
+		return "", err
+	}
+
+	source, err := zip.OpenReader(sourcePath)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	wc := bucket.Object(gcpFunctionSourceArchive).NewWriter(ctx)
+	zipWriter := zip.NewWriter(wc)
+	for _, f := range source.File {
+		if err = copyZipEntry(zipWriter, f); err != nil {
+			wc.Close()
+			return "", err
+		}
+	}
+	files := map[string]string{}
+	if keyPath != "" {
+		files["cosign.pub"] = keyPath
+	}
+	if policyPath != "" {
+		files[policy.FileName] = policyPath
+	}
+	for name, filePath := range files {
+		if err = addZipFile(zipWriter, name, filePath); err != nil {
+			wc.Close()
+			return "", err
+		}
+	}
+	if err = zipWriter.Close(); err != nil {
+		wc.Close()
+		return "", err
+	}
+	if err = wc.Close(); err != nil {
+		return "", fmt.Errorf("Writer.Close: %w", err)
+	}
+	return fmt.Sprintf("gs://%s/%s", p.bucket, gcpFunctionSourceArchive), nil
+}
+
+func addZipFile(zipWriter *zip.Writer, name string, filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	w, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func copyZipEntry(zipWriter *zip.Writer, f *zip.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := zipWriter.CreateHeader(&f.FileHeader)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// createAuditLogSink routes the audit log entries of function changes to the topic the verifier is triggered by.
+func createAuditLogSink(ctx context.Context, project string, topic string, verifierServiceAccount string) error {
+	pubsubService, err := pubsub.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("pubsub.NewService: %w", err)
+	}
+	var apiErr *googleapi.Error
+	if _, err = pubsubService.Projects.Topics.Create(topic, &pubsub.Topic{}).Context(ctx).Do(); err != nil && !(errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict) {
+		return fmt.Errorf("failed to create topic %s: %w", topic, err)
+	}
+
+	loggingService, err := logging.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("logging.NewService: %w", err)
+	}
+	sink, err := loggingService.Projects.Sinks.Create("projects/"+project, &logging.LogSink{
+		Name:        gcpAuditSinkName,
+		Destination: "pubsub.googleapis.com/" + topic,
+		Filter:      gcpAuditSinkFilter(verifierServiceAccount),
+	}).UniqueWriterIdentity(true).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to create sink %s: %w", gcpAuditSinkName, err)
+	}
+
+	policy, err := pubsubService.Projects.Topics.GetIamPolicy(topic).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to get topic iam policy: %w", err)
+	}
+	policy.Bindings = append(policy.Bindings, &pubsub.Binding{Role: "roles/pubsub.publisher", Members: []string{sink.WriterIdentity}})
+	if _, err = pubsubService.Projects.Topics.SetIamPolicy(topic, &pubsub.SetIamPolicyRequest{Policy: policy}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to grant sink writer identity on topic: %w", err)
+	}
+	return nil
 }
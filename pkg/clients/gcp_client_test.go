@@ -0,0 +1,60 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+)
+
+func TestParseGCPResourceName(t *testing.T) {
+	project, location, kind, name, err := parseGCPResourceName("projects/p/locations/us-central1/functions/f")
+	if err != nil || project != "p" || location != "us-central1" || kind != "functions" || name != "f" {
+		t.Fatalf("unexpected parse result: %s %s %s %s: %v", project, location, kind, name, err)
+	}
+	if _, _, _, _, err = parseGCPResourceName("projects/p/topics/t"); err == nil {
+		t.Fatal("expected invalid function identifier error")
+	}
+	if objectName := blockedMembersObjectName("projects/p/locations/l/services/s"); objectName != gcpBlockedMembersFolder+"projects-p-locations-l-services-s.json" {
+		t.Fatalf("unexpected blocked members object name: %s", objectName)
+	}
+}
+
+func TestGCPAuditSinkFilter(t *testing.T) {
+	filter := gcpAuditSinkFilter("verifier@fc-project.iam.gserviceaccount.com")
+	if !strings.HasSuffix(filter, ` AND NOT protoPayload.authenticationInfo.principalEmail="verifier@fc-project.iam.gserviceaccount.com"`) {
+		t.Fatalf("expected the changes of the verifier to be excluded, got: %s", filter)
+	}
+}
+
+func TestRestoreInvokers(t *testing.T) {
+	policy := &iampb.Policy{Bindings: []*iampb.Binding{
+		{Role: "roles/viewer", Members: []string{"user:a@example.com"}},
+		{Role: "roles/run.invoker", Members: []string{"user:b@example.com"}},
+	}}
+	restoreInvokers(policy, "roles/run.invoker", []string{"allUsers", "user:b@example.com"})
+	expected := []string{"user:b@example.com", "allUsers"}
+	if len(policy.Bindings) != 2 || !reflect.DeepEqual(policy.Bindings[1].Members, expected) {
+		t.Fatalf("unexpected invokers: %v", policy.Bindings)
+	}
+	restoreInvokers(policy, "roles/cloudfunctions.invoker", []string{"allUsers"})
+	if len(policy.Bindings) != 3 || policy.Bindings[2].Role != "roles/cloudfunctions.invoker" {
+		t.Fatalf("expected new invoker binding: %v", policy.Bindings)
+	}
+}
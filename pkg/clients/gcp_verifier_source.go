@@ -0,0 +1,51 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+// verifierSourceReleaseUrl is the url of the verifier function source archive released with a version.
+var verifierSourceReleaseUrl = "https://github.com/openclarity/functionclarity/releases/download/%s/gcp_function.zip"
+
+// ResolveVerifierSource returns the path of the verifier function source archive to deploy: sourcePath when set, else
+// the archive released with the version of the cli, downloaded and checked against its published checksum once, under
+// the home dir.
+func ResolveVerifierSource(sourcePath string) (string, error) {
+	if sourcePath != "" {
+		if _, err := os.Stat(sourcePath); err != nil {
+			return "", fmt.Errorf("failed to find verifier function source: %w", err)
+		}
+		return sourcePath, nil
+	}
+	if utils.Version == "" {
+		return "", fmt.Errorf("failed to find verifier function source: a development build has no released verifier, " +
+			"build it with: make gcp-function and set its path with --verifier-source")
+	}
+	cachedPath := filepath.Join(utils.FunctionClarityHomeDir, "verifier", utils.Version, gcpFunctionSourceArchive)
+	if _, err := os.Stat(cachedPath); err == nil {
+		return cachedPath, nil
+	}
+	if err := downloadReleaseAsset(fmt.Sprintf(verifierSourceReleaseUrl, utils.Version), cachedPath); err != nil {
+		return "", fmt.Errorf("failed to download verifier function source of version: %s: %w", utils.Version, err)
+	}
+	return cachedPath, nil
+}
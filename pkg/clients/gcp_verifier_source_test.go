@@ -0,0 +1,82 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func TestResolveVerifierSource(t *testing.T) {
+	source := []byte("verifier source")
+	checksum := sha256.Sum256(source)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.2.3/gcp_function.zip":
+			w.Write(source) //nolint:errcheck
+		case "/v1.2.3/gcp_function.zip.sha256":
+			fmt.Fprintln(w, hex.EncodeToString(checksum[:]))
+		case "/v1.2.4/gcp_function.zip":
+			w.Write([]byte("tampered source")) //nolint:errcheck
+		case "/v1.2.4/gcp_function.zip.sha256":
+			fmt.Fprintln(w, hex.EncodeToString(checksum[:]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	previousUrl, previousVersion, previousHomeDir := verifierSourceReleaseUrl, utils.Version, utils.FunctionClarityHomeDir
+	defer func() {
+		verifierSourceReleaseUrl, utils.Version, utils.FunctionClarityHomeDir = previousUrl, previousVersion, previousHomeDir
+	}()
+	verifierSourceReleaseUrl = server.URL + "/%s/gcp_function.zip"
+	utils.FunctionClarityHomeDir = t.TempDir()
+
+	utils.Version = ""
+	if _, err := ResolveVerifierSource(""); err == nil {
+		t.Fatal("expected a development build without a source path to fail")
+	}
+
+	utils.Version = "v1.2.3"
+	path, err := ResolveVerifierSource("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(utils.FunctionClarityHomeDir, "verifier", "v1.2.3", "gcp_function.zip"); path != expected {
+		t.Fatalf("expected the released source at: %s, got: %s", expected, path)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != string(source) {
+		t.Fatalf("unexpected source content: %s, %v", content, err)
+	}
+
+	utils.Version = "v1.2.4"
+	if _, err = ResolveVerifierSource(""); err == nil {
+		t.Fatal("expected a checksum mismatch to fail")
+	}
+
+	if path, err = ResolveVerifierSource("other.zip"); err == nil {
+		t.Fatalf("expected a missing source path to fail, got: %s", path)
+	}
+}
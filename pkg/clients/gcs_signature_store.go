@@ -0,0 +1,153 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"google.golang.org/api/iterator"
+)
+
+type GCSSignatureStore struct {
+	bucket string
+	prefix string
+}
+
+func NewGCSSignatureStore(bucket string, prefix string) *GCSSignatureStore {
+	p := new(GCSSignatureStore)
+	p.bucket = bucket
+	p.prefix = prefix
+	return p
+}
+
+func (p *GCSSignatureStore) Upload(signature string, identity string, isKeyless bool) error {
+	if err := p.UploadFile(signature, identity+".sig"); err != nil {
+		return err
+	}
+	if isKeyless {
+		certificate, err := os.ReadFile(utils.FunctionClarityHomeDir + identity + ".crt.base64")
+		if err != nil {
+			return err
+		}
+		if err = p.UploadFile(string(certificate), identity+".crt.base64"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GCSSignatureStore) UploadFile(content string, fileName string) error {
+	ctx := context.Background()
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	wc := client.Bucket(p.bucket).Object(p.prefix + fileName).NewWriter(ctx)
+	if _, err = io.Copy(wc, strings.NewReader(content)); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+	fmt.Printf("Uploaded %v to: %v\n", p.prefix+fileName, p.bucket)
+	return nil
+}
+
+func (p *GCSSignatureStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	fileName = fileName + "." + outputType
+	bucket := p.bucket
+	objectName := p.prefix + fileName
+	if bucketPathToSignatures != "" {
+		if bucket, objectName, err = extractBucketAndPath(bucketPathToSignatures + fileName); err != nil {
+			return err
+		}
+	}
+	outputFile := filepath.Join(outputDir, fileName)
+	if err = downloadObject(ctx, client, bucket, objectName, outputFile); err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("%w : %s", utils.ErrSignatureNotFound, objectName)
+		}
+		return err
+	}
+	fmt.Printf("Downloaded %v to: %v\n", objectName, outputFile)
+	return nil
+}
+
+// DownloadPublicKeys downloads all the keys under the GCS path, given as gs://<bucket>/<folder>.
+func (p *GCSSignatureStore) DownloadPublicKeys(path string) (string, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	bucketName, folder, err := extractBucketAndPath(path)
+	if err != nil {
+		return "", err
+	}
+	resultFolderName, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
+	if err = os.MkdirAll(folderResultFullPath, os.ModePerm); err != nil {
+		return "", err
+	}
+	objects := client.Bucket(bucketName).Objects(ctx, &storage.Query{Prefix: folder})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to list public keys in: %s: %w", path, err)
+		}
+		if strings.HasSuffix(attrs.Name, "/") {
+			continue
+		}
+		if err = downloadObject(ctx, client, bucketName, attrs.Name, folderResultFullPath+"/"+strings.ReplaceAll(attrs.Name, "/", "-")); err != nil {
+			return "", err
+		}
+	}
+	return folderResultFullPath, nil
+}
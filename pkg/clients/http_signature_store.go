@@ -0,0 +1,141 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+// HTTPSignatureStoreTokenEnv holds an optional bearer token sent to http signature stores.
+const HTTPSignatureStoreTokenEnv = "FUNCTION_CLARITY_SIGNATURE_STORE_TOKEN"
+
+// httpSignatureStoreTimeout bounds each request to the store, including the transfer of the file.
+const httpSignatureStoreTimeout = time.Minute
+
+// HTTPSignatureStore keeps the signatures under a base url, files are uploaded with PUT and downloaded with GET.
+type HTTPSignatureStore struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewHTTPSignatureStore(baseURL string) *HTTPSignatureStore {
+	p := new(HTTPSignatureStore)
+	p.baseURL = strings.TrimSuffix(baseURL, "/") + "/"
+	p.httpClient = &http.Client{Timeout: httpSignatureStoreTimeout}
+	return p
+}
+
+func (o *HTTPSignatureStore) Upload(signature string, identity string, isKeyless bool) error {
+	if err := o.UploadFile(signature, identity+".sig"); err != nil {
+		return err
+	}
+	if isKeyless {
+		certificate, err := os.ReadFile(utils.FunctionClarityHomeDir + identity + ".crt.base64")
+		if err != nil {
+			return err
+		}
+		if err = o.UploadFile(string(certificate), identity+".crt.base64"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *HTTPSignatureStore) UploadFile(content string, fileName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpSignatureStoreTimeout)
+	defer cancel()
+
+	resp, err := o.do(ctx, http.MethodPut, o.baseURL+fileName, strings.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to upload %s: unexpected status: %s", fileName, resp.Status)
+	}
+	return nil
+}
+
+func (o *HTTPSignatureStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	baseURL := o.baseURL
+	if bucketPathToSignatures != "" {
+		baseURL = strings.TrimSuffix(bucketPathToSignatures, "/") + "/"
+	}
+	fileName = fileName + "." + outputType
+	return o.download(baseURL+fileName, filepath.Join(outputDir, fileName))
+}
+
+// DownloadPublicKeys downloads the public key of the url, a relative path is resolved against the base url of the store.
+func (o *HTTPSignatureStore) DownloadPublicKeys(keyURL string) (string, error) {
+	if !strings.HasPrefix(keyURL, "http://") && !strings.HasPrefix(keyURL, "https://") {
+		keyURL = o.baseURL + strings.TrimPrefix(keyURL, "/")
+	}
+	resultFolderName, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
+	if err = os.MkdirAll(folderResultFullPath, os.ModePerm); err != nil {
+		return "", err
+	}
+	return folderResultFullPath, o.download(keyURL, folderResultFullPath+"/"+path.Base(keyURL))
+}
+
+func (o *HTTPSignatureStore) download(fileURL string, outputFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpSignatureStoreTimeout)
+	defer cancel()
+
+	resp, err := o.do(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w : %s", utils.ErrSignatureNotFound, fileURL)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: unexpected status: %s", fileURL, resp.Status)
+	}
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
+func (o *HTTPSignatureStore) do(ctx context.Context, method string, fileURL string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fileURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(HTTPSignatureStoreTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return o.httpClient.Do(req)
+}
@@ -0,0 +1,145 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func TestNewSignatureStore(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected SignatureStore
+	}{
+		{"s3://signatures", NewS3SignatureStore("ak", "sk", "signatures", "", "us-east-1")},
+		{"s3://signatures/team/functions", NewS3SignatureStore("ak", "sk", "signatures", "team/functions/", "us-east-1")},
+		{"gs://signatures/team/", NewGCSSignatureStore("signatures", "team/")},
+		{"https://signatures.example.com/functions", NewHTTPSignatureStore("https://signatures.example.com/functions")},
+		{"file:///var/signatures", NewFileSignatureStore("/var/signatures")},
+		{"signatures", NewFileSignatureStore("signatures")},
+	}
+	for _, tt := range tests {
+		store, err := NewSignatureStore(tt.url, "ak", "sk", "us-east-1")
+		if err != nil {
+			t.Fatalf("failed to create signature store of %s: %v", tt.url, err)
+		}
+		if !reflect.DeepEqual(store, tt.expected) {
+			t.Fatalf("unexpected signature store of %s: %+v", tt.url, store)
+		}
+	}
+	if _, err := NewSignatureStore("ftp://signatures", "", "", ""); err == nil {
+		t.Fatal("expected unsupported signature store error")
+	}
+}
+
+func TestHTTPSignatureStore(t *testing.T) {
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(HTTPSignatureStoreTokenEnv, "token")
+	var lock sync.Mutex
+	files := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			content, _ := io.ReadAll(r.Body)
+			files[r.URL.Path] = string(content)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			content, ok := files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		}
+	}))
+	defer server.Close()
+
+	store := NewHTTPSignatureStore(server.URL + "/signatures/")
+	if err := store.Upload("signature", "identity", false); err != nil {
+		t.Fatalf("failed to upload signature: %v", err)
+	}
+	if files["/signatures/identity.sig"] != "signature" {
+		t.Fatalf("signature not uploaded: %v", files)
+	}
+	outputDir := t.TempDir()
+	if err := store.DownloadSignature("identity", "sig", "", outputDir); err != nil {
+		t.Fatalf("failed to download signature: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "identity.sig"))
+	if err != nil || string(content) != "signature" {
+		t.Fatalf("signature not downloaded to the output dir: %v", err)
+	}
+
+	err = store.DownloadSignature("missing", "sig", "", outputDir)
+	if err == nil || !strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
+		t.Fatalf("expected signature not found error, got: %v", err)
+	}
+
+	if err = store.UploadFile("public key", "keys/cosign.pub"); err != nil {
+		t.Fatalf("failed to upload public key: %v", err)
+	}
+	keysFolder, err := store.DownloadPublicKeys("keys/cosign.pub")
+	if err != nil {
+		t.Fatalf("failed to download public key: %v", err)
+	}
+	defer utils.CleanDirectory(keysFolder)
+	if content, err = os.ReadFile(keysFolder + "/cosign.pub"); err != nil || string(content) != "public key" {
+		t.Fatalf("public key not downloaded: %v", err)
+	}
+}
+
+func TestHTTPSignatureStoreTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a stalled store
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	store := NewHTTPSignatureStore(server.URL)
+	store.httpClient.Timeout = 50 * time.Millisecond
+	err := store.DownloadSignature("identity", "sig", "", t.TempDir())
+	if err == nil || errors.Is(err, utils.ErrSignatureNotFound) {
+		t.Fatalf("expected download to time out, got: %v", err)
+	}
+	if err = store.UploadFile("signature", "identity.sig"); err == nil {
+		t.Fatal("expected upload to time out")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+// LocalClient verifies function packages that are available on the local
+// filesystem. Together with a FileSignatureStore it doesn't require any cloud
+// account and is meant for air-gapped environments.
+type LocalClient struct {
+}
+
+func NewLocalClient() *LocalClient {
+	return new(LocalClient)
+}
+
+func (o *LocalClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	if _, err := os.Stat(funcIdentifier); err != nil {
+		return "", fmt.Errorf("failed to find local package: %s: %w", funcIdentifier, err)
+	}
+	return "Zip", nil
+}
+
+func (o *LocalClient) GetFuncCode(funcIdentifier string) (string, error) {
+	info, err := os.Stat(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	contentName := uuid.New().String()
+	codePath := utils.FunctionClarityHomeDir + contentName
+	// the returned path is removed once verification ends, so the package is always copied or extracted
+	if info.IsDir() {
+		if err := copyDirectory(funcIdentifier, codePath); err != nil {
+			return codePath, err
+		}
+		return codePath, nil
+	}
+	lowerIdentifier := strings.ToLower(funcIdentifier)
+	switch {
+	case strings.HasSuffix(lowerIdentifier, ".zip"):
+		err = utils.ExtractZip(funcIdentifier, codePath)
+	case strings.HasSuffix(lowerIdentifier, ".tar.gz"), strings.HasSuffix(lowerIdentifier, ".tgz"):
+		err = utils.ExtractTarGz(funcIdentifier, codePath)
+	default:
+		return "", fmt.Errorf("unsupported local package: %s, expected a zip or tar.gz file or a directory", funcIdentifier)
+	}
+	if err != nil {
+		return codePath, err
+	}
+	return codePath, nil
+}
+
+func (o *LocalClient) GetFuncImageURI(funcIdentifier string) (string, error) {
+	return "", fmt.Errorf("image packages are not supported for local functions")
+}
+
+func (o *LocalClient) GetFuncHash(funcIdentifier string) (string, error) {
+	return "", fmt.Errorf("image packages are not supported for local functions")
+}
+
+func (o *LocalClient) IsFuncInRegions(funcIdentifier string, regions []string) (bool, error) {
+	return true, nil
+}
+
+func (o *LocalClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
+	return false, nil
+}
+
+func (o *LocalClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (o *LocalClient) HandleBlock(funcIdentifier *string, failed bool) error {
+	return fmt.Errorf("block action is not supported for local functions")
+}
+
+func (o *LocalClient) HandleDetect(funcIdentifier *string, failureCategory string) error {
+	return fmt.Errorf("detect action is not supported for local functions")
+}
+
+func (o *LocalClient) Notify(msg string, snsArn string) error {
+	return fmt.Errorf("notifications are not supported for local functions")
+}
+
+func (o *LocalClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
+	notification.FunctionName = filepath.Base(functionIdentifier)
+	notification.FunctionIdentifier = functionIdentifier
+	return nil
+}
+
+func copyDirectory(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func TestLocalClientGetFuncCodeKeepsSource(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing"
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	localClient := NewLocalClient()
+	codePath, err := localClient.GetFuncCode(pathToSourceCode)
+	if err != nil {
+		t.Fatalf("failed to get local function code: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(codePath, "cmd", "commands.go")); err != nil {
+		t.Fatalf("code not copied: %v", err)
+	}
+	utils.CleanDirectory(codePath)
+	if _, err := os.Stat(filepath.Join(pathToSourceCode, "cmd", "commands.go")); err != nil {
+		t.Fatalf("source folder must not be affected by cleanup: %v", err)
+	}
+}
+
+func TestLocalClientGetFuncCodeTarGz(t *testing.T) {
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "code.tgz")
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gzipWriter := gzip.NewWriter(archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	// the archive of a folder created with tar -C <folder> . holds its root as ./
+	if err = tarWriter.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"./main.py": "print('hello')", "./lib/util.py": "pass"} {
+		if err = tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	codePath, err := NewLocalClient().GetFuncCode(archivePath)
+	if err != nil {
+		t.Fatalf("failed to get local function code: %v", err)
+	}
+	defer utils.CleanDirectory(codePath)
+	content, err := os.ReadFile(filepath.Join(codePath, "lib", "util.py"))
+	if err != nil || string(content) != "pass" {
+		t.Fatalf("code not extracted: %s, %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(codePath, "main.py")); err != nil {
+		t.Fatalf("code not extracted: %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/google"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const (
+	OCIArtifactConfigMediaType types.MediaType = "application/vnd.dev.functionclarity.identity.config.v1+json"
+	OCISignatureMediaType      types.MediaType = "application/vnd.dev.functionclarity.signature.v1"
+	OCICertificateMediaType    types.MediaType = "application/vnd.dev.functionclarity.certificate.v1"
+	OCIManifestMediaType       types.MediaType = "application/vnd.dev.functionclarity.manifest.v1+json"
+	OCIIdentityAnnotationKey                   = "dev.functionclarity.identity"
+	ociTitleAnnotationKey                      = "org.opencontainers.image.title"
+	ociMaxTagLength                            = 128
+)
+
+// OCISignatureStore keeps the signatures, certificates and manifest of each identity as one OCI artifact,
+// tagged by the identity in the repository, i.e. next to the function images in ECR or Artifact Registry.
+type OCISignatureStore struct {
+	repository string
+	options    []remote.Option
+}
+
+func NewOCISignatureStore(repository string, options ...remote.Option) *OCISignatureStore {
+	p := new(OCISignatureStore)
+	p.repository = strings.TrimSuffix(repository, "/")
+	p.options = options
+	if len(p.options) == 0 {
+		p.options = []remote.Option{remote.WithAuthFromKeychain(authn.NewMultiKeychain(authn.DefaultKeychain, google.Keychain))}
+	}
+	return p
+}
+
+func (o *OCISignatureStore) Upload(signature string, identity string, isKeyless bool) error {
+	files := map[string][]byte{identity + ".sig": []byte(signature)}
+	if isKeyless {
+		certificate, err := os.ReadFile(utils.FunctionClarityHomeDir + identity + ".crt.base64")
+		if err != nil {
+			return err
+		}
+		files[identity+".crt.base64"] = certificate
+	}
+	return o.putFiles(ociIdentity(identity), files)
+}
+
+func (o *OCISignatureStore) UploadFile(content string, fileName string) error {
+	return o.putFiles(ociIdentity(fileName), map[string][]byte{fileName: []byte(content)})
+}
+
+func (o *OCISignatureStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	repository := o.repository
+	if bucketPathToSignatures != "" {
+		repository = strings.TrimSuffix(strings.TrimPrefix(bucketPathToSignatures, "oci://"), "/")
+	}
+	fileName = fileName + "." + outputType
+	ref, err := name.ParseReference(repository + ":" + ociTag(ociIdentity(fileName)))
+	if err != nil {
+		return fmt.Errorf("failed to parse signature reference: %w", err)
+	}
+	img, err := o.fetch(ref)
+	if err != nil {
+		return err
+	}
+	if img == nil {
+		return fmt.Errorf("%w : %s", utils.ErrSignatureNotFound, ref)
+	}
+	files, err := artifactFiles(img)
+	if err != nil {
+		return err
+	}
+	content, ok := files[fileName]
+	if !ok {
+		return fmt.Errorf("%w : %s in %s", utils.ErrSignatureNotFound, fileName, ref)
+	}
+	return os.WriteFile(filepath.Join(outputDir, fileName), content, 0600)
+}
+
+// DownloadPublicKeys downloads the files of the artifact, given as [oci://]<registry>/<repository>:<tag>.
+func (o *OCISignatureStore) DownloadPublicKeys(path string) (string, error) {
+	ref, err := name.ParseReference(strings.TrimPrefix(path, "oci://"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public keys reference: %w", err)
+	}
+	img, err := o.fetch(ref)
+	if err != nil {
+		return "", err
+	}
+	if img == nil {
+		return "", fmt.Errorf("public keys artifact not found: %s", ref)
+	}
+	files, err := artifactFiles(img)
+	if err != nil {
+		return "", err
+	}
+	resultFolderName, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
+	if err = os.MkdirAll(folderResultFullPath, os.ModePerm); err != nil {
+		return "", err
+	}
+	for fileName, content := range files {
+		if err = os.WriteFile(filepath.Join(folderResultFullPath, filepath.Base(fileName)), content, 0600); err != nil {
+			return "", err
+		}
+	}
+	return folderResultFullPath, nil
+}
+
+// putFiles adds the files to the artifact of the identity, replacing files with the same name.
+func (o *OCISignatureStore) putFiles(identity string, files map[string][]byte) error {
+	ref, err := name.ParseReference(o.repository + ":" + ociTag(identity))
+	if err != nil {
+		return fmt.Errorf("failed to parse signature reference: %w", err)
+	}
+	existing, err := o.fetch(ref)
+	if err != nil {
+		return err
+	}
+	var addenda []mutate.Addendum
+	if existing != nil {
+		manifest, err := existing.Manifest()
+		if err != nil {
+			return err
+		}
+		for _, desc := range manifest.Layers {
+			if _, replaced := files[desc.Annotations[ociTitleAnnotationKey]]; replaced {
+				continue
+			}
+			layer, err := existing.LayerByDigest(desc.Digest)
+			if err != nil {
+				return err
+			}
+			addenda = append(addenda, mutate.Addendum{Layer: layer, MediaType: desc.MediaType, Annotations: desc.Annotations})
+		}
+	}
+	for fileName, content := range files {
+		mediaType := ociMediaType(fileName)
+		addenda = append(addenda, mutate.Addendum{
+			Layer:       static.NewLayer(content, mediaType),
+			MediaType:   mediaType,
+			Annotations: map[string]string{ociTitleAnnotationKey: fileName},
+		})
+	}
+	img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), OCIArtifactConfigMediaType)
+	if img, err = mutate.Append(img, addenda...); err != nil {
+		return err
+	}
+	img = mutate.Annotations(img, map[string]string{OCIIdentityAnnotationKey: identity}).(v1.Image)
+	if err = remote.Write(ref, img, o.options...); err != nil {
+		return fmt.Errorf("failed to push signature artifact: %s: %w", ref, err)
+	}
+	fmt.Printf("Uploaded %s to: %s\n", strings.Join(fileNames(files), ", "), ref)
+	return nil
+}
+
+// fetch returns the artifact of the reference, or nil when it doesn't exist.
+func (o *OCISignatureStore) fetch(ref name.Reference) (v1.Image, error) {
+	img, err := remote.Image(ref, o.options...)
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signature artifact: %s: %w", ref, err)
+	}
+	return img, nil
+}
+
+func artifactFiles(img v1.Image) (map[string][]byte, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, desc := range manifest.Layers {
+		fileName := desc.Annotations[ociTitleAnnotationKey]
+		if fileName == "" {
+			continue
+		}
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[fileName] = content
+	}
+	return files, nil
+}
+
+// ociIdentity returns the identity the file belongs to, the identity manifest is kept in the artifact of its identity.
+func ociIdentity(fileName string) string {
+	for _, suffix := range []string{".json", ".sig", ".crt.base64"} {
+		if strings.HasSuffix(fileName, suffix) {
+			fileName = strings.TrimSuffix(fileName, suffix)
+			break
+		}
+	}
+	return strings.TrimSuffix(fileName, ".manifest")
+}
+
+// ociTag returns the tag of the identity, identities that don't fit in a tag are tagged by their sha256.
+func ociTag(identity string) string {
+	tag := strings.ReplaceAll(identity, ":", "-")
+	if len(tag) > ociMaxTagLength {
+		return fmt.Sprintf("fc-sha256-%x", sha256.Sum256([]byte(identity)))
+	}
+	return tag
+}
+
+func ociMediaType(fileName string) types.MediaType {
+	switch {
+	case strings.HasSuffix(fileName, ".sig"):
+		return OCISignatureMediaType
+	case strings.HasSuffix(fileName, ".crt.base64"):
+		return OCICertificateMediaType
+	case strings.HasSuffix(fileName, ".json"):
+		return OCIManifestMediaType
+	}
+	return "application/octet-stream"
+}
+
+func fileNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for fileName := range files {
+		names = append(names, fileName)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,143 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+func newTestRegistry(t *testing.T) string {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
+
+func TestOCISignatureStore(t *testing.T) {
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	repository := newTestRegistry(t) + "/functions/signatures"
+	store, err := NewSignatureStore("oci://"+repository, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := "fc-v2:sha512:" + strings.Repeat("ab", 64)
+	certificatePath := utils.FunctionClarityHomeDir + identity + ".crt.base64"
+	if err = os.WriteFile(certificatePath, []byte("certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer utils.CleanDirectory(certificatePath)
+
+	if err = store.Upload("signature", identity, true); err != nil {
+		t.Fatalf("failed to upload signature: %v", err)
+	}
+	if err = store.UploadFile("{}", identity+".manifest.json"); err != nil {
+		t.Fatalf("failed to upload manifest: %v", err)
+	}
+	if err = store.Upload("manifest signature", identity+".manifest", false); err != nil {
+		t.Fatalf("failed to upload manifest signature: %v", err)
+	}
+	// uploading a file again replaces it in the artifact
+	if err = store.Upload("new signature", identity, false); err != nil {
+		t.Fatalf("failed to upload signature: %v", err)
+	}
+
+	ref, err := name.ParseReference(repository + ":" + ociTag(identity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := remote.Image(ref)
+	if err != nil {
+		t.Fatalf("identity artifact not found: %v", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Layers) != 4 || manifest.Config.MediaType != OCIArtifactConfigMediaType || manifest.Annotations[OCIIdentityAnnotationKey] != identity {
+		t.Fatalf("unexpected identity artifact: %+v", manifest)
+	}
+
+	expected := map[string]string{
+		identity + ".sig":           "new signature",
+		identity + ".crt.base64":    "certificate",
+		identity + ".manifest.json": "{}",
+		identity + ".manifest.sig":  "manifest signature",
+	}
+	outputDir := t.TempDir()
+	for fileName, content := range expected {
+		i := strings.LastIndex(fileName, ".")
+		if strings.HasSuffix(fileName, ".crt.base64") {
+			i = strings.LastIndex(fileName, ".crt.base64")
+		}
+		if err = store.DownloadSignature(fileName[:i], fileName[i+1:], "", outputDir); err != nil {
+			t.Fatalf("failed to download %s: %v", fileName, err)
+		}
+		downloaded, err := os.ReadFile(filepath.Join(outputDir, fileName))
+		if err != nil || string(downloaded) != content {
+			t.Fatalf("unexpected content of %s: %s: %v", fileName, downloaded, err)
+		}
+	}
+
+	err = store.DownloadSignature("missing", "sig", "", outputDir)
+	if err == nil || !strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
+		t.Fatalf("expected signature not found error, got: %v", err)
+	}
+	err = store.DownloadSignature(identity+".manifest", "crt.base64", "", outputDir)
+	if err == nil || !strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
+		t.Fatalf("expected certificate not found error, got: %v", err)
+	}
+}
+
+func TestOCISignatureStoreDownloadPublicKeys(t *testing.T) {
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	repository := newTestRegistry(t) + "/functions/keys"
+	store := NewOCISignatureStore(repository)
+	if err := store.putFiles("keys", map[string][]byte{"first.pub": []byte("first key")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.putFiles("keys", map[string][]byte{"second.pub": []byte("second key")}); err != nil {
+		t.Fatal(err)
+	}
+	keysFolder, err := store.DownloadPublicKeys("oci://" + repository + ":keys")
+	if err != nil {
+		t.Fatalf("failed to download public keys: %v", err)
+	}
+	defer utils.CleanDirectory(keysFolder)
+	for fileName, content := range map[string]string{"first.pub": "first key", "second.pub": "second key"} {
+		if downloaded, err := os.ReadFile(keysFolder + "/" + fileName); err != nil || string(downloaded) != content {
+			t.Fatalf("unexpected content of %s: %s: %v", fileName, downloaded, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// downloadReleaseAsset downloads the release asset of url to path, once it matches the sha256 checksum published
+// next to it, as <url>.sha256.
+func downloadReleaseAsset(url string, path string) error {
+	expectedChecksum, err := releaseAssetChecksum(url + ".sha256")
+	if err != nil {
+		return fmt.Errorf("failed to get checksum of: %s: %w", url, err)
+	}
+	fmt.Printf("downloading: %s\n", url)
+	response, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", response.Status)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	// the asset is written aside and renamed once verified, so neither an interrupted nor a tampered download is cached
+	tmpPath := path + ".download"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath) //nolint:errcheck
+	hasher := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(file, hasher), response.Body); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if checksum := hex.EncodeToString(hasher.Sum(nil)); checksum != expectedChecksum {
+		return fmt.Errorf("checksum mismatch of: %s, expected: %s, got: %s", url, expectedChecksum, checksum)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// releaseAssetChecksum returns the hex sha256 checksum of a checksum file, which holds the checksum optionally
+// followed by the file name, as written by sha256sum.
+func releaseAssetChecksum(url string) (string, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", response.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file")
+	}
+	checksum := strings.ToLower(fields[0])
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid sha256 checksum: %s", fields[0])
+	}
+	return checksum, nil
+}
@@ -0,0 +1,221 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+type S3SignatureStore struct {
+	accessKey string
+	secretKey string
+	bucket    string
+	prefix    string
+	region    string
+}
+
+func NewS3SignatureStore(accessKey string, secretKey string, bucket string, prefix string, region string) *S3SignatureStore {
+	p := new(S3SignatureStore)
+	p.accessKey = accessKey
+	p.secretKey = secretKey
+	p.bucket = bucket
+	p.prefix = prefix
+	p.region = region
+	return p
+}
+
+func (o *S3SignatureStore) Upload(signature string, identity string, isKeyless bool) error {
+	cfg := o.getConfig()
+
+	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
+	// Upload the file to S3.
+	_, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.prefix + identity + ".sig"),
+		Body:   strings.NewReader(signature),
+	})
+	if err != nil {
+		return err
+	}
+
+	if isKeyless {
+		certificatePath := utils.FunctionClarityHomeDir + identity + ".crt.base64"
+		f, err := os.Open(certificatePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+			Bucket: aws.String(o.bucket),
+			Key:    aws.String(o.prefix + identity + ".crt.base64"),
+			Body:   f,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\ncertificate file uploaded to, %s\n", aws.ToString(&result.Location))
+	}
+	return nil
+}
+
+func (o *S3SignatureStore) UploadFile(content string, fileName string) error {
+	cfg := o.getConfig()
+	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
+	_, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.prefix + fileName),
+		Body:   strings.NewReader(content),
+	})
+	return err
+}
+
+func (o *S3SignatureStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	cfg := o.getConfig()
+	downloader := manager.NewDownloader(s3.NewFromConfig(*cfg))
+	fileName = fileName + "." + outputType
+	bucket := o.bucket
+	filePath := o.prefix + fileName
+	if bucketPathToSignatures != "" {
+		var err error
+		signatureFullPath := bucketPathToSignatures + fileName
+		bucket, filePath, err = extractBucketAndPath(signatureFullPath)
+		if err != nil {
+			return err
+		}
+	}
+	outputFile := filepath.Join(outputDir, fileName)
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(filePath),
+	})
+
+	if err != nil {
+		var nsk *s3types.NoSuchKey
+		if errors.As(err, &nsk) || strings.Contains(err.Error(), "storage: object doesn't exist") {
+			return fmt.Errorf("%w : %v", utils.ErrSignatureNotFound, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (o *S3SignatureStore) DownloadPublicKeys(bucketPath string) (string, error) {
+	cfg := o.getConfig()
+	s3Client := s3.NewFromConfig(*cfg)
+	bucketName, folder, err := extractBucketAndPath(bucketPath)
+	if err != nil {
+		return "", err
+	}
+	resultFolderName, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+
+	folderResultFullPath := utils.FunctionClarityHomeDir + resultFolderName.String()
+	err = os.MkdirAll(folderResultFullPath, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+	listObjectsV2Response, err := s3Client.ListObjectsV2(context.TODO(),
+		&s3.ListObjectsV2Input{
+			Bucket: &bucketName,
+			Prefix: &folder,
+		})
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, item := range listObjectsV2Response.Contents {
+			if !strings.HasSuffix(*item.Key, "/") {
+				err = o.downloadFile(*item.Key, folderResultFullPath, bucketName)
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+		if listObjectsV2Response.IsTruncated {
+			listObjectsV2Response, _ = s3Client.ListObjectsV2(context.TODO(),
+				&s3.ListObjectsV2Input{
+					Bucket:            &bucketName,
+					Prefix:            &folder,
+					ContinuationToken: listObjectsV2Response.ContinuationToken,
+				})
+		} else {
+			break
+		}
+	}
+	return folderResultFullPath, nil
+}
+
+func (o *S3SignatureStore) downloadFile(filePath string, folderToSave string, bucketName string) error {
+	cfg := o.getConfig()
+	downloader := manager.NewDownloader(s3.NewFromConfig(*cfg))
+	fileName := filePath
+	outputFile := folderToSave + "/" + strings.ReplaceAll(fileName, "/", "-")
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if bucketName == "" {
+		bucketName = o.bucket
+	}
+
+	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(filePath),
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *S3SignatureStore) getConfig() *aws.Config {
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion(o.region))
+	if o.accessKey != "" && o.secretKey != "" {
+		cfg, err = config.LoadDefaultConfig(context.TODO(),
+			config.WithRegion(o.region),
+			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(o.accessKey, o.secretKey, "")))
+	}
+	if err != nil {
+		panic(fmt.Sprintf("failed loading config, %v", err))
+	}
+	return &cfg
+}
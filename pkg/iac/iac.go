@@ -0,0 +1,136 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package iac emits the function clarity stack as infrastructure as code, to be deployed by a pipeline rather than by
+// the cli. Every format is converted from the rendered CloudFormation template, so they all deploy the same resources.
+package iac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatCloudFormation = "cfn"
+	FormatTerraform      = "terraform"
+	FormatCdkJson        = "cdk-json"
+)
+
+var Formats = []string{FormatCloudFormation, FormatTerraform, FormatCdkJson}
+
+const (
+	cloudFormationFile = "function-clarity-stack.template.json"
+	terraformFile      = "function-clarity.tf.json"
+	cdkAssemblyDir     = "cdk.out"
+	cdkStackArtifact   = "FunctionClarityStack"
+	// cdkSchemaVersion is the cloud assembly schema version of the manifest
+	cdkSchemaVersion = "21.0.0"
+)
+
+// template is the part of a CloudFormation template the conversions rely on.
+type template struct {
+	Description string              `json:"Description"`
+	Resources   map[string]resource `json:"Resources"`
+}
+
+type resource struct {
+	Type       string                 `json:"Type"`
+	DependsOn  interface{}            `json:"DependsOn"`
+	Properties map[string]interface{} `json:"Properties"`
+}
+
+// Emit converts the stack template of the region to the files of the format, keyed by their relative path.
+func Emit(format string, stackTemplate string, region string, stackName string) (map[string][]byte, error) {
+	var parsed template
+	if err := json.Unmarshal([]byte(stackTemplate), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse stack template: %w", err)
+	}
+	switch format {
+	case FormatCloudFormation:
+		content, err := indentJson([]byte(stackTemplate))
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{cloudFormationFile: content}, nil
+	case FormatTerraform:
+		content, err := terraform(parsed, region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert stack template to terraform: %w", err)
+		}
+		return map[string][]byte{terraformFile: content}, nil
+	case FormatCdkJson:
+		return cdkAssembly(stackTemplate, region, stackName)
+	}
+	return nil, fmt.Errorf("unsupported format: %s, expected one of: %s", format, strings.Join(Formats, ", "))
+}
+
+// cdkAssembly returns a cloud assembly of the stack, deployed with: cdk deploy --app cdk.out.
+func cdkAssembly(stackTemplate string, region string, stackName string) (map[string][]byte, error) {
+	templateContent, err := indentJson([]byte(stackTemplate))
+	if err != nil {
+		return nil, err
+	}
+	templateFile := cdkStackArtifact + ".template.json"
+	manifest := map[string]interface{}{
+		"version": cdkSchemaVersion,
+		"artifacts": map[string]interface{}{
+			cdkStackArtifact: map[string]interface{}{
+				"type":        "aws:cloudformation:stack",
+				"environment": "aws://unknown-account/" + region,
+				"displayName": cdkStackArtifact,
+				"properties": map[string]interface{}{
+					"templateFile": templateFile,
+					"stackName":    stackName,
+				},
+			},
+		},
+	}
+	manifestContent, err := marshalJson(manifest)
+	if err != nil {
+		return nil, err
+	}
+	versionContent, err := marshalJson(map[string]string{"version": cdkSchemaVersion})
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		cdkAssemblyDir + "/manifest.json":   manifestContent,
+		cdkAssemblyDir + "/cdk.out":         versionContent,
+		cdkAssemblyDir + "/" + templateFile: templateContent,
+	}, nil
+}
+
+func indentJson(content []byte) ([]byte, error) {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, content, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to format stack template: %w", err)
+	}
+	indented.WriteString("\n")
+	return indented.Bytes(), nil
+}
+
+// marshalJson encodes the value indented, without escaping the html characters of the policies and patterns.
+func marshalJson(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
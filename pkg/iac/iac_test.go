@@ -0,0 +1,122 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iac
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	i "github.com/openclarity/functionclarity/pkg/init"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const codeKey = "function-clarity-0123456789abcdef.zip"
+
+// stackTemplates are the variants of the embedded stack template, by the name of their golden files.
+func stackTemplates(t *testing.T) map[string]string {
+	config := i.AWSInput{Bucket: "functionclarity-golden", Action: "detect", Region: "us-east-1"}
+	withTrail := config
+	withTrail.ReverifySchedule = "rate(1 day)"
+	withEventBridge := config
+	withEventBridge.Ingestion = i.IngestionEventBridge
+	variants := map[string]struct {
+		config      i.AWSInput
+		logGroupArn string
+	}{
+		"trail":       {config: withTrail},
+		"log-group":   {config: config, logGroupArn: "arn:aws:logs:us-east-1:123456789012:log-group:existing-trail-logs:*"},
+		"eventbridge": {config: withEventBridge},
+	}
+	templates := map[string]string{}
+	for name, variant := range variants {
+		stackTemplate, err := clients.StackTemplate(variant.config, "", codeKey, variant.logGroupArn)
+		if err != nil {
+			t.Fatalf("failed to render stack template: %s: %v", name, err)
+		}
+		templates[name] = stackTemplate
+	}
+	return templates
+}
+
+func TestEmitGolden(t *testing.T) {
+	for name, stackTemplate := range stackTemplates(t) {
+		for _, format := range Formats {
+			files, err := Emit(format, stackTemplate, "us-east-1", "function-clarity-stack")
+			if err != nil {
+				t.Fatalf("failed to emit %s of: %s: %v", format, name, err)
+			}
+			paths := make([]string, 0, len(files))
+			for path := range files {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				golden := filepath.Join("testdata", name, format, path+".golden")
+				if *update {
+					if err = os.MkdirAll(filepath.Dir(golden), os.ModePerm); err != nil {
+						t.Fatal(err)
+					}
+					if err = os.WriteFile(golden, files[path], 0600); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				expected, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("failed to read golden file, run the test with -update to create it: %v", err)
+				}
+				if string(expected) != string(files[path]) {
+					t.Errorf("%s differs from the golden file: %s, run the test with -update if the stack template changed", path, golden)
+				}
+			}
+		}
+	}
+}
+
+func TestTerraformUnsupportedProperty(t *testing.T) {
+	stackTemplate := `{"Resources": {"Group": {"Type": "AWS::Logs::LogGroup", "Properties": {"LogGroupName": "group", "KmsKeyId": "key"}}}}`
+	if _, err := Emit(FormatTerraform, stackTemplate, "us-east-1", "stack"); err == nil || !strings.Contains(err.Error(), "KmsKeyId") {
+		t.Fatalf("expected an unmapped property to fail the conversion, got: %v", err)
+	}
+	stackTemplate = `{"Resources": {"Queue": {"Type": "AWS::SQS::Queue", "Properties": {}}}}`
+	if _, err := Emit(FormatTerraform, stackTemplate, "us-east-1", "stack"); err == nil || !strings.Contains(err.Error(), "AWS::SQS::Queue") {
+		t.Fatalf("expected an unmapped resource type to fail the conversion, got: %v", err)
+	}
+}
+
+func TestTerraformName(t *testing.T) {
+	for id, expected := range map[string]string{
+		"FunctionClarityLambdaVerifier":                 "function_clarity_lambda_verifier",
+		"FunctionClarityCloudTrailToCloudWatchLogsRole": "function_clarity_cloud_trail_to_cloud_watch_logs_role",
+		"FunctionClarityEventTarget":                    "function_clarity_event_target",
+	} {
+		if name := terraformName(id); name != expected {
+			t.Errorf("expected terraform name: %s of: %s, got: %s", expected, id, name)
+		}
+	}
+}
+
+func TestEmitUnsupportedFormat(t *testing.T) {
+	if _, err := Emit("pulumi", `{"Resources": {}}`, "us-east-1", "stack"); err == nil {
+		t.Fatal("expected an unsupported format to fail")
+	}
+}
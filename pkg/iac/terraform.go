@@ -0,0 +1,511 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// terraformResourceTypes maps the CloudFormation resource types of the stack to the terraform resource types of the
+// aws provider, a resource type missing here fails the conversion so the template can't drift from it.
+var terraformResourceTypes = map[string]string{
+	"AWS::Lambda::Function":         "aws_lambda_function",
+	"AWS::Lambda::Permission":       "aws_lambda_permission",
+	"AWS::IAM::Role":                "aws_iam_role",
+	"AWS::Events::Rule":             "aws_cloudwatch_event_rule",
+	"AWS::Logs::LogGroup":           "aws_cloudwatch_log_group",
+	"AWS::Logs::SubscriptionFilter": "aws_cloudwatch_log_subscription_filter",
+	"AWS::S3::Bucket":               "aws_s3_bucket",
+	"AWS::S3::BucketPolicy":         "aws_s3_bucket_policy",
+	"AWS::CloudTrail::Trail":        "aws_cloudtrail",
+}
+
+// terraformRefAttributes are the attributes the Ref of a resource resolves to, by CloudFormation resource type.
+var terraformRefAttributes = map[string]string{
+	"AWS::S3::Bucket":     "id",
+	"AWS::IAM::Role":      "name",
+	"AWS::Logs::LogGroup": "name",
+}
+
+// terraformPseudoParameters are the terraform expressions of the CloudFormation pseudo parameters.
+var terraformPseudoParameters = map[string]struct {
+	expression string
+	dataType   string
+}{
+	"AWS::Region":    {"data.aws_region.current.name", "aws_region"},
+	"AWS::AccountId": {"data.aws_caller_identity.current.account_id", "aws_caller_identity"},
+}
+
+type terraformConverter struct {
+	resources map[string]resource
+	// blocks holds the converted resources by terraform resource type and name
+	blocks map[string]map[string]interface{}
+	// dataSources holds the data sources the pseudo parameters are read from
+	dataSources map[string]bool
+}
+
+// properties are the properties of a resource, every property must be taken by the conversion of its resource.
+type properties struct {
+	id     string
+	values map[string]interface{}
+	taken  map[string]bool
+}
+
+func (p *properties) take(name string) (interface{}, bool) {
+	value, exist := p.values[name]
+	p.taken[name] = true
+	return value, exist
+}
+
+func (p *properties) checkTaken() error {
+	var left []string
+	for name := range p.values {
+		if !p.taken[name] {
+			left = append(left, name)
+		}
+	}
+	if len(left) > 0 {
+		sort.Strings(left)
+		return fmt.Errorf("unsupported properties of resource: %s: %s", p.id, strings.Join(left, ", "))
+	}
+	return nil
+}
+
+func terraform(t template, region string) ([]byte, error) {
+	c := &terraformConverter{resources: t.Resources, blocks: map[string]map[string]interface{}{}, dataSources: map[string]bool{}}
+	ids := make([]string, 0, len(t.Resources))
+	for id := range t.Resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := c.convert(id, t.Resources[id]); err != nil {
+			return nil, err
+		}
+	}
+	config := map[string]interface{}{
+		"terraform": map[string]interface{}{
+			"required_providers": map[string]interface{}{
+				"aws": map[string]string{"source": "hashicorp/aws", "version": ">= 4.0"},
+			},
+		},
+		"provider": map[string]interface{}{
+			"aws": map[string]string{"region": region},
+		},
+		"resource": c.blocks,
+	}
+	if len(c.dataSources) > 0 {
+		data := map[string]interface{}{}
+		for dataType := range c.dataSources {
+			data[dataType] = map[string]interface{}{"current": map[string]interface{}{}}
+		}
+		config["data"] = data
+	}
+	return marshalJson(config)
+}
+
+func (c *terraformConverter) convert(id string, r resource) error {
+	p := &properties{id: id, values: r.Properties, taken: map[string]bool{}}
+	body := map[string]interface{}{}
+	var err error
+	switch r.Type {
+	case "AWS::Lambda::Function":
+		err = c.lambdaFunction(p, body)
+	case "AWS::Lambda::Permission":
+		err = c.mapProperties(p, body, map[string]string{"FunctionName": "function_name", "Action": "action", "Principal": "principal", "SourceArn": "source_arn"})
+	case "AWS::IAM::Role":
+		err = c.role(p, body)
+	case "AWS::Events::Rule":
+		err = c.eventRule(id, p, body)
+	case "AWS::Logs::LogGroup":
+		err = c.mapProperties(p, body, map[string]string{"LogGroupName": "name", "RetentionInDays": "retention_in_days"})
+	case "AWS::Logs::SubscriptionFilter":
+		// the name is generated by CloudFormation, but required by terraform
+		body["name"] = id
+		err = c.mapProperties(p, body, map[string]string{"DestinationArn": "destination_arn", "FilterPattern": "filter_pattern", "LogGroupName": "log_group_name"})
+	case "AWS::S3::Bucket":
+		err = c.bucket(id, p, body)
+	case "AWS::S3::BucketPolicy":
+		err = c.mapProperties(p, body, map[string]string{"Bucket": "bucket"})
+		if err == nil {
+			err = c.document(p, body, "PolicyDocument", "policy")
+		}
+	case "AWS::CloudTrail::Trail":
+		err = c.trail(p, body)
+	default:
+		return fmt.Errorf("unsupported resource type: %s of resource: %s", r.Type, id)
+	}
+	if err != nil {
+		return err
+	}
+	if err = p.checkTaken(); err != nil {
+		return err
+	}
+	dependsOn, err := c.dependsOn(r.DependsOn)
+	if err != nil {
+		return err
+	}
+	if len(dependsOn) > 0 {
+		body["depends_on"] = dependsOn
+	}
+	c.add(terraformResourceTypes[r.Type], terraformName(id), body)
+	return nil
+}
+
+func (c *terraformConverter) lambdaFunction(p *properties, body map[string]interface{}) error {
+	if code, exist := p.take("Code"); exist {
+		codeProperties, ok := code.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected code of resource: %s", p.id)
+		}
+		codeP := &properties{id: p.id + ".Code", values: codeProperties, taken: map[string]bool{}}
+		if err := c.mapProperties(codeP, body, map[string]string{"S3Bucket": "s3_bucket", "S3Key": "s3_key"}); err != nil {
+			return err
+		}
+		if err := codeP.checkTaken(); err != nil {
+			return err
+		}
+	}
+	if environment, exist := p.take("Environment"); exist {
+		variables, err := c.expression(environment.(map[string]interface{})["Variables"])
+		if err != nil {
+			return err
+		}
+		body["environment"] = []interface{}{map[string]interface{}{"variables": variables}}
+	}
+	return c.mapProperties(p, body, map[string]string{"Description": "description", "FunctionName": "function_name", "Handler": "handler",
+		"PackageType": "package_type", "MemorySize": "memory_size", "ReservedConcurrentExecutions": "reserved_concurrent_executions",
+		"Role": "role", "Runtime": "runtime", "Timeout": "timeout"})
+}
+
+func (c *terraformConverter) role(p *properties, body map[string]interface{}) error {
+	if err := c.mapProperties(p, body, map[string]string{"RoleName": "name", "Path": "path"}); err != nil {
+		return err
+	}
+	if err := c.document(p, body, "AssumeRolePolicyDocument", "assume_role_policy"); err != nil {
+		return err
+	}
+	policies, exist := p.take("Policies")
+	if !exist {
+		return nil
+	}
+	var inlinePolicies []interface{}
+	for _, policy := range policies.([]interface{}) {
+		policyProperties := &properties{id: p.id + ".Policies", values: policy.(map[string]interface{}), taken: map[string]bool{}}
+		inlinePolicy := map[string]interface{}{}
+		if err := c.mapProperties(policyProperties, inlinePolicy, map[string]string{"PolicyName": "name"}); err != nil {
+			return err
+		}
+		if err := c.document(policyProperties, inlinePolicy, "PolicyDocument", "policy"); err != nil {
+			return err
+		}
+		if err := policyProperties.checkTaken(); err != nil {
+			return err
+		}
+		inlinePolicies = append(inlinePolicies, inlinePolicy)
+	}
+	body["inline_policy"] = inlinePolicies
+	return nil
+}
+
+// eventRule converts the rule, and its targets to resources of their own as terraform defines them.
+func (c *terraformConverter) eventRule(id string, p *properties, body map[string]interface{}) error {
+	if state, exist := p.take("State"); exist && state != "ENABLED" {
+		return fmt.Errorf("unsupported state: %v of resource: %s", state, id)
+	}
+	if err := c.mapProperties(p, body, map[string]string{"Description": "description", "ScheduleExpression": "schedule_expression"}); err != nil {
+		return err
+	}
+	if err := c.document(p, body, "EventPattern", "event_pattern"); err != nil {
+		return err
+	}
+	targets, exist := p.take("Targets")
+	if !exist {
+		return nil
+	}
+	for _, target := range targets.([]interface{}) {
+		targetProperties := &properties{id: id + ".Targets", values: target.(map[string]interface{}), taken: map[string]bool{}}
+		targetBody := map[string]interface{}{"rule": fmt.Sprintf("${%s.%s.name}", terraformResourceTypes["AWS::Events::Rule"], terraformName(id))}
+		if err := c.mapProperties(targetProperties, targetBody, map[string]string{"Arn": "arn", "Id": "target_id"}); err != nil {
+			return err
+		}
+		if err := targetProperties.checkTaken(); err != nil {
+			return err
+		}
+		c.add("aws_cloudwatch_event_target", terraformName(id)+"_"+terraformName(fmt.Sprint(targetProperties.values["Id"])), targetBody)
+	}
+	return nil
+}
+
+// bucket converts the bucket, and its lifecycle configuration to a resource of its own as terraform defines it.
+func (c *terraformConverter) bucket(id string, p *properties, body map[string]interface{}) error {
+	lifecycle, exist := p.take("LifecycleConfiguration")
+	if !exist {
+		return nil
+	}
+	var rules []interface{}
+	for index, rule := range lifecycle.(map[string]interface{})["Rules"].([]interface{}) {
+		ruleProperties := &properties{id: id + ".LifecycleConfiguration", values: rule.(map[string]interface{}), taken: map[string]bool{}}
+		ruleBody := map[string]interface{}{"id": fmt.Sprintf("rule-%d", index+1), "filter": []interface{}{map[string]interface{}{}}}
+		if days, exist := ruleProperties.take("ExpirationInDays"); exist {
+			ruleBody["expiration"] = []interface{}{map[string]interface{}{"days": days}}
+		}
+		if err := c.mapProperties(ruleProperties, ruleBody, map[string]string{"Status": "status"}); err != nil {
+			return err
+		}
+		if err := ruleProperties.checkTaken(); err != nil {
+			return err
+		}
+		rules = append(rules, ruleBody)
+	}
+	c.add("aws_s3_bucket_lifecycle_configuration", terraformName(id), map[string]interface{}{
+		"bucket": fmt.Sprintf("${%s.%s.id}", terraformResourceTypes["AWS::S3::Bucket"], terraformName(id)),
+		"rule":   rules,
+	})
+	return nil
+}
+
+func (c *terraformConverter) trail(p *properties, body map[string]interface{}) error {
+	if selectors, exist := p.take("EventSelectors"); exist {
+		var eventSelectors []interface{}
+		for _, selector := range selectors.([]interface{}) {
+			selectorProperties := &properties{id: p.id + ".EventSelectors", values: selector.(map[string]interface{}), taken: map[string]bool{}}
+			selectorBody := map[string]interface{}{}
+			if err := c.mapProperties(selectorProperties, selectorBody, map[string]string{"ReadWriteType": "read_write_type"}); err != nil {
+				return err
+			}
+			if err := selectorProperties.checkTaken(); err != nil {
+				return err
+			}
+			eventSelectors = append(eventSelectors, selectorBody)
+		}
+		body["event_selector"] = eventSelectors
+	}
+	return c.mapProperties(p, body, map[string]string{"IsLogging": "enable_logging", "IsMultiRegionTrail": "is_multi_region_trail",
+		"IncludeGlobalServiceEvents": "include_global_service_events", "CloudWatchLogsLogGroupArn": "cloud_watch_logs_group_arn",
+		"CloudWatchLogsRoleArn": "cloud_watch_logs_role_arn", "S3BucketName": "s3_bucket_name", "TrailName": "name"})
+}
+
+// mapProperties converts the properties to the terraform arguments they are mapped to.
+func (c *terraformConverter) mapProperties(p *properties, body map[string]interface{}, arguments map[string]string) error {
+	for property, argument := range arguments {
+		value, exist := p.take(property)
+		if !exist {
+			continue
+		}
+		converted, err := c.expression(value)
+		if err != nil {
+			return fmt.Errorf("failed to convert property: %s of resource: %s: %w", property, p.id, err)
+		}
+		body[argument] = converted
+	}
+	return nil
+}
+
+// document converts a policy document or event pattern property to the json string terraform expects.
+func (c *terraformConverter) document(p *properties, body map[string]interface{}, property string, argument string) error {
+	value, exist := p.take(property)
+	if !exist {
+		return nil
+	}
+	converted, err := c.expression(value)
+	if err != nil {
+		return fmt.Errorf("failed to convert property: %s of resource: %s: %w", property, p.id, err)
+	}
+	content, err := marshalJson(converted)
+	if err != nil {
+		return err
+	}
+	var compact bytes.Buffer
+	if err = json.Compact(&compact, content); err != nil {
+		return err
+	}
+	body[argument] = compact.String()
+	return nil
+}
+
+// expression converts a property value, resolving the intrinsic functions to terraform interpolations.
+func (c *terraformConverter) expression(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return escapeTerraform(v), nil
+	case []interface{}:
+		converted := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			convertedItem, err := c.expression(item)
+			if err != nil {
+				return nil, err
+			}
+			converted = append(converted, convertedItem)
+		}
+		return converted, nil
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for function, argument := range v {
+				if strings.HasPrefix(function, "Fn::") || function == "Ref" {
+					return c.intrinsic(function, argument)
+				}
+			}
+		}
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			convertedItem, err := c.expression(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[key] = convertedItem
+		}
+		return converted, nil
+	}
+	return value, nil
+}
+
+func (c *terraformConverter) intrinsic(function string, argument interface{}) (interface{}, error) {
+	switch function {
+	case "Ref":
+		reference, err := c.reference(fmt.Sprint(argument), "")
+		if err != nil {
+			return nil, err
+		}
+		return "${" + reference + "}", nil
+	case "Fn::GetAtt":
+		names, ok := argument.([]interface{})
+		if !ok || len(names) != 2 || names[1] != "Arn" {
+			return nil, fmt.Errorf("unsupported Fn::GetAtt: %v, expected the Arn of a resource", argument)
+		}
+		reference, err := c.reference(fmt.Sprint(names[0]), "arn")
+		if err != nil {
+			return nil, err
+		}
+		// the Arn of a log group includes the log streams in CloudFormation, but not in terraform
+		if c.resources[fmt.Sprint(names[0])].Type == "AWS::Logs::LogGroup" {
+			return "${" + reference + "}:*", nil
+		}
+		return "${" + reference + "}", nil
+	case "Fn::Sub":
+		return c.sub(fmt.Sprint(argument))
+	}
+	return nil, fmt.Errorf("unsupported intrinsic function: %s", function)
+}
+
+// sub converts the variables of a Fn::Sub string, the pseudo parameters and the references to the resources.
+func (c *terraformConverter) sub(s string) (string, error) {
+	var converted strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			converted.WriteString(escapeTerraform(s))
+			return converted.String(), nil
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable in Fn::Sub: %s", s)
+		}
+		converted.WriteString(escapeTerraform(s[:start]))
+		name := s[start+2 : start+end]
+		if pseudo, exist := terraformPseudoParameters[name]; exist {
+			c.dataSources[pseudo.dataType] = true
+			converted.WriteString("${" + pseudo.expression + "}")
+		} else {
+			reference, err := c.reference(name, "")
+			if err != nil {
+				return "", err
+			}
+			converted.WriteString("${" + reference + "}")
+		}
+		s = s[start+end+1:]
+	}
+}
+
+// reference returns the terraform reference of the attribute of a resource, or of the attribute its Ref resolves to.
+func (c *terraformConverter) reference(id string, attribute string) (string, error) {
+	r, exist := c.resources[id]
+	if !exist {
+		return "", fmt.Errorf("unknown resource: %s", id)
+	}
+	resourceType, exist := terraformResourceTypes[r.Type]
+	if !exist {
+		return "", fmt.Errorf("unsupported resource type: %s of resource: %s", r.Type, id)
+	}
+	if attribute == "" {
+		if attribute, exist = terraformRefAttributes[r.Type]; !exist {
+			return "", fmt.Errorf("unsupported Ref of resource: %s", id)
+		}
+	}
+	return resourceType + "." + terraformName(id) + "." + attribute, nil
+}
+
+func (c *terraformConverter) dependsOn(dependsOn interface{}) ([]string, error) {
+	var ids []string
+	switch d := dependsOn.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		ids = []string{d}
+	case []interface{}:
+		for _, id := range d {
+			ids = append(ids, fmt.Sprint(id))
+		}
+	default:
+		return nil, fmt.Errorf("unexpected DependsOn: %v", dependsOn)
+	}
+	var references []string
+	for _, id := range ids {
+		r, exist := c.resources[id]
+		if !exist {
+			return nil, fmt.Errorf("unknown resource: %s", id)
+		}
+		references = append(references, terraformResourceTypes[r.Type]+"."+terraformName(id))
+	}
+	return references, nil
+}
+
+func (c *terraformConverter) add(resourceType string, name string, body map[string]interface{}) {
+	if c.blocks[resourceType] == nil {
+		c.blocks[resourceType] = map[string]interface{}{}
+	}
+	c.blocks[resourceType][name] = body
+}
+
+// terraformName converts a CloudFormation logical id to a terraform resource name, i.e. FunctionClarityLogGroup to
+// function_clarity_log_group.
+func terraformName(id string) string {
+	var name strings.Builder
+	runes := []rune(id)
+	for index, r := range runes {
+		if unicode.IsUpper(r) {
+			if index > 0 && (unicode.IsLower(runes[index-1]) || (index+1 < len(runes) && unicode.IsLower(runes[index+1]))) {
+				name.WriteRune('_')
+			}
+			name.WriteRune(unicode.ToLower(r))
+		} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			name.WriteRune(r)
+		} else {
+			name.WriteRune('_')
+		}
+	}
+	return name.String()
+}
+
+// escapeTerraform escapes the template sequences of a literal string, so terraform doesn't interpolate them.
+func escapeTerraform(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
@@ -15,6 +15,19 @@
 
 package init
 
+import (
+	"fmt"
+	"sort"
+)
+
+// The ways the verifier receives the function events.
+const (
+	// IngestionCloudTrailLogs subscribes the verifier to the CloudWatch Logs group of a CloudTrail trail
+	IngestionCloudTrailLogs = "cloudtrail-logs"
+	// IngestionEventBridge invokes the verifier by an EventBridge rule on the lambda API calls
+	IngestionEventBridge = "eventbridge"
+)
+
 type AWSInput struct {
 	AccessKey              string
 	SecretKey              string
@@ -29,6 +42,69 @@ type AWSInput struct {
 	IncludedFuncTagKeys    []string
 	IncludedFuncRegions    []string
 	BucketPathToPublicKeys string
+	SignatureStore         string
+	Policy                 string
+	// ReverifySchedule is the EventBridge schedule expression of re-verifying all the functions, e.g. rate(1 day),
+	// leave empty to verify on function events only
+	ReverifySchedule string
+	// Ingestion is one of the Ingestion* values, an empty value is IngestionCloudTrailLogs
+	Ingestion string
+	// MemberRoleName is the role assumed in the other accounts of the organization to verify their functions, leave
+	// empty to verify the functions of the deployment account only
+	MemberRoleName string
+	// AccountRoles maps account ids to the ARN of the role to assume in them, overriding MemberRoleName
+	AccountRoles map[string]string
+	// TrustedPrincipals are the ARNs of the users or roles, besides the verifier function, trusted by the role of the
+	// member accounts, i.e. the principals running the verify and scan commands on the other accounts
+	TrustedPrincipals []string
+	// ReverifyAccounts are the other accounts of the organization whose functions are re-verified on schedule, along
+	// with the accounts of AccountRoles
+	ReverifyAccounts []string
+}
+
+// ValidateIngestion makes sure the ingestion is one of the Ingestion* values, or empty.
+func (a *AWSInput) ValidateIngestion() error {
+	if a.Ingestion != "" && a.Ingestion != IngestionCloudTrailLogs && a.Ingestion != IngestionEventBridge {
+		return fmt.Errorf("unsupported event ingestion: %s, expected %s or %s", a.Ingestion, IngestionCloudTrailLogs, IngestionEventBridge)
+	}
+	return nil
+}
+
+// UsesEventBridge tells if the verifier is invoked by an EventBridge rule, and not by the logs of a trail.
+func (a *AWSInput) UsesEventBridge() bool {
+	return a.Ingestion == IngestionEventBridge
+}
+
+// RoleArnForAccount returns the ARN of the role to assume to verify the functions of the account, or an empty string
+// when the functions of the account are verified with the credentials of the verifier.
+func (a *AWSInput) RoleArnForAccount(accountId string, verifierAccountId string) string {
+	if accountId == "" || accountId == verifierAccountId {
+		return ""
+	}
+	if roleArn, exist := a.AccountRoles[accountId]; exist {
+		return roleArn
+	}
+	if a.MemberRoleName == "" {
+		return ""
+	}
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, a.MemberRoleName)
+}
+
+// ScheduledAccounts returns the other accounts whose functions are re-verified on schedule, besides the account of
+// the verifier.
+func (a *AWSInput) ScheduledAccounts() []string {
+	accounts := append([]string{}, a.ReverifyAccounts...)
+	for accountId := range a.AccountRoles {
+		accounts = append(accounts, accountId)
+	}
+	sort.Strings(accounts)
+	var unique []string
+	for _, accountId := range accounts {
+		if accountId != "" && (len(unique) == 0 || unique[len(unique)-1] != accountId) {
+			unique = append(unique, accountId)
+		}
+	}
+	return unique
 }
 
 type CloudTrail struct {
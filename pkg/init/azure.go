@@ -0,0 +1,39 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+type AzureInput struct {
+	SubscriptionId         string
+	TenantId               string
+	ClientId               string
+	ClientSecret           string
+	StorageAccount         string
+	StorageKey             string
+	Container              string
+	Action                 string
+	BlockMode              string
+	PublicKey              string
+	PrivateKey             string
+	IsKeyless              bool
+	EventGridTopicEndpoint string
+	EventGridKey           string
+	IncludedFuncTagKeys    []string
+	IncludedFuncRegions    []string
+	BucketPathToPublicKeys string
+	SignatureStore         string
+	ManagementEndpoint     string
+	StorageEndpoint        string
+}
@@ -0,0 +1,45 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+import "fmt"
+
+type GCPInput struct {
+	Project                string
+	Location               string
+	Bucket                 string
+	Action                 string
+	PublicKey              string
+	PrivateKey             string
+	IsKeyless              bool
+	PubSubTopic            string
+	IncludedFuncTagKeys    []string
+	IncludedFuncRegions    []string
+	BucketPathToPublicKeys string
+	SignatureStore         string
+	Policy                 string
+	// ServiceAccount is the service account the verifier function runs as
+	ServiceAccount string
+}
+
+// VerifierServiceAccount returns the service account the verifier function runs as, the App Engine default service
+// account of the project unless set.
+func (g *GCPInput) VerifierServiceAccount() string {
+	if g.ServiceAccount != "" {
+		return g.ServiceAccount
+	}
+	return fmt.Sprintf("%s@appspot.gserviceaccount.com", g.Project)
+}
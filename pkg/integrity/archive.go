@@ -0,0 +1,193 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveRoot is a placeholder destination, used to resolve archive entries the way they are resolved on extraction.
+const archiveRoot = "archive-root"
+
+func isArchive(path string) bool {
+	lowerPath := strings.ToLower(path)
+	if !strings.HasSuffix(lowerPath, ".zip") && !strings.HasSuffix(lowerPath, ".tar.gz") && !strings.HasSuffix(lowerPath, ".tgz") {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// hashArchiveFiles hashes the files of a zip or tar.gz archive, with the same names and relative paths they get
+// when the archive is extracted to a folder (see utils.ExtractZip), so the identity of an archive equals the
+// identity of the deployed package. Each file is streamed from the archive into the hash function, nothing is
+// extracted to disk or kept in memory.
+func hashArchiveFiles(archivePath string, workers int, hashFunc func(codeFile) (string, error)) ([]codeFile, []string, *IgnoreRules, error) {
+	var files []codeFile
+	var hashes []string
+	var ignoreRules *IgnoreRules
+	var err error
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		files, hashes, ignoreRules, err = hashZipFiles(archivePath, workers, hashFunc)
+	} else {
+		files, hashes, ignoreRules, err = hashTarGzFiles(archivePath, hashFunc)
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read archive: %s: %w", archivePath, err)
+	}
+	return files, hashes, ignoreRules, nil
+}
+
+func hashZipFiles(archivePath string, workers int, hashFunc func(codeFile) (string, error)) ([]codeFile, []string, *IgnoreRules, error) {
+	archive, err := zip.OpenReader(filepath.Clean(archivePath))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer archive.Close()
+	entries := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name, err := archiveEntryName(f.Name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// like on extraction, a repeated entry overrides the previous one
+		entries[name] = f
+	}
+
+	ignoreRules := &IgnoreRules{}
+	if f, ok := entries[IgnoreFileName]; ok {
+		if ignoreRules, err = readIgnoreRules(f.Open); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	var files []codeFile
+	for name, f := range entries {
+		relPath := filepath.ToSlash(name)
+		if ignoreRules.Ignored(relPath) {
+			continue
+		}
+		files = append(files, codeFile{path: filepath.Join(archivePath, name), name: name, relPath: relPath, open: f.Open})
+	}
+	hashes, err := hashFiles(files, workers, hashFunc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return files, hashes, ignoreRules, nil
+}
+
+// hashTarGzFiles hashes the files in a single pass over the archive, in the order they are stored. The ignore file
+// may come after the files it excludes, so every file is hashed and the excluded ones are dropped at the end.
+func hashTarGzFiles(archivePath string, hashFunc func(codeFile) (string, error)) ([]codeFile, []string, *IgnoreRules, error) {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer f.Close()
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer gzipReader.Close()
+	entries := make(map[string]codeFile)
+	entryHashes := make(map[string]string)
+	ignoreRules := &IgnoreRules{}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, nil, nil, fmt.Errorf("unsupported entry type of file: %s", header.Name)
+		}
+		name, err := archiveEntryName(header.Name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		file := codeFile{path: filepath.Join(archivePath, name), name: name, relPath: filepath.ToSlash(name), open: func() (io.ReadCloser, error) {
+			return io.NopCloser(tarReader), nil
+		}}
+		if name == IgnoreFileName {
+			// the ignore file is small, it is read once for its rules and once more for its hash
+			content, err := io.ReadAll(tarReader)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+			}
+			if ignoreRules, err = ParseIgnoreRules(string(content)); err != nil {
+				return nil, nil, nil, err
+			}
+			file.open = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(content)), nil
+			}
+		}
+		hash, err := hashFunc(file)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read file: %s: %w", header.Name, err)
+		}
+		// like on extraction, a repeated entry overrides the previous one
+		entries[name], entryHashes[name] = file, hash
+	}
+
+	var files []codeFile
+	var hashes []string
+	for name, file := range entries {
+		if ignoreRules.Ignored(file.relPath) {
+			continue
+		}
+		files = append(files, file)
+		hashes = append(hashes, entryHashes[name])
+	}
+	return files, hashes, ignoreRules, nil
+}
+
+func archiveEntryName(entryName string) (string, error) {
+	filePath := filepath.Join(archiveRoot, entryName)
+	if !strings.HasPrefix(filePath, archiveRoot+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid file path: %s", entryName)
+	}
+	return strings.TrimPrefix(filePath, archiveRoot+string(os.PathSeparator)), nil
+}
+
+func readIgnoreRules(open func() (io.ReadCloser, error)) (*IgnoreRules, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return ParseIgnoreRules(string(content))
+}
@@ -0,0 +1,174 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/openclarity/functionclarity/pkg/utils"
+)
+
+const pathToArchiveSourceCode = "../../test_utils/source_for_testing/code_for_testing"
+
+func TestArchiveIdentityParity(t *testing.T) {
+	tmp := t.TempDir()
+	zipPath := filepath.Join(tmp, "code.zip")
+	writeArchive(t, zipPath, func(name string, dir bool, content []byte, w interface{}) error {
+		zipWriter := w.(*zip.Writer)
+		if dir {
+			_, err := zipWriter.Create(name + "/")
+			return err
+		}
+		f, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	})
+	tarGzPath := filepath.Join(tmp, "code.tar.gz")
+	writeArchive(t, tarGzPath, func(name string, dir bool, content []byte, w interface{}) error {
+		tarWriter := w.(*tar.Writer)
+		if dir {
+			return tarWriter.WriteHeader(&tar.Header{Name: name + "/", Typeflag: tar.TypeDir, Mode: 0755})
+		}
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		_, err := tarWriter.Write(content)
+		return err
+	})
+
+	// the verifier extracts the deployed package to a uniquely named folder
+	extractedPath := filepath.Join(tmp, uuid.New().String())
+	if err := utils.ExtractZip(zipPath, extractedPath); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range IdentityVersions() {
+		identityGenerator, err := GetIdentityGenerator(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		extractedIdentity, err := identityGenerator.GenerateIdentity(extractedPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, archivePath := range []string{zipPath, tarGzPath} {
+			archiveIdentity, err := identityGenerator.GenerateIdentity(archivePath)
+			if err != nil {
+				t.Fatalf("Failed to generate identity for archive: %s: %v", archivePath, err)
+			}
+			if archiveIdentity != extractedIdentity {
+				t.Fatalf("Error. identity version: %s, archive: %s, expected: %s, got: %s", version, archivePath, extractedIdentity, archiveIdentity)
+			}
+		}
+	}
+
+	extractedManifest, err := GenerateManifest(extractedPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, archivePath := range []string{zipPath, tarGzPath} {
+		archiveManifest, err := GenerateManifest(archivePath, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(extractedManifest, archiveManifest) || len(archiveManifest.Ignore) != 1 {
+			t.Fatalf("Error. archive: %s, expected manifest: %+v, got: %+v", archivePath, extractedManifest, archiveManifest)
+		}
+	}
+}
+
+func TestArchiveInvalidPath(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "code.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipWriter := zip.NewWriter(f)
+	if _, err = zipWriter.Create("../outside.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err = zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err = new(Sha256).GenerateIdentity(zipPath); err == nil {
+		t.Fatal("Error. expected an invalid file path error")
+	}
+}
+
+// writeArchive writes the source code folder, with an ignore file and ignored files, to a zip or tar.gz archive
+func writeArchive(t *testing.T, archivePath string, addEntry func(name string, dir bool, content []byte, w interface{}) error) {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w interface{}
+	var closers []io.Closer
+	if filepath.Ext(archivePath) == ".zip" {
+		zipWriter := zip.NewWriter(f)
+		w, closers = zipWriter, []io.Closer{zipWriter}
+	} else {
+		gzipWriter := gzip.NewWriter(f)
+		tarWriter := tar.NewWriter(gzipWriter)
+		w, closers = tarWriter, []io.Closer{tarWriter, gzipWriter}
+	}
+	err = filepath.WalkDir(pathToArchiveSourceCode, func(path string, d os.DirEntry, err error) error {
+		if err != nil || path == pathToArchiveSourceCode {
+			return err
+		}
+		name, err := filepath.Rel(pathToArchiveSourceCode, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return addEntry(filepath.ToSlash(name), true, nil, w)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return addEntry(filepath.ToSlash(name), false, content, w)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a tar.gz archive is read in order, the ignored files come both before and after the ignore file
+	if err = addEntry("main.go.swp", false, []byte("backup"), w); err != nil {
+		t.Fatal(err)
+	}
+	if err = addEntry(IgnoreFileName, false, []byte("*.swp\n"), w); err != nil {
+		t.Fatal(err)
+	}
+	if err = addEntry("cmd/sign.go.swp", false, []byte("backup"), w); err != nil {
+		t.Fatal(err)
+	}
+	for _, closer := range closers {
+		if err = closer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
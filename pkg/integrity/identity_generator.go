@@ -17,50 +17,154 @@ package integrity
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+
+	"golang.org/x/sync/errgroup"
 )
 
 type IdentityGenerator interface {
 	GenerateIdentity(path string) (string, error)
 }
 
-type Sha256 struct{}
+type Sha256 struct {
+	// Workers is the maximal number of files hashed in parallel, defaults to the number of CPUs.
+	Workers int
+}
+
+type codeFile struct {
+	path string
+	// name is the file path relative to the code root, as computed by the legacy identity
+	name string
+	// relPath is the normalized (slash separated) file path relative to the code root
+	relPath string
+	// open reads the file content, files of archives are read from the archive instead of the path
+	open func() (io.ReadCloser, error)
+}
+
+func (f codeFile) Open() (io.ReadCloser, error) {
+	if f.open != nil {
+		return f.open()
+	}
+	return os.Open(filepath.Clean(f.path))
+}
 
 func (o *Sha256) GenerateIdentity(path string) (string, error) {
-	var identities []string
+	_, identities, _, err := hashCodeFiles(path, o.Workers, fileIdentity)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(identities)
+	joinedShaString := strings.Join(identities[:], ",")
+	identitiesSha256 := sha256.Sum256([]byte(joinedShaString))
+	return fmt.Sprintf("%x", identitiesSha256), nil
+}
+
+func hashFiles(files []codeFile, workers int, hashFunc func(codeFile) (string, error)) ([]string, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	hashes := make([]string, len(files))
+	g := new(errgroup.Group)
+	g.SetLimit(workers)
+	for index := range files {
+		index := index
+		g.Go(func() error {
+			hash, err := hashFunc(files[index])
+			if err != nil {
+				return err
+			}
+			hashes[index] = hash
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// hashCodeFiles hashes the files that are part of the code identity with the hash function, and returns them with
+// their hashes. The root is either a code folder or a zip or tar.gz archive, read as if it was extracted to a folder.
+func hashCodeFiles(root string, workers int, hashFunc func(codeFile) (string, error)) ([]codeFile, []string, *IgnoreRules, error) {
+	if isArchive(root) {
+		return hashArchiveFiles(root, workers, hashFunc)
+	}
+	files, ignoreRules, err := listCodeFiles(root)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	hashes, err := hashFiles(files, workers, hashFunc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return files, hashes, ignoreRules, nil
+}
+
+// listCodeFiles lists the files of the code folder that are part of the code identity, files excluded by the
+// ignore file are skipped.
+func listCodeFiles(root string) ([]codeFile, *IgnoreRules, error) {
+	ignoreRules, err := LoadIgnoreRules(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []codeFile
 	rootFolderName := ""
-	err := filepath.WalkDir(path,
+	err = filepath.WalkDir(root,
 		func(path string, d os.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
 			if !d.IsDir() {
-				data, err := os.ReadFile(path)
-				if err != nil {
-					return err
+				name := d.Name()
+				relPath := d.Name()
+				if rootFolderName != "" {
+					name = path[strings.Index(path, rootFolderName)+len(rootFolderName)+1:]
+					if relPath, err = filepath.Rel(root, path); err != nil {
+						return err
+					}
 				}
-				dataString := fmt.Sprintf("%x", data)
-				if rootFolderName == "" {
-					dataString = dataString + d.Name()
-				} else {
-					dataString = dataString + path[strings.Index(path, rootFolderName)+len(rootFolderName)+1:]
+				relPath = filepath.ToSlash(relPath)
+				if ignoreRules.Ignored(relPath) {
+					return nil
 				}
-				sha := sha256.Sum256([]byte(dataString))
-				identities = append(identities, fmt.Sprintf("%x", sha))
+				files = append(files, codeFile{path: path, name: name, relPath: relPath})
 			} else if rootFolderName == "" {
 				rootFolderName = d.Name()
+			} else {
+				relPath, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				if ignoreRules.IgnoredDir(filepath.ToSlash(relPath)) {
+					return filepath.SkipDir
+				}
 			}
 			return nil
 		})
+	return files, ignoreRules, err
+}
+
+// fileIdentity streams the file through the hasher. The hex encoding of the content is hashed rather than the
+// raw content, to keep the identities of existing signatures.
+func fileIdentity(file codeFile) (string, error) {
+	f, err := file.Open()
 	if err != nil {
 		return "", err
 	}
-	sort.Strings(identities)
-	joinedShaString := strings.Join(identities[:], ",")
-	identitiesSha256 := sha256.Sum256([]byte(joinedShaString))
-	return fmt.Sprintf("%x", identitiesSha256), nil
+	defer f.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hex.NewEncoder(hash), f); err != nil {
+		return "", err
+	}
+	if _, err = io.WriteString(hash, file.name); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
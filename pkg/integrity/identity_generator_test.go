@@ -16,6 +16,12 @@
 package integrity
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -58,3 +64,71 @@ func TestGenerateIdentityUniqueness(t *testing.T) {
 		t.Fatalf("Error. The generated identities should be diffrent")
 	}
 }
+
+func TestGenerateIdentityCompatibility(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "code")
+	if err := os.MkdirAll(filepath.Join(root, "nested", "deeper"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	large := make([]byte, 3*1024*1024+7)
+	for i := range large {
+		large[i] = byte(i % 251)
+	}
+	files := map[string][]byte{
+		"main.go":                    []byte("package main"),
+		"empty":                      {},
+		filepath.Join("nested", "a"): []byte("a"),
+		filepath.Join("nested", "deeper", "model.bin"): large,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{0, 1, 3} {
+		integrityCalculator := Sha256{Workers: workers}
+		identity, err := integrityCalculator.GenerateIdentity(root)
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", root)
+		}
+		if expected := legacyIdentity(t, root); identity != expected {
+			t.Fatalf("Error. identity: %s differs from the identity of the previous implementation: %s", identity, expected)
+		}
+	}
+}
+
+// legacyIdentity is the original in memory implementation, kept to make sure existing signatures stay valid.
+func legacyIdentity(t *testing.T, path string) string {
+	var identities []string
+	rootFolderName := ""
+	err := filepath.WalkDir(path,
+		func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				dataString := fmt.Sprintf("%x", data)
+				if rootFolderName == "" {
+					dataString = dataString + d.Name()
+				} else {
+					dataString = dataString + path[strings.Index(path, rootFolderName)+len(rootFolderName)+1:]
+				}
+				sha := sha256.Sum256([]byte(dataString))
+				identities = append(identities, fmt.Sprintf("%x", sha))
+			} else if rootFolderName == "" {
+				rootFolderName = d.Name()
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(identities)
+	identitiesSha256 := sha256.Sum256([]byte(strings.Join(identities, ",")))
+	return fmt.Sprintf("%x", identitiesSha256)
+}
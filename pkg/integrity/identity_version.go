@@ -0,0 +1,130 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IdentityVersionLegacy identities are bare hex digests, created before identities were versioned.
+const IdentityVersionLegacy = "fc-v1"
+
+const IdentityVersionV2Sha256 = "fc-v2:sha256"
+
+const IdentityVersionV2Sha512 = "fc-v2:sha512"
+
+const DefaultIdentityVersion = IdentityVersionLegacy
+
+const identityVersionPrefix = "fc-"
+
+var (
+	identityGeneratorsLock sync.RWMutex
+	identityGenerators     = map[string]IdentityGenerator{}
+	// identityVersions holds the registered versions, the most recently registered first
+	identityVersions []string
+)
+
+func init() {
+	RegisterIdentityGenerator(IdentityVersionLegacy, &Sha256{})
+	RegisterIdentityGenerator(IdentityVersionV2Sha256, &V2{Algorithm: "sha256", NewHash: sha256.New})
+	RegisterIdentityGenerator(IdentityVersionV2Sha512, &V2{Algorithm: "sha512", NewHash: sha512.New})
+}
+
+func RegisterIdentityGenerator(version string, generator IdentityGenerator) {
+	identityGeneratorsLock.Lock()
+	defer identityGeneratorsLock.Unlock()
+	if _, exist := identityGenerators[version]; !exist {
+		identityVersions = append([]string{version}, identityVersions...)
+	}
+	identityGenerators[version] = generator
+}
+
+func GetIdentityGenerator(version string) (IdentityGenerator, error) {
+	if version == "" {
+		version = DefaultIdentityVersion
+	}
+	identityGeneratorsLock.RLock()
+	defer identityGeneratorsLock.RUnlock()
+	generator, exist := identityGenerators[version]
+	if !exist {
+		return nil, fmt.Errorf("unsupported identity version: %s", version)
+	}
+	return generator, nil
+}
+
+// IdentityVersions returns the registered identity versions, newest first.
+func IdentityVersions() []string {
+	identityGeneratorsLock.RLock()
+	defer identityGeneratorsLock.RUnlock()
+	return append([]string{}, identityVersions...)
+}
+
+// ParseIdentityVersion returns the version an identity was generated with.
+func ParseIdentityVersion(identity string) string {
+	if !strings.HasPrefix(identity, identityVersionPrefix) {
+		return IdentityVersionLegacy
+	}
+	return identity[:strings.LastIndex(identity, ":")]
+}
+
+// V2 identities are built from the raw content digest of each file and its normalized path relative to the
+// code root, formatted as fc-v2:<algorithm>:<digest>.
+type V2 struct {
+	Algorithm string
+	NewHash   func() hash.Hash
+	// Workers is the maximal number of files hashed in parallel, defaults to the number of CPUs.
+	Workers int
+}
+
+func (o *V2) GenerateIdentity(path string) (string, error) {
+	_, entries, _, err := hashCodeFiles(path, o.Workers, func(file codeFile) (string, error) {
+		digest, err := o.fileDigest(file)
+		if err != nil {
+			return "", err
+		}
+		return file.relPath + "\x00" + digest, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(entries)
+	identityHash := o.NewHash()
+	if _, err = io.WriteString(identityHash, strings.Join(entries, "\n")); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fc-v2:%s:%x", o.Algorithm, identityHash.Sum(nil)), nil
+}
+
+func (o *V2) fileDigest(file codeFile) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fileHash := o.NewHash()
+	if _, err = io.Copy(fileHash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fileHash.Sum(nil)), nil
+}
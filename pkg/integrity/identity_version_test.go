@@ -0,0 +1,67 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersionedIdentities(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+	const pathToIdenticalSourceCode = "../../test_utils/identical_source_for_testing/code_for_testing"
+	const pathToChangedSourceCode = "../../test_utils/changed_code_for_testing/"
+
+	for _, version := range IdentityVersions() {
+		identityGenerator, err := GetIdentityGenerator(version)
+		if err != nil {
+			t.Fatalf("Failed to get identity generator for version: %s", version)
+		}
+		identity, err := identityGenerator.GenerateIdentity(pathToSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", pathToSourceCode)
+		}
+		if ParseIdentityVersion(identity) != version {
+			t.Fatalf("Error. identity: %s should be parsed as version: %s", identity, version)
+		}
+		if version != IdentityVersionLegacy && !strings.HasPrefix(identity, version+":") {
+			t.Fatalf("Error. identity: %s should be prefixed with its version: %s", identity, version)
+		}
+		identicalIdentity, err := identityGenerator.GenerateIdentity(pathToIdenticalSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", pathToIdenticalSourceCode)
+		}
+		if identity != identicalIdentity {
+			t.Fatalf("Error. The generated %s identities aren't consistent", version)
+		}
+		changedIdentity, err := identityGenerator.GenerateIdentity(pathToChangedSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate code identity for code in: %s", pathToChangedSourceCode)
+		}
+		if identity == changedIdentity {
+			t.Fatalf("Error. The generated %s identities should be diffrent", version)
+		}
+	}
+}
+
+func TestUnknownIdentityVersion(t *testing.T) {
+	if _, err := GetIdentityGenerator("fc-v0:md5"); err == nil {
+		t.Fatalf("Error. unknown identity version should not be supported")
+	}
+	if identityGenerator, err := GetIdentityGenerator(""); err != nil || identityGenerator == nil {
+		t.Fatalf("Error. empty identity version should resolve to the default version")
+	}
+}
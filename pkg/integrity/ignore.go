@@ -0,0 +1,184 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is a gitignore style file at the code root, listing files to exclude from the code identity.
+// The file itself is always part of the identity, so the ignore rules can't be changed without changing the identity.
+const IgnoreFileName = ".fcignore"
+
+type IgnoreRules struct {
+	// Patterns are the effective patterns, in the order they appear in the ignore file
+	Patterns []string
+	rules    []ignoreRule
+}
+
+type ignoreRule struct {
+	negate  bool
+	dirOnly bool
+	regex   *regexp.Regexp
+}
+
+// LoadIgnoreRules reads the ignore file at the code root, no rules are returned if the file doesn't exist.
+func LoadIgnoreRules(root string) (*IgnoreRules, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return &IgnoreRules{}, nil
+	}
+	content, err := os.ReadFile(filepath.Join(root, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &IgnoreRules{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return ParseIgnoreRules(string(content))
+}
+
+func ParseIgnoreRules(content string) (*IgnoreRules, error) {
+	ignoreRules := &IgnoreRules{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		pattern := strings.TrimRight(strings.TrimSuffix(scanner.Text(), "\r"), " \t")
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		rule, err := compileIgnorePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern: %s: %w", IgnoreFileName, pattern, err)
+		}
+		ignoreRules.Patterns = append(ignoreRules.Patterns, pattern)
+		ignoreRules.rules = append(ignoreRules.rules, rule)
+	}
+	return ignoreRules, scanner.Err()
+}
+
+func (r *IgnoreRules) IsEmpty() bool {
+	return len(r.rules) == 0
+}
+
+// Ignored reports whether the file, given by its slash separated path relative to the code root, is excluded.
+// Like in git, a file in an excluded directory can't be included back.
+func (r *IgnoreRules) Ignored(relPath string) bool {
+	if r.IsEmpty() || relPath == IgnoreFileName {
+		return false
+	}
+	segments := strings.Split(relPath, "/")
+	for i := 1; i < len(segments); i++ {
+		if r.match(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	return r.match(relPath, false)
+}
+
+// IgnoredDir reports whether the directory, given by its slash separated path relative to the code root, is excluded.
+func (r *IgnoreRules) IgnoredDir(relPath string) bool {
+	return !r.IsEmpty() && r.match(relPath, true)
+}
+
+func (r *IgnoreRules) match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.regex.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func compileIgnorePattern(pattern string) (ignoreRule, error) {
+	rule := ignoreRule{}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	// a pattern with a separator is relative to the code root, otherwise it matches at any level
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return rule, fmt.Errorf("empty pattern")
+	}
+	expression := globToRegex(pattern)
+	if !anchored {
+		expression = "(.*/)?" + expression
+	}
+	regex, err := regexp.Compile("^" + expression + "$")
+	if err != nil {
+		return rule, err
+	}
+	rule.regex = regex
+	return rule, nil
+}
+
+func globToRegex(pattern string) string {
+	var expression strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expression.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case c == '*':
+			expression.WriteString("[^/]*")
+		case c == '?':
+			expression.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expression.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expression.String()
+}
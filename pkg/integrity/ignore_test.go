@@ -0,0 +1,90 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	rules, err := ParseIgnoreRules("# comment\n*.swp\n.git/\n/build\ndocs/**/*.md\n!docs/keep.md\nlogs/\n!logs/app.log\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"main.go":                false,
+		"main.go.swp":            true,
+		"pkg/util.go.swp":        true,
+		".git/HEAD":              true,
+		"pkg/.git/config":        true,
+		"build/out.bin":          true,
+		"pkg/build/out.bin":      false,
+		"docs/readme.md":         true,
+		"docs/api/v1/readme.md":  true,
+		"docs/keep.md":           false,
+		"logs/app.log":           true,
+		IgnoreFileName:           false,
+		"pkg/" + IgnoreFileName:  false,
+		"docs/api/v1/readme.txt": false,
+	}
+	for path, expected := range tests {
+		if ignored := rules.Ignored(path); ignored != expected {
+			t.Errorf("Error. path: %s, expected ignored: %v, got: %v", path, expected, ignored)
+		}
+	}
+}
+
+func TestIgnoreRulesChangeIdentity(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name string, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("main.go", "package main")
+	writeFile(IgnoreFileName, "*.swp\n.cache/\n")
+	generator, err := GetIdentityGenerator(IdentityVersionV2Sha256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile("main.go.swp", "backup")
+	writeFile(".cache/entry", "cached")
+	if identityWithIgnoredFiles, err := generator.GenerateIdentity(root); err != nil || identityWithIgnoredFiles != identity {
+		t.Fatalf("Error. ignored files must not change the identity: %v", err)
+	}
+	manifest, err := GenerateManifest(root, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 2 || len(manifest.Ignore) != 2 {
+		t.Fatalf("Error. expected manifest with 2 files and 2 ignore patterns, got: %+v", manifest)
+	}
+
+	writeFile(IgnoreFileName, "*.swp\n.cache/\n*.go\n")
+	if identityWithChangedRules, err := generator.GenerateIdentity(root); err != nil || identityWithChangedRules == identity {
+		t.Fatalf("Error. changing the ignore rules must change the identity: %v", err)
+	}
+}
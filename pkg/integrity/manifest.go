@@ -0,0 +1,160 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const ManifestSuffix = ".manifest"
+
+// functionManifestSuffix tells the name of a function from a code identity, when a manifest is stored under both.
+const functionManifestSuffix = ".function"
+
+// Manifest lists the digest of every file that is part of a code identity. The manifest is signed in place of the bare
+// identity it holds, so a failed verification can report which files changed.
+type Manifest struct {
+	Identity        string `json:"identity"`
+	IdentityVersion string `json:"identityVersion"`
+	// Ignore holds the effective ignore file patterns the identity was generated with
+	Ignore []string       `json:"ignore,omitempty"`
+	Files  []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+}
+
+type ManifestDiff struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+func GenerateManifest(path string, identity string) (*Manifest, error) {
+	files, digests, ignoreRules, err := hashCodeFiles(path, 0, fileSha256)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{Identity: identity, IdentityVersion: ParseIdentityVersion(identity), Ignore: ignoreRules.Patterns}
+	for index, file := range files {
+		manifest.Files = append(manifest.Files, ManifestFile{Path: file.relPath, Sha256: digests[index]})
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+	return manifest, nil
+}
+
+// FunctionManifestName returns the name the signed manifest of the code last signed for a function is stored under,
+// given the function by its name, qualified name, ARN or resource name.
+func FunctionManifestName(function string) string {
+	name := function
+	if strings.HasPrefix(name, "arn:") {
+		// arn:<partition>:lambda:<region>:<account>:function:<name>[:<qualifier>]
+		if parts := strings.Split(name, ":"); len(parts) > 6 {
+			name = parts[6]
+		}
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+	if index := strings.Index(name, ":"); index >= 0 {
+		name = name[:index]
+	}
+	return name + functionManifestSuffix
+}
+
+func ParseManifest(data []byte) (*Manifest, error) {
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func (m *Manifest) Marshal() (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return string(data), nil
+}
+
+// DiffManifests reports the files of the actual code that were added, removed or modified compared to the signed
+// manifest.
+func DiffManifests(signed *Manifest, actual *Manifest) ManifestDiff {
+	diff := ManifestDiff{}
+	signedFiles := make(map[string]string, len(signed.Files))
+	for _, file := range signed.Files {
+		signedFiles[file.Path] = file.Sha256
+	}
+	for _, file := range actual.Files {
+		digest, exist := signedFiles[file.Path]
+		if !exist {
+			diff.Added = append(diff.Added, file.Path)
+		} else if digest != file.Sha256 {
+			diff.Modified = append(diff.Modified, file.Path)
+		}
+		delete(signedFiles, file.Path)
+	}
+	for path := range signedFiles {
+		diff.Removed = append(diff.Removed, path)
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+func (d ManifestDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+func (d ManifestDiff) String() string {
+	if d.IsEmpty() {
+		return "no files changed"
+	}
+	var changes []string
+	if len(d.Added) > 0 {
+		changes = append(changes, "added: "+strings.Join(d.Added, ", "))
+	}
+	if len(d.Removed) > 0 {
+		changes = append(changes, "removed: "+strings.Join(d.Removed, ", "))
+	}
+	if len(d.Modified) > 0 {
+		changes = append(changes, "modified: "+strings.Join(d.Modified, ", "))
+	}
+	return strings.Join(changes, "; ")
+}
+
+func fileSha256(file codeFile) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifestDiff(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+	const pathToIdenticalSourceCode = "../../test_utils/identical_source_for_testing/code_for_testing/"
+	const pathToChangedSourceCode = "../../test_utils/changed_code_for_testing/"
+
+	signedManifest, err := GenerateManifest(pathToSourceCode, "identity")
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for code in: %s", pathToSourceCode)
+	}
+	content, err := signedManifest.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedManifest, err := ParseManifest([]byte(content))
+	if err != nil || !reflect.DeepEqual(signedManifest, parsedManifest) {
+		t.Fatalf("Error. manifest changed after marshaling: %v", err)
+	}
+
+	identicalManifest, err := GenerateManifest(pathToIdenticalSourceCode, "")
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for code in: %s", pathToIdenticalSourceCode)
+	}
+	if diff := DiffManifests(signedManifest, identicalManifest); !diff.IsEmpty() {
+		t.Fatalf("Error. identical code should not have changes: %s", diff)
+	}
+
+	changedManifest, err := GenerateManifest(pathToChangedSourceCode, "")
+	if err != nil {
+		t.Fatalf("Failed to generate manifest for code in: %s", pathToChangedSourceCode)
+	}
+	changedManifest.Files = append(changedManifest.Files, ManifestFile{Path: "added.go", Sha256: "digest"})
+	changedManifest.Files = changedManifest.Files[1:]
+	diff := DiffManifests(signedManifest, changedManifest)
+	expected := ManifestDiff{Added: []string{"added.go"}, Removed: []string{"LICENSE"}, Modified: []string{"cmd/commands.go"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("Error. expected changes: %s, got: %s", expected, diff)
+	}
+}
+
+func TestFunctionManifestName(t *testing.T) {
+	tests := []struct {
+		function string
+		expected string
+	}{
+		{function: "my-function", expected: "my-function.function"},
+		{function: "arn:aws:lambda:us-east-1:123456789012:function:my-function", expected: "my-function.function"},
+		{function: "arn:aws:lambda:us-east-1:123456789012:function:my-function:1", expected: "my-function.function"},
+		{function: "my-function:prod", expected: "my-function.function"},
+		{function: "projects/my-project/locations/us-central1/functions/my-function", expected: "my-function.function"},
+		{function: "my-group/my-function", expected: "my-function.function"},
+	}
+	for _, test := range tests {
+		if name := FunctionManifestName(test.function); name != test.expected {
+			t.Errorf("Error. expected manifest name of: %s to be: %s, got: %s", test.function, test.expected, name)
+		}
+	}
+}
@@ -16,11 +16,16 @@
 package options
 
 import (
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/integrity"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/cobra"
 )
 
 type SignBlobOptions struct {
+	IdentityVersion string
+	FunctionName    string
 	options.SignBlobOptions
 }
 
@@ -47,4 +52,10 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
 		"skip confirmation prompts for non-destructive operations")
+
+	cmd.Flags().StringVar(&o.IdentityVersion, "identity-version", integrity.DefaultIdentityVersion,
+		"version of the code identity to sign ("+strings.Join(integrity.IdentityVersions(), "|")+")")
+
+	cmd.Flags().StringVar(&o.FunctionName, "function-name", "",
+		"name of the function the code is deployed as, its signed manifest is also stored under the name, so a failed verification of the function reports the changed files")
 }
@@ -16,12 +16,20 @@
 package options
 
 import (
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/policy"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/cobra"
 )
 
 type VerifyOpts struct {
-	BundlePath string
+	BundlePath        string
+	IdentityVersion   string
+	ReferenceIdentity string
+	// Policy, when set, selects the signers and the action of each function by its matched rule
+	Policy *policy.Policy
 	co.VerifyOptions
 }
 
@@ -39,9 +47,6 @@ func (o *VerifyOpts) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Attachment, "attachment", "",
 		"related image attachment to sign (sbom), default none")
 
-	cmd.Flags().StringVarP(&o.Output, "output", "o", "json",
-		"output format for the signing image information (json|text)")
-
 	cmd.Flags().StringVar(&o.SignatureRef, "signature", "",
 		"signature content or path or remote URL")
 
@@ -50,4 +55,10 @@ func (o *VerifyOpts) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
 		"path to bundle FILE")
+
+	cmd.Flags().StringVar(&o.IdentityVersion, "identity-version", "",
+		"version of the code identity to verify ("+strings.Join(integrity.IdentityVersions(), "|")+"), if empty the version the function was last signed with is tried first, then the default version and the others")
+
+	cmd.Flags().StringVar(&o.ReferenceIdentity, "reference-identity", "",
+		"identity of previously signed code, its signed manifest is used to report the changed files when verification fails")
 }
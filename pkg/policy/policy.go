@@ -0,0 +1,201 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy is the declarative verification policy: rules matched by function name, tags, account and region,
+// each defining the allowed signers, the required package type and the post verification action of its functions.
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/open-policy-agent/opa/rego"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the policy file deployed with the verifier functions.
+const FileName = "policy.yaml"
+
+var actions = []string{"", "none", "detect", "block", "notify"}
+
+var packageTypes = []string{"", "Zip", "Image"}
+
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+	// Rego is an embedded Rego module, evaluated over the verification result of every function
+	Rego string `yaml:"rego"`
+
+	regoQuery *rego.PreparedEvalQuery
+}
+
+// Rule applies to the functions matching all of its criteria, a criterion left empty matches every function.
+// Function names, accounts, regions and tag values are glob patterns.
+type Rule struct {
+	Name        string            `yaml:"name"`
+	Functions   []string          `yaml:"functions"`
+	Tags        map[string]string `yaml:"tags"`
+	Accounts    []string          `yaml:"accounts"`
+	Regions     []string          `yaml:"regions"`
+	Signers     []Signer          `yaml:"signers"`
+	PackageType string            `yaml:"packageType"`
+	Action      string            `yaml:"action"`
+}
+
+// Signer is either a public key reference, or the identity of a keyless signer.
+type Signer struct {
+	Key                      string `yaml:"key"`
+	Email                    string `yaml:"email"`
+	Issuer                   string `yaml:"issuer"`
+	GithubWorkflowRepository string `yaml:"githubWorkflowRepository"`
+}
+
+type Function struct {
+	Name      string
+	AccountId string
+	Region    string
+	Tags      map[string]string
+}
+
+func (s Signer) IsKeyless() bool {
+	return s.Key == ""
+}
+
+func Load(policyPath string) (*Policy, error) {
+	content, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return Parse(content)
+}
+
+func Parse(content []byte) (*Policy, error) {
+	p := &Policy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	for i := range p.Rules {
+		if err := p.Rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid policy rule %d (%s): %w", i+1, p.Rules[i].Name, err)
+		}
+	}
+	if p.HasRego() {
+		if err := p.prepareRego(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Match returns the first rule matching the function, or nil when no rule matches.
+func (p *Policy) Match(f Function) *Rule {
+	for i := range p.Rules {
+		if p.Rules[i].matches(f) {
+			return &p.Rules[i]
+		}
+	}
+	return nil
+}
+
+// UsesTags tells if the function tags are needed to match the rules.
+func (p *Policy) UsesTags() bool {
+	for _, rule := range p.Rules {
+		if len(rule.Tags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasKeylessSigners tells if any of the rules allows a keyless signer.
+func (p *Policy) HasKeylessSigners() bool {
+	for _, rule := range p.Rules {
+		for _, signer := range rule.Signers {
+			if signer.IsKeyless() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *Rule) validate() error {
+	if !contains(actions, r.Action) {
+		return fmt.Errorf("unsupported action: %s, expected one of: none, detect, block, notify", r.Action)
+	}
+	if !contains(packageTypes, r.PackageType) {
+		return fmt.Errorf("unsupported package type: %s, expected Zip or Image", r.PackageType)
+	}
+	for _, pattern := range append(append(append([]string{}, r.Functions...), r.Accounts...), r.Regions...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern: %s: %w", pattern, err)
+		}
+	}
+	for key, pattern := range r.Tags {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern of tag: %s: %w", key, err)
+		}
+	}
+	for _, signer := range r.Signers {
+		keylessIdentity := signer.Email != "" || signer.Issuer != "" || signer.GithubWorkflowRepository != ""
+		if signer.Key != "" && keylessIdentity {
+			return fmt.Errorf("signer with key: %s can't define a keyless identity", signer.Key)
+		}
+		if signer.Key == "" && !keylessIdentity {
+			return fmt.Errorf("signer must define a key or a keyless identity")
+		}
+	}
+	return nil
+}
+
+func (r *Rule) matches(f Function) bool {
+	if !matchesAny(r.Functions, f.Name) || !matchesAny(r.Accounts, f.AccountId) || !matchesAny(r.Regions, f.Region) {
+		return false
+	}
+	for key, pattern := range r.Tags {
+		value, exist := f.Tags[key]
+		if !exist {
+			return false
+		}
+		if matched, _ := path.Match(pattern, value); pattern != "" && !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+)
+
+const testPolicy = `
+rules:
+  - name: prod
+    tags:
+      env: prod
+    accounts: ["1234*"]
+    packageType: Zip
+    signers:
+      - key: prod.pub
+      - email: release@example.com
+        issuer: https://token.actions.githubusercontent.com
+        githubWorkflowRepository: example/functions
+    action: block
+  - name: sandbox
+    functions: ["sandbox-*", "dev-*"]
+    action: detect
+  - name: default
+    action: notify
+`
+
+func TestMatch(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		function Function
+		rule     string
+	}{
+		"prod":                {Function{Name: "orders", AccountId: "123456", Tags: map[string]string{"env": "prod"}}, "prod"},
+		"prod other account":  {Function{Name: "orders", AccountId: "999999", Tags: map[string]string{"env": "prod"}}, "default"},
+		"sandbox":             {Function{Name: "sandbox-orders", AccountId: "123456"}, "sandbox"},
+		"sandbox tagged prod": {Function{Name: "sandbox-orders", AccountId: "123456", Tags: map[string]string{"env": "prod"}}, "prod"},
+		"untagged":            {Function{Name: "orders", AccountId: "123456", Tags: map[string]string{"team": "a"}}, "default"},
+	}
+	for name, test := range tests {
+		rule := p.Match(test.function)
+		if rule == nil || rule.Name != test.rule {
+			t.Errorf("Error. %s: expected rule: %s, got: %v", name, test.rule, rule)
+		}
+	}
+	if !p.UsesTags() || !p.HasKeylessSigners() {
+		t.Errorf("Error. expected the policy to use tags and keyless signers")
+	}
+	if signers := p.Rules[0].Signers; len(signers) != 2 || signers[0].IsKeyless() || !signers[1].IsKeyless() {
+		t.Errorf("Error. unexpected signers: %v", signers)
+	}
+}
+
+func TestMatchNoRule(t *testing.T) {
+	p, err := Parse([]byte("rules:\n  - functions: [\"prod-*\"]\n    regions: [\"us-*\"]\n    action: block\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule := p.Match(Function{Name: "prod-orders", Region: "eu-west-1"}); rule != nil {
+		t.Errorf("Error. expected no rule, got: %v", rule)
+	}
+	if rule := p.Match(Function{Name: "prod-orders", Region: "us-east-1"}); rule == nil {
+		t.Errorf("Error. expected a rule")
+	}
+	if p.HasKeylessSigners() {
+		t.Errorf("Error. expected no keyless signers")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"action":              "rules:\n  - action: delete\n",
+		"package type":        "rules:\n  - packageType: jar\n",
+		"pattern":             "rules:\n  - functions: [\"[\"]\n",
+		"unknown field":       "rules:\n  - function: orders\n",
+		"empty signer":        "rules:\n  - signers:\n      - {}\n",
+		"key and keyless":     "rules:\n  - signers:\n      - key: cosign.pub\n        email: a@example.com\n",
+		"tag value pattern":   "rules:\n  - tags:\n      env: \"[\"\n",
+		"not a list of rules": "rules: prod\n",
+	}
+	for name, content := range tests {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Errorf("Error. %s: expected an error", name)
+		}
+	}
+}
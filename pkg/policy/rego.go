@@ -0,0 +1,168 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// RegoQuery is evaluated over the embedded Rego module, which must be in package functionclarity and may define:
+// allow (defaults to the signature verification result), deny (a set of reasons) and action (overrides the action).
+const RegoQuery = "data.functionclarity"
+
+// Input is the document the Rego module is evaluated against.
+type Input struct {
+	Function    FunctionMetadata  `json:"function"`
+	Tags        map[string]string `json:"tags"`
+	PackageType string            `json:"packageType"`
+	Identity    string            `json:"identity"`
+	Verified    bool              `json:"verified"`
+	Error       string            `json:"error,omitempty"`
+	Certificate *Certificate      `json:"certificate,omitempty"`
+}
+
+type FunctionMetadata struct {
+	AccountId          string `json:"accountId"`
+	FunctionName       string `json:"functionName"`
+	FunctionIdentifier string `json:"functionIdentifier"`
+	Region             string `json:"region"`
+}
+
+// Certificate holds the claims of the keyless signing certificate.
+type Certificate struct {
+	Subject                  string `json:"subject"`
+	Issuer                   string `json:"issuer"`
+	GithubWorkflowTrigger    string `json:"githubWorkflowTrigger,omitempty"`
+	GithubWorkflowSha        string `json:"githubWorkflowSha,omitempty"`
+	GithubWorkflowName       string `json:"githubWorkflowName,omitempty"`
+	GithubWorkflowRepository string `json:"githubWorkflowRepository,omitempty"`
+	GithubWorkflowRef        string `json:"githubWorkflowRef,omitempty"`
+	NotBefore                string `json:"notBefore"`
+	NotAfter                 string `json:"notAfter"`
+}
+
+type Decision struct {
+	Allow   bool
+	Reasons []string
+	Action  string
+}
+
+// HasRego tells if the policy embeds a Rego module.
+func (p *Policy) HasRego() bool {
+	return p.Rego != ""
+}
+
+func (p *Policy) prepareRego() error {
+	query, err := rego.New(rego.Query(RegoQuery), rego.Module("policy.rego", p.Rego)).PrepareForEval(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to compile rego module: %w", err)
+	}
+	p.regoQuery = &query
+	return nil
+}
+
+// Evaluate evaluates the embedded Rego module against the input.
+func (p *Policy) Evaluate(ctx context.Context, input Input) (*Decision, error) {
+	if p.regoQuery == nil {
+		if err := p.prepareRego(); err != nil {
+			return nil, err
+		}
+	}
+	resultSet, err := p.regoQuery.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate rego policy: %w", err)
+	}
+	decision := &Decision{Allow: input.Verified}
+	if len(resultSet) == 0 || len(resultSet[0].Expressions) == 0 {
+		return decision, nil
+	}
+	result, ok := resultSet[0].Expressions[0].Value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected rego policy result: %v", resultSet[0].Expressions[0].Value)
+	}
+	if allow, exist := result["allow"]; exist {
+		if decision.Allow, ok = allow.(bool); !ok {
+			return nil, fmt.Errorf("unexpected rego policy allow: %v, expected a boolean", allow)
+		}
+	}
+	if deny, exist := result["deny"]; exist {
+		reasons, ok := deny.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected rego policy deny: %v, expected a set of reasons", deny)
+		}
+		for _, reason := range reasons {
+			decision.Reasons = append(decision.Reasons, fmt.Sprint(reason))
+		}
+		sort.Strings(decision.Reasons)
+		if len(decision.Reasons) > 0 {
+			decision.Allow = false
+		}
+	}
+	if action, exist := result["action"]; exist {
+		if decision.Action, ok = action.(string); !ok || !contains(actions, decision.Action) {
+			return nil, fmt.Errorf("unexpected rego policy action: %v, expected one of: none, detect, block, notify", action)
+		}
+	}
+	return decision, nil
+}
+
+// ParseCertificate parses the claims of a keyless signing certificate, given as PEM or base64 encoded PEM.
+func ParseCertificate(content []byte) (*Certificate, error) {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content))); err == nil {
+		content = decoded
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("failed to parse certificate: no certificate found")
+	}
+	cert := certs[0]
+	extensions := cosign.CertExtensions{Cert: cert}
+	return &Certificate{
+		Subject:                  certificateSubject(cert),
+		Issuer:                   extensions.GetIssuer(),
+		GithubWorkflowTrigger:    extensions.GetCertExtensionGithubWorkflowTrigger(),
+		GithubWorkflowSha:        extensions.GetExtensionGithubWorkflowSha(),
+		GithubWorkflowName:       extensions.GetCertExtensionGithubWorkflowName(),
+		GithubWorkflowRepository: extensions.GetCertExtensionGithubWorkflowRepository(),
+		GithubWorkflowRef:        extensions.GetCertExtensionGithubWorkflowRef(),
+		NotBefore:                cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:                 cert.NotAfter.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// certificateSubject returns the email of the signer, or the uri of the workflow that signed.
+func certificateSubject(cert *x509.Certificate) string {
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testRegoPolicy = `
+rego: |
+  package functionclarity
+
+  default allow = false
+
+  allow {
+    input.verified
+    input.certificate.githubWorkflowRepository == "example/functions"
+    startswith(input.certificate.githubWorkflowRef, "refs/tags/")
+  }
+
+  allow {
+    time.parse_rfc3339_ns(input.tags.exempt) > time.now_ns()
+  }
+
+  deny[reason] {
+    input.function.region == "eu-south-1"
+    reason := "region is not allowed"
+  }
+
+  action = "block" {
+    input.tags.env == "prod"
+  }
+`
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testRegoPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := &Certificate{GithubWorkflowRepository: "example/functions", GithubWorkflowRef: "refs/tags/v1.0.0"}
+	branch := &Certificate{GithubWorkflowRepository: "example/functions", GithubWorkflowRef: "refs/heads/main"}
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	tests := map[string]struct {
+		input    Input
+		expected Decision
+	}{
+		"release":         {Input{Verified: true, Certificate: release, Tags: map[string]string{"env": "prod"}}, Decision{Allow: true, Action: "block"}},
+		"branch":          {Input{Verified: true, Certificate: branch}, Decision{Allow: false}},
+		"exempt":          {Input{Verified: false, Tags: map[string]string{"exempt": future}}, Decision{Allow: true}},
+		"exempt expired":  {Input{Verified: false, Tags: map[string]string{"exempt": past}}, Decision{Allow: false}},
+		"denied region":   {Input{Verified: true, Certificate: release, Function: FunctionMetadata{Region: "eu-south-1"}}, Decision{Allow: false, Reasons: []string{"region is not allowed"}}},
+		"not signed prod": {Input{Verified: false, Tags: map[string]string{"env": "prod"}}, Decision{Allow: false, Action: "block"}},
+	}
+	for name, test := range tests {
+		decision, err := p.Evaluate(context.Background(), test.input)
+		if err != nil {
+			t.Fatalf("Error. %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(*decision, test.expected) {
+			t.Errorf("Error. %s: expected decision: %v, got: %v", name, test.expected, *decision)
+		}
+	}
+}
+
+func TestEvaluateDefaultsToVerificationResult(t *testing.T) {
+	p, err := Parse([]byte("rego: |\n  package functionclarity\n\n  deny[\"unsigned prod\"] {\n    not input.verified\n    input.tags.env == \"prod\"\n  }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, verified := range []bool{true, false} {
+		decision, err := p.Evaluate(context.Background(), Input{Verified: verified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allow != verified {
+			t.Errorf("Error. expected allow: %v, got: %v", verified, decision.Allow)
+		}
+	}
+}
+
+func TestParseInvalidRego(t *testing.T) {
+	tests := map[string]string{
+		"syntax":         "rego: |\n  package functionclarity\n  allow {\n",
+		"allow type":     "rego: |\n  package functionclarity\n  allow = \"yes\"\n",
+		"unknown action": "rego: |\n  package functionclarity\n  action = \"delete\"\n",
+	}
+	for name, content := range tests {
+		p, err := Parse([]byte(content))
+		if err == nil {
+			_, err = p.Evaluate(context.Background(), Input{})
+		}
+		if err == nil {
+			t.Errorf("Error. %s: expected an error", name)
+		}
+	}
+}
+
+func TestParseCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflowURI, _ := url.Parse("https://github.com/example/functions/.github/workflows/release.yml@refs/tags/v1.0.0")
+	extension := func(oid asn1.ObjectIdentifier, value string) pkix.Extension {
+		return pkix.Extension{Id: oid, Value: []byte(value)}
+	}
+	notBefore := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(10 * time.Minute),
+		URIs:         []*url.URL{workflowURI},
+		ExtraExtensions: []pkix.Extension{
+			extension(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, "https://token.actions.githubusercontent.com"),
+			extension(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 2}, "push"),
+			extension(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}, "example/functions"),
+			extension(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 6}, "refs/tags/v1.0.0"),
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	expected := Certificate{
+		Subject:                  workflowURI.String(),
+		Issuer:                   "https://token.actions.githubusercontent.com",
+		GithubWorkflowTrigger:    "push",
+		GithubWorkflowRepository: "example/functions",
+		GithubWorkflowRef:        "refs/tags/v1.0.0",
+		NotBefore:                "2022-12-01T10:00:00Z",
+		NotAfter:                 "2022-12-01T10:10:00Z",
+	}
+	for _, content := range [][]byte{certPem, []byte(base64.StdEncoding.EncodeToString(certPem))} {
+		certificate, err := ParseCertificate(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*certificate, expected) {
+			t.Errorf("Error. expected certificate: %v, got: %v", expected, *certificate)
+		}
+	}
+}
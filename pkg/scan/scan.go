@@ -0,0 +1,211 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scan verifies existing functions in bulk, and summarizes their verification results in a report.
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/verify"
+	"gopkg.in/yaml.v3"
+)
+
+// Target is a function to verify, with the client of the region it runs in.
+type Target struct {
+	Client   clients.Client
+	Function string
+	Region   string
+}
+
+type Report struct {
+	Start      time.Time `json:"start" yaml:"start"`
+	DurationMs int64     `json:"durationMs" yaml:"durationMs"`
+	Regions    []string  `json:"regions" yaml:"regions"`
+	// Accounts are the accounts verified by assuming a role in them, empty for the account of the credentials
+	Accounts []string `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+	Total    int      `json:"total" yaml:"total"`
+	Verified int      `json:"verified" yaml:"verified"`
+	Failed   int      `json:"failed" yaml:"failed"`
+	Skipped  int      `json:"skipped" yaml:"skipped"`
+	// Errors counts the functions an error prevented verifying
+	Errors int `json:"errors" yaml:"errors"`
+	// FailureCategories counts the functions that failed or errored by failure category
+	FailureCategories map[string]int `json:"failureCategories,omitempty" yaml:"failureCategories,omitempty"`
+	// RegionErrors holds the regions whose functions couldn't be listed, as <account>/<region> when accounts are set
+	RegionErrors map[string]string            `json:"regionErrors,omitempty" yaml:"regionErrors,omitempty"`
+	Results      []*verify.VerificationResult `json:"results" yaml:"results"`
+}
+
+func NewReport(regions []string) *Report {
+	return &Report{Start: time.Now().UTC(), Regions: regions, FailureCategories: map[string]int{}, RegionErrors: map[string]string{}}
+}
+
+// Run verifies the targets, with at most concurrency verifications running at a time, and adds their results to the
+// report in the order of the targets.
+func (r *Report) Run(targets []Target, concurrency int, verifyFunc func(Target) (*verify.VerificationResult, error)) {
+	r.RunUntil(targets, concurrency, nil, verifyFunc)
+}
+
+// RunUntil verifies the targets like Run, but stops starting verifications once stop returns true, and returns the
+// number of targets started. Only the results of the started targets are added to the report.
+func (r *Report) RunUntil(targets []Target, concurrency int, stop func() bool, verifyFunc func(Target) (*verify.VerificationResult, error)) int {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]*verify.VerificationResult, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	var progressLock sync.Mutex
+	started, done := 0, 0
+	// next returns the index of the next target to verify, a worker takes it only once it is free to verify it
+	next := func() (int, bool) {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+		if started == len(targets) || (stop != nil && stop()) {
+			return 0, false
+		}
+		started++
+		return started - 1, true
+	}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, ok := next(); ok; i, ok = next() {
+				results[i], errs[i] = verifyFunc(targets[i])
+				progressLock.Lock()
+				done++
+				fmt.Printf("scan progress: %d/%d functions verified\n", done, len(targets))
+				progressLock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, result := range results[:started] {
+		if result == nil {
+			result = &verify.VerificationResult{Function: targets[i].Function, Region: targets[i].Region}
+		}
+		if result.Region == "" {
+			result.Region = targets[i].Region
+		}
+		if errs[i] != nil && result.Error == "" {
+			result.Error = errs[i].Error()
+		}
+		r.add(result, errs[i])
+	}
+	r.DurationMs = time.Since(r.Start).Milliseconds()
+	return started
+}
+
+func (r *Report) add(result *verify.VerificationResult, err error) {
+	r.Total++
+	r.Results = append(r.Results, result)
+	switch {
+	case err != nil:
+		r.Errors++
+	case result.Skipped:
+		r.Skipped++
+		return
+	case result.Verified:
+		r.Verified++
+		return
+	default:
+		r.Failed++
+	}
+	category := result.FailureCategory
+	if category == "" {
+		category = verify.FailureCategory(err)
+	}
+	r.FailureCategories[category]++
+}
+
+// Write writes the report in one of the output formats: json, yaml or table. The table lists the functions that
+// weren't verified only.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(r)
+	case "table":
+		return r.writeTable(w)
+	}
+	return fmt.Errorf("unsupported output format: %s, expected one of: %s", format, strings.Join(verify.OutputFormats, ", "))
+}
+
+func (r *Report) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "REGIONS\t%s\n", strings.Join(r.Regions, ","))
+	if len(r.Accounts) > 0 {
+		fmt.Fprintf(tw, "ACCOUNTS\t%s\n", strings.Join(r.Accounts, ","))
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\n", r.Total)
+	fmt.Fprintf(tw, "VERIFIED\t%d\n", r.Verified)
+	fmt.Fprintf(tw, "FAILED\t%d\n", r.Failed)
+	fmt.Fprintf(tw, "SKIPPED\t%d\n", r.Skipped)
+	fmt.Fprintf(tw, "ERRORS\t%d\n", r.Errors)
+	fmt.Fprintf(tw, "DURATION\t%dms\n", r.DurationMs)
+	categories := make([]string, 0, len(r.FailureCategories))
+	for category := range r.FailureCategories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		fmt.Fprintf(tw, "FAILURE CATEGORY %s\t%d\n", category, r.FailureCategories[category])
+	}
+	regions := make([]string, 0, len(r.RegionErrors))
+	for region := range r.RegionErrors {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		fmt.Fprintf(tw, "REGION ERROR %s\t%s\n", region, r.RegionErrors[region])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if r.Failed+r.Errors == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FUNCTION\tREGION\tFAILURE CATEGORY\tACTION\tERROR")
+	for _, result := range r.Results {
+		if result.Verified || result.Skipped {
+			continue
+		}
+		function := result.FunctionArn
+		if function == "" {
+			function = result.Function
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", function, result.Region, result.FailureCategory, result.Action, result.Error)
+	}
+	return tw.Flush()
+}
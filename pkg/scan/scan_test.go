@@ -0,0 +1,117 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"github.com/openclarity/functionclarity/pkg/verify"
+)
+
+func TestReportRun(t *testing.T) {
+	var targets []Target
+	for i := 0; i < 20; i++ {
+		targets = append(targets, Target{Function: fmt.Sprintf("function-%d", i), Region: "us-east-1"})
+	}
+	var running, maxRunning int32
+	report := NewReport([]string{"us-east-1"})
+	report.Run(targets, 3, func(target Target) (*verify.VerificationResult, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+		result := &verify.VerificationResult{Function: target.Function}
+		switch target.Function {
+		case "function-1":
+			result.FailureCategory = utils.FailureSignatureNotFound
+		case "function-2":
+			result.Skipped = true
+		case "function-3":
+			result.FailureCategory = utils.FailureProvider
+			return result, verify.ProviderError{Err: fmt.Errorf("throttled")}
+		default:
+			result.Verified = true
+		}
+		return result, nil
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("Error. expected at most 3 concurrent verifications, got: %d", maxRunning)
+	}
+	if report.Total != 20 || report.Verified != 17 || report.Failed != 1 || report.Skipped != 1 || report.Errors != 1 {
+		t.Errorf("Error. unexpected report counts: %+v", report)
+	}
+	if report.FailureCategories[utils.FailureSignatureNotFound] != 1 || report.FailureCategories[utils.FailureProvider] != 1 {
+		t.Errorf("Error. unexpected failure categories: %v", report.FailureCategories)
+	}
+	if report.Results[5].Function != "function-5" || report.Results[3].Error != "throttled" || report.Results[0].Region != "us-east-1" {
+		t.Errorf("Error. expected results in the order of the targets, got: %+v", report.Results[:6])
+	}
+
+	var out bytes.Buffer
+	if err := report.Write(&out, "table"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"TOTAL", "20", "function-1", "signature-not-found", "function-3", "throttled"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Error. expected table to contain: %s, got: %s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "function-5") {
+		t.Errorf("Error. expected verified functions to be omitted from table: %s", out.String())
+	}
+
+	out.Reset()
+	if err := report.Write(&out, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var fromJson Report
+	if err := json.Unmarshal(out.Bytes(), &fromJson); err != nil {
+		t.Fatal(err)
+	}
+	if fromJson.Verified != 17 || len(fromJson.Results) != 20 {
+		t.Errorf("Error. unexpected json report: %s", out.String())
+	}
+}
+
+func TestReportRunUntil(t *testing.T) {
+	var targets []Target
+	for i := 0; i < 10; i++ {
+		targets = append(targets, Target{Function: fmt.Sprintf("function-%d", i)})
+	}
+	var verified int32
+	report := NewReport([]string{"us-east-1"})
+	started := report.RunUntil(targets, 2, func() bool { return atomic.LoadInt32(&verified) >= 4 }, func(target Target) (*verify.VerificationResult, error) {
+		atomic.AddInt32(&verified, 1)
+		return &verify.VerificationResult{Function: target.Function, Verified: true}, nil
+	})
+	if started < 4 || started > 5 {
+		t.Fatalf("Error. expected the verifications to stop after 4 verified, started: %d", started)
+	}
+	if report.Total != started || len(report.Results) != started || report.Results[started-1].Function != fmt.Sprintf("function-%d", started-1) {
+		t.Errorf("Error. expected only the started targets in the report, got: %+v", report)
+	}
+}
@@ -17,34 +17,76 @@ package sign
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/openclarity/functionclarity/cmd/function-clarity/cli/sign"
 	"github.com/openclarity/functionclarity/pkg/clients"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/viper"
 )
 
-func SignAndUploadCode(client clients.Client, codePath string, o *options.SignBlobOptions, ro *co.RootOptions) error {
-	hash := new(integrity.Sha256)
-	codeIdentity, err := hash.GenerateIdentity(codePath)
+func SignAndUploadCode(store clients.SignatureStore, codePath string, o *options.SignBlobOptions, ro *co.RootOptions) error {
+	identityGenerator, err := integrity.GetIdentityGenerator(o.IdentityVersion)
 	if err != nil {
 		return fmt.Errorf("failed to create identity: %w", err)
 	}
+	codeIdentity, err := identityGenerator.GenerateIdentity(codePath)
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+	manifest, err := integrity.GenerateManifest(codePath, codeIdentity)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest for identity: %s: %w", codeIdentity, err)
+	}
+	manifestContent, err := manifest.Marshal()
+	if err != nil {
+		return err
+	}
 	isKeyless := false
 	privateKey := viper.GetString("privatekey")
 	if !o.SecurityKey.Use && privateKey == "" && integrity.IsExperimentalEnv() {
 		isKeyless = true
 	}
 
-	signedIdentity, err := sign.SignIdentity(codeIdentity, o, ro, isKeyless)
+	// the manifest holds the identity, a single signature covers both
+	signedManifest, err := sign.SignPayload(manifestContent, codeIdentity, o, ro, isKeyless)
 	if err != nil {
 		return fmt.Errorf("failed to sign identity: %s with private key in path: %s: %w", codeIdentity, privateKey, err)
 	}
-	if err = client.Upload(signedIdentity, codeIdentity, isKeyless); err != nil {
-		return fmt.Errorf("failed to upload code signature: identity: %s, signature: %s to bucket: %s: %w", codeIdentity, signedIdentity, viper.GetString("bucket"), err)
+	if err = uploadSignedManifest(store, codeIdentity, codeIdentity, manifestContent, signedManifest, isKeyless); err != nil {
+		return err
+	}
+	if o.FunctionName != "" {
+		functionManifestName := integrity.FunctionManifestName(o.FunctionName)
+		if err = uploadSignedManifest(store, functionManifestName, codeIdentity, manifestContent, signedManifest, isKeyless); err != nil {
+			return err
+		}
 	}
 	fmt.Println("Code uploaded successfully")
 	return nil
 }
+
+// uploadSignedManifest uploads the manifest and its signature under the name, the manifest first, so the signature is
+// never found without it.
+func uploadSignedManifest(store clients.SignatureStore, name string, codeIdentity string, manifestContent string, signedManifest string, isKeyless bool) error {
+	if isKeyless && name != codeIdentity {
+		// the certificate was written under the name of the identity, the stores upload it by the name of the signature
+		certificate, err := os.ReadFile(utils.FunctionClarityHomeDir + codeIdentity + ".crt.base64")
+		if err != nil {
+			return fmt.Errorf("failed to read certificate of identity: %s: %w", codeIdentity, err)
+		}
+		if err = os.WriteFile(utils.FunctionClarityHomeDir+name+".crt.base64", certificate, 0600); err != nil {
+			return fmt.Errorf("failed to write certificate of: %s: %w", name, err)
+		}
+	}
+	if err := store.UploadFile(manifestContent, name+integrity.ManifestSuffix+".json"); err != nil {
+		return fmt.Errorf("failed to upload manifest of identity: %s: %w", codeIdentity, err)
+	}
+	if err := store.Upload(signedManifest, name, isKeyless); err != nil {
+		return fmt.Errorf("failed to upload code signature: identity: %s, signature: %s: %w", codeIdentity, signedManifest, err)
+	}
+	return nil
+}
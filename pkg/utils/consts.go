@@ -15,18 +15,60 @@
 
 package utils
 
-import "os"
+import (
+	"errors"
+	"os"
+)
 
 const FunctionSignedTagValue = "Function signed and verified"
 
 const FunctionNotSignedTagValue = "Function not signed"
 
+// FunctionVerificationFailedTagValue is followed by the failure category of a function that is signed, but failed verification
+const FunctionVerificationFailedTagValue = "Function verification failed"
+
 const FunctionVerifyResultTagKey = "Function clarity result"
 
+// GCP labels only allow lowercase letters, numbers, underscores and dashes
+const FunctionVerifyResultLabelKey = "function-clarity-result"
+
+const FunctionSignedLabelValue = "signed-and-verified"
+
+const FunctionNotSignedLabelValue = "not-signed"
+
 const FunctionClarityConcurrencyTagKey = "FUNCTION_CLARITY_CONCURRENCY_LEVEL"
 
+const FunctionClarityPreviousStateTagKey = "FUNCTION_CLARITY_PREVIOUS_STATE"
+
 const FunctionClaritySignatureNotFoundMessage = "storage: object doesn't exist"
 
+// ErrSignatureNotFound is returned by the signature stores when the requested file doesn't exist
+var ErrSignatureNotFound = errors.New(FunctionClaritySignatureNotFoundMessage)
+
+// Failure categories of a verification. A function failing with signature-not-found or certificate-not-found is
+// unsigned, while the other categories mean a signature exists but the function was tampered with or isn't trusted.
+const (
+	FailureSignatureNotFound   = "signature-not-found"
+	FailureCertificateNotFound = "certificate-not-found"
+	FailureInvalidSignature    = "invalid-signature"
+	FailureIdentityMismatch    = "identity-mismatch"
+	FailureUntrustedKey        = "untrusted-key"
+	FailureRekorEntryNotFound  = "rekor-entry-not-found"
+	FailurePackageType         = "package-type-not-allowed"
+	FailurePolicyDenied        = "policy-denied"
+	FailurePackageDownload     = "package-download-failed"
+	FailureProvider            = "provider-error"
+	FailureError               = "error"
+)
+
+// IsUnsignedFailure tells if the failure category means no signature was found for the function.
+func IsUnsignedFailure(failureCategory string) bool {
+	return failureCategory == FailureSignatureNotFound || failureCategory == FailureCertificateNotFound
+}
+
 var HomeDir, _ = os.UserHomeDir()
 
 var FunctionClarityHomeDir = HomeDir + "/function-clarity/"
+
+// Version is the release version of function clarity, set at build time, and empty for a development build.
+var Version string
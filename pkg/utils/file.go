@@ -16,7 +16,9 @@
 package utils
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
@@ -91,6 +93,65 @@ func ExtractZip(zipPath string, dstToExtract string) error {
 	return nil
 }
 
+// ExtractTarGz extracts the directories and regular files of a tar.gz archive, like ExtractZip does for a zip archive.
+func ExtractTarGz(tarGzPath string, dstToExtract string) error {
+	archive, err := os.Open(filepath.Clean(tarGzPath))
+	if err != nil {
+		return fmt.Errorf("failed to open archive file : %s. %v", tarGzPath, err)
+	}
+	defer archive.Close()
+	gzipReader, err := gzip.NewReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to read archive file : %s. %v", tarGzPath, err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive file : %s. %v", tarGzPath, err)
+		}
+		filePath := filepath.Join(dstToExtract, header.Name)
+		// archives of a folder created with tar -C <folder> . hold its root as ./
+		if header.Typeflag == tar.TypeDir && filePath == filepath.Clean(dstToExtract) {
+			continue
+		}
+		if !strings.HasPrefix(filePath, filepath.Clean(dstToExtract)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path")
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(filePath, os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directory for path: %s. %v", filePath, err)
+			}
+			continue
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return fmt.Errorf("unsupported entry type of file: %s", header.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create directories for path: %s. %v", filePath, err)
+		}
+
+		dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("failed to open destination file for writing: %s. %v", filePath, err)
+		}
+		if _, err := io.Copy(dstFile, tarReader); err != nil {
+			dstFile.Close()
+			return fmt.Errorf("failed to copy file: %s from archive to local path: %s. %v", header.Name, dstFile.Name(), err)
+		}
+		dstFile.Close()
+	}
+}
+
 func CleanDirectory(directory string) {
 	if err := os.RemoveAll(directory); err != nil {
 		fmt.Printf("failed to delete directory %v: %v", directory, err)
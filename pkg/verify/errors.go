@@ -16,9 +16,17 @@
 package verify
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"github.com/sigstore/cosign/pkg/cosign"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
 )
 
+// VerifyError is a failed verification, as opposed to an error that prevented the verification. Every kind of failed
+// verification below embeds it, so errors.Is(err, VerifyError{}) holds for all of them, while errors.As tells them apart.
 type VerifyError struct {
 	Err error
 }
@@ -29,3 +37,118 @@ func (e VerifyError) Error() string {
 func (m VerifyError) Is(target error) bool {
 	return target == VerifyError{}
 }
+func (e VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// SignatureNotFoundError means no signature is stored for the function identity, the function is unsigned.
+type SignatureNotFoundError struct{ VerifyError }
+
+// CertificateNotFoundError means no certificate is stored for the keyless signature of the function identity.
+type CertificateNotFoundError struct{ VerifyError }
+
+// InvalidSignatureError means the signature doesn't verify the function identity, image or signed manifest.
+type InvalidSignatureError struct{ VerifyError }
+
+// IdentityMismatchError means the keyless certificate doesn't hold the expected signer identity.
+type IdentityMismatchError struct{ VerifyError }
+
+// UntrustedKeyError means none of the trusted public keys verifies the signature.
+type UntrustedKeyError struct{ VerifyError }
+
+// RekorEntryNotFoundError means the signature has no valid entry in the transparency log.
+type RekorEntryNotFoundError struct{ VerifyError }
+
+// PackageTypeError means the package type of the function isn't allowed by the policy rule it matched.
+type PackageTypeError struct{ VerifyError }
+
+// PolicyDeniedError means the rego policy denied the function.
+type PolicyDeniedError struct{ VerifyError }
+
+// PackageDownloadError means the code or image of the function couldn't be fetched, so it wasn't verified.
+type PackageDownloadError struct {
+	Err error
+}
+
+func (e PackageDownloadError) Error() string {
+	return e.Err.Error()
+}
+func (e PackageDownloadError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderError means a call to the cloud provider or to the signature store failed, so the function wasn't verified.
+type ProviderError struct {
+	Err error
+}
+
+func (e ProviderError) Error() string {
+	return e.Err.Error()
+}
+func (e ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// FailureCategory returns the failure category of the error, one of the utils.Failure* categories, or an empty
+// string when there is no error.
+func FailureCategory(err error) string {
+	if err == nil {
+		return ""
+	}
+	// the policy denial wraps the failure it was evaluated over, so it is checked first
+	var policyDenied PolicyDeniedError
+	var packageType PackageTypeError
+	var signatureNotFound SignatureNotFoundError
+	var certificateNotFound CertificateNotFoundError
+	var identityMismatch IdentityMismatchError
+	var untrustedKey UntrustedKeyError
+	var rekorEntryNotFound RekorEntryNotFoundError
+	var invalidSignature InvalidSignatureError
+	var packageDownload PackageDownloadError
+	var provider ProviderError
+	switch {
+	case errors.As(err, &policyDenied):
+		return utils.FailurePolicyDenied
+	case errors.As(err, &packageType):
+		return utils.FailurePackageType
+	case errors.As(err, &signatureNotFound):
+		return utils.FailureSignatureNotFound
+	case errors.As(err, &certificateNotFound):
+		return utils.FailureCertificateNotFound
+	case errors.As(err, &identityMismatch):
+		return utils.FailureIdentityMismatch
+	case errors.As(err, &untrustedKey):
+		return utils.FailureUntrustedKey
+	case errors.As(err, &rekorEntryNotFound):
+		return utils.FailureRekorEntryNotFound
+	case errors.As(err, &invalidSignature), errors.Is(err, VerifyError{}):
+		return utils.FailureInvalidSignature
+	case errors.As(err, &packageDownload):
+		return utils.FailurePackageDownload
+	case errors.As(err, &provider):
+		return utils.FailureProvider
+	}
+	return utils.FailureError
+}
+
+// signatureError turns an error of cosign verification into the kind of failed verification it describes.
+// cosign doesn't type its verification errors, so the certificate identity and transparency log failures are
+// recognized by their message.
+func signatureError(err error) error {
+	message := err.Error()
+	var cosignErr *cosign.VerificationError
+	switch {
+	case errors.Is(err, ociremote.ErrImageNotFound):
+		// the signature image of the function image doesn't exist, the image was never signed
+		return SignatureNotFoundError{VerifyError{Err: err}}
+	case errors.Is(err, cosign.ErrNoMatchingSignatures) && strings.HasSuffix(message, cosign.ErrNoMatchingSignatures.Error()+":\n"):
+		// no signature was attached to the image at all
+		return SignatureNotFoundError{VerifyError{Err: err}}
+	case errors.As(err, &cosignErr) && (strings.Contains(message, "not found in certificate") ||
+		strings.Contains(message, "none of the expected identities matched")):
+		return IdentityMismatchError{VerifyError{Err: err}}
+	case strings.Contains(message, "tlog entry") || strings.Contains(message, "tlog entries"):
+		return RekorEntryNotFoundError{VerifyError{Err: err}}
+	}
+	return InvalidSignatureError{VerifyError{Err: err}}
+}
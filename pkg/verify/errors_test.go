@@ -0,0 +1,135 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"github.com/sigstore/cosign/pkg/cosign"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+)
+
+func TestFailureCategory(t *testing.T) {
+	signatureNotFound := SignatureNotFoundError{VerifyError{Err: fmt.Errorf("code verification error: %w", utils.ErrSignatureNotFound)}}
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, ""},
+		{fmt.Errorf("connection refused"), utils.FailureError},
+		{VerifyError{Err: fmt.Errorf("bad")}, utils.FailureInvalidSignature},
+		{signatureNotFound, utils.FailureSignatureNotFound},
+		{fmt.Errorf("%w, changed files compared to identity: abc", signatureNotFound), utils.FailureSignatureNotFound},
+		{CertificateNotFoundError{VerifyError{Err: utils.ErrSignatureNotFound}}, utils.FailureCertificateNotFound},
+		{UntrustedKeyError{VerifyError{Err: fmt.Errorf("couldn't find valid public key")}}, utils.FailureUntrustedKey},
+		{PolicyDeniedError{VerifyError{Err: fmt.Errorf("denied by rego policy: %w", signatureNotFound)}}, utils.FailurePolicyDenied},
+		{PackageTypeError{VerifyError{Err: fmt.Errorf("package type: Zip is not allowed")}}, utils.FailurePackageType},
+		{PackageDownloadError{Err: fmt.Errorf("failed to fetch function code")}, utils.FailurePackageDownload},
+		{ProviderError{Err: fmt.Errorf("failed to resolve package type")}, utils.FailureProvider},
+	}
+	for _, test := range tests {
+		if category := FailureCategory(test.err); category != test.expected {
+			t.Errorf("Error. error: %v, expected category: %s, got: %s", test.err, test.expected, category)
+		}
+	}
+}
+
+func TestSignatureError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{fmt.Errorf("verifying abc: invalid signature when validating ASN.1 encoded signature"), utils.FailureInvalidSignature},
+		{fmt.Errorf("verifying abc: validating cert: %w", &cosign.VerificationError{}), utils.FailureInvalidSignature},
+		{fmt.Errorf("verifying abc: validating cert: expected email not found in certificate: %w", &cosign.VerificationError{}), utils.FailureIdentityMismatch},
+		{fmt.Errorf("verifying abc: could not find a tlog entry for provided blob"), utils.FailureRekorEntryNotFound},
+		{fmt.Errorf("image verification error: %w:\n", cosign.ErrNoMatchingSignatures), utils.FailureSignatureNotFound},
+		{fmt.Errorf("image verification error: %w:\nfailed to verify signature", cosign.ErrNoMatchingSignatures), utils.FailureInvalidSignature},
+		{fmt.Errorf("image verification error: %w", ociremote.ErrImageNotFound), utils.FailureSignatureNotFound},
+	}
+	for _, test := range tests {
+		err := signatureError(test.err)
+		if category := FailureCategory(err); category != test.expected {
+			t.Errorf("Error. error: %v, expected category: %s, got: %s", test.err, test.expected, category)
+		}
+		if !errors.Is(err, VerifyError{}) {
+			t.Errorf("Error. expected a failed verification, got: %v", err)
+		}
+	}
+}
+
+func TestVerifyUnsignedCode(t *testing.T) {
+	codePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(codePath, "main.py"), []byte("print('hello')"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	o := &options.VerifyOpts{}
+	o.Key = "cosign.pub"
+	result, err := Verify(clients.NewLocalClient(), clients.NewFileSignatureStore(t.TempDir()), codePath, o, context.Background(), "", "", nil, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verified || result.FailureCategory != utils.FailureSignatureNotFound {
+		t.Errorf("Error. expected unsigned code, got: %+v", result)
+	}
+}
+
+type layersLocalClient struct {
+	*clients.LocalClient
+	layers []string
+}
+
+func (c *layersLocalClient) GetFuncLayers(funcIdentifier string) ([]string, error) {
+	return c.layers, nil
+}
+
+func (c *layersLocalClient) GetLayerCode(layerVersionArn string) (string, error) {
+	return c.GetFuncCode(layerVersionArn)
+}
+
+func TestVerifyUnsignedLayers(t *testing.T) {
+	codePath := t.TempDir()
+	layerPath := t.TempDir()
+	for _, path := range []string{codePath, layerPath} {
+		if err := os.WriteFile(filepath.Join(path, "main.py"), []byte("print('"+path+"')"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := &layersLocalClient{LocalClient: clients.NewLocalClient(), layers: []string{layerPath, filepath.Join(layerPath, "missing")}}
+	o := &options.VerifyOpts{}
+	o.Key = "cosign.pub"
+	result, err := Verify(client, clients.NewFileSignatureStore(t.TempDir()), codePath, o, context.Background(), "", "", nil, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Layers) != 2 {
+		t.Fatalf("Error. expected results of 2 layers, got: %+v", result.Layers)
+	}
+	if result.Layers[0].Verified || result.Layers[0].FailureCategory != utils.FailureSignatureNotFound || result.Layers[0].Identity == "" {
+		t.Errorf("Error. expected unsigned layer, got: %+v", result.Layers[0])
+	}
+	if result.Layers[1].Verified || result.Layers[1].FailureCategory != utils.FailurePackageDownload {
+		t.Errorf("Error. expected layer download failure, got: %+v", result.Layers[1])
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormats are the formats a verification result can be written in.
+var OutputFormats = []string{"json", "yaml", "table"}
+
+type VerificationResult struct {
+	Function string `json:"function" yaml:"function"`
+	// FunctionArn is the ARN of a lambda, or the resource name of a function on other providers
+	FunctionArn        string  `json:"functionArn,omitempty" yaml:"functionArn,omitempty"`
+	AccountId          string  `json:"accountId,omitempty" yaml:"accountId,omitempty"`
+	Region             string  `json:"region,omitempty" yaml:"region,omitempty"`
+	PackageType        string  `json:"packageType,omitempty" yaml:"packageType,omitempty"`
+	Identity           string  `json:"identity,omitempty" yaml:"identity,omitempty"`
+	Verified           bool    `json:"verified" yaml:"verified"`
+	Skipped            bool    `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	SkipReason         string  `json:"skipReason,omitempty" yaml:"skipReason,omitempty"`
+	PolicyRule         string  `json:"policyRule,omitempty" yaml:"policyRule,omitempty"`
+	Key                string  `json:"key,omitempty" yaml:"key,omitempty"`
+	CertificateSubject string  `json:"certificateSubject,omitempty" yaml:"certificateSubject,omitempty"`
+	CertificateIssuer  string  `json:"certificateIssuer,omitempty" yaml:"certificateIssuer,omitempty"`
+	RekorLogIndex      *int64  `json:"rekorLogIndex,omitempty" yaml:"rekorLogIndex,omitempty"`
+	FailureCategory    string  `json:"failureCategory,omitempty" yaml:"failureCategory,omitempty"`
+	Error              string  `json:"error,omitempty" yaml:"error,omitempty"`
+	Action             string  `json:"action,omitempty" yaml:"action,omitempty"`
+	Notified           bool    `json:"notified,omitempty" yaml:"notified,omitempty"`
+	Timings            Timings `json:"timings" yaml:"timings"`
+	// Layers holds the verification results of the layer versions attached to the function
+	Layers []LayerResult `json:"layers,omitempty" yaml:"layers,omitempty"`
+}
+
+type LayerResult struct {
+	Arn                string `json:"arn" yaml:"arn"`
+	Identity           string `json:"identity,omitempty" yaml:"identity,omitempty"`
+	Verified           bool   `json:"verified" yaml:"verified"`
+	Key                string `json:"key,omitempty" yaml:"key,omitempty"`
+	CertificateSubject string `json:"certificateSubject,omitempty" yaml:"certificateSubject,omitempty"`
+	FailureCategory    string `json:"failureCategory,omitempty" yaml:"failureCategory,omitempty"`
+	Error              string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type Timings struct {
+	Start          time.Time `json:"start" yaml:"start"`
+	VerificationMs int64     `json:"verificationMs" yaml:"verificationMs"`
+	ActionMs       int64     `json:"actionMs" yaml:"actionMs"`
+	TotalMs        int64     `json:"totalMs" yaml:"totalMs"`
+}
+
+// Write writes the result in one of the output formats: json, yaml or table.
+func (r *VerificationResult) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(r)
+	case "table":
+		return r.writeTable(w)
+	}
+	return fmt.Errorf("unsupported output format: %s, expected one of: %s", format, strings.Join(OutputFormats, ", "))
+}
+
+func (r *VerificationResult) writeTable(w io.Writer) error {
+	rows := [][2]string{
+		{"FUNCTION", r.Function},
+		{"FUNCTION ARN", r.FunctionArn},
+		{"ACCOUNT", r.AccountId},
+		{"REGION", r.Region},
+		{"PACKAGE TYPE", r.PackageType},
+		{"IDENTITY", r.Identity},
+		{"VERIFIED", strconv.FormatBool(r.Verified)},
+		{"SKIP REASON", r.SkipReason},
+		{"POLICY RULE", r.PolicyRule},
+		{"KEY", r.Key},
+		{"CERTIFICATE SUBJECT", r.CertificateSubject},
+		{"CERTIFICATE ISSUER", r.CertificateIssuer},
+		{"FAILURE CATEGORY", r.FailureCategory},
+		{"ERROR", r.Error},
+		{"ACTION", r.Action},
+		{"DURATION", fmt.Sprintf("%dms", r.Timings.TotalMs)},
+	}
+	if r.RekorLogIndex != nil {
+		rows = append(rows, [2]string{"REKOR LOG INDEX", strconv.FormatInt(*r.RekorLogIndex, 10)})
+	}
+	for _, layer := range r.Layers {
+		status := "verified"
+		if !layer.Verified {
+			status = "failed: " + layer.FailureCategory
+		}
+		rows = append(rows, [2]string{"LAYER " + layer.Arn, status})
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		if row[1] != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,101 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+func TestVerificationResultWrite(t *testing.T) {
+	logIndex := int64(42)
+	result := &VerificationResult{
+		Function:        "orders",
+		FunctionArn:     "arn:aws:lambda:us-east-1:123456789012:function:orders",
+		PackageType:     "Zip",
+		Identity:        "abc",
+		Verified:        false,
+		RekorLogIndex:   &logIndex,
+		FailureCategory: utils.FailureInvalidSignature,
+		Action:          "block",
+		Layers: []LayerResult{
+			{Arn: "arn:aws:lambda:us-east-1:123456789012:layer:deps:3", Verified: true},
+			{Arn: "arn:aws:lambda:us-east-1:123456789012:layer:tools:1", FailureCategory: utils.FailureSignatureNotFound},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := result.Write(&out, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var fromJson VerificationResult
+	if err := json.Unmarshal(out.Bytes(), &fromJson); err != nil {
+		t.Fatal(err)
+	}
+	if fromJson.FunctionArn != result.FunctionArn || *fromJson.RekorLogIndex != 42 || fromJson.FailureCategory != utils.FailureInvalidSignature ||
+		len(fromJson.Layers) != 2 || !fromJson.Layers[0].Verified {
+		t.Errorf("Error. unexpected json result: %s", out.String())
+	}
+
+	out.Reset()
+	if err := result.Write(&out, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	var fromYaml VerificationResult
+	if err := yaml.Unmarshal(out.Bytes(), &fromYaml); err != nil {
+		t.Fatal(err)
+	}
+	if fromYaml.Identity != "abc" || fromYaml.Action != "block" {
+		t.Errorf("Error. unexpected yaml result: %s", out.String())
+	}
+
+	out.Reset()
+	if err := result.Write(&out, "table"); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []string{"FUNCTION ARN", "VERIFIED", "REKOR LOG INDEX", "42", "invalid-signature", "layer:deps:3 ",
+		"failed: signature-not-found"} {
+		if !strings.Contains(out.String(), row) {
+			t.Errorf("Error. expected table to contain: %s, got: %s", row, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "SKIP REASON") {
+		t.Errorf("Error. expected empty fields to be omitted from table: %s", out.String())
+	}
+
+	if err := result.Write(&out, "xml"); err == nil {
+		t.Errorf("Error. expected unsupported format error")
+	}
+}
+
+func TestVerifySkippedByTagFilter(t *testing.T) {
+	result, err := Verify(clients.NewLocalClient(), clients.NewFileSignatureStore(t.TempDir()), t.TempDir(), &options.VerifyOpts{},
+		context.Background(), "", "", []string{"env"}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Skipped || result.Verified || !strings.Contains(result.SkipReason, "env") || result.Timings.Start.IsZero() {
+		t.Errorf("Error. expected skipped result, got: %+v", result)
+	}
+}
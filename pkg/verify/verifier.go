@@ -17,6 +17,7 @@ package verify
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,50 +26,317 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/openclarity/functionclarity/pkg/clients"
 	"github.com/openclarity/functionclarity/pkg/integrity"
 	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/policy"
+	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
 	v "github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	"github.com/sigstore/cosign/pkg/cosign"
 )
 
-func Verify(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context, action string,
-	topicArn string, tagKeysFilter []string, filteredRegions []string, pathToPublicKeys string, pathToSignatures string) (string, bool, error) {
+// Verify verifies the function and performs the action upon the result. The returned result is never nil, it also
+// describes verifications that failed with an error.
+func Verify(client clients.Client, store clients.SignatureStore, functionIdentifier string, o *options.VerifyOpts, ctx context.Context, action string,
+	topicArn string, tagKeysFilter []string, filteredRegions []string, pathToPublicKeys string, pathToSignatures string) (*VerificationResult, error) {
+	r := &VerificationResult{Function: functionIdentifier, Timings: Timings{Start: time.Now().UTC()}}
+	workDir, err := newWorkDir()
+	if err == nil {
+		defer os.RemoveAll(workDir) //nolint:errcheck
+		err = verifyFunction(client, store, functionIdentifier, o, ctx, action, topicArn, tagKeysFilter, filteredRegions, pathToPublicKeys, pathToSignatures, workDir, r)
+	}
+	if err != nil {
+		r.Error = err.Error()
+		if r.FailureCategory == "" {
+			r.FailureCategory = FailureCategory(err)
+		}
+	}
+	r.Timings.TotalMs = time.Since(r.Timings.Start).Milliseconds()
+	return r, err
+}
+
+// newWorkDir creates the directory a verification downloads its signatures, certificates and manifests to. Functions
+// with the same code have the same identity, so verifications running at the same time must not share their files.
+func newWorkDir() (string, error) {
+	if err := os.MkdirAll(utils.FunctionClarityHomeDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create verification directory: %w", err)
+	}
+	workDir, err := os.MkdirTemp(utils.FunctionClarityHomeDir, "verify-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create verification directory: %w", err)
+	}
+	return workDir, nil
+}
+
+func verifyFunction(client clients.Client, store clients.SignatureStore, functionIdentifier string, o *options.VerifyOpts, ctx context.Context, action string,
+	topicArn string, tagKeysFilter []string, filteredRegions []string, pathToPublicKeys string, pathToSignatures string, workDir string, r *VerificationResult) error {
 
 	if filteredRegions != nil && (len(filteredRegions) > 0) {
-		funcInRegions := client.IsFuncInRegions(filteredRegions)
+		funcInRegions, err := client.IsFuncInRegions(functionIdentifier, filteredRegions)
+		if err != nil {
+			return ProviderError{Err: fmt.Errorf("check function regions: failed to check region of function: %s: %w", functionIdentifier, err)}
+		}
 		if !funcInRegions {
 			fmt.Printf("function: %s not in regions list: %s, skipping validation", functionIdentifier, filteredRegions)
-			return "", false, nil
+			r.Skipped = true
+			r.SkipReason = fmt.Sprintf("function not in included regions: %s", strings.Join(filteredRegions, ","))
+			return nil
 		}
 	}
 
 	if tagKeysFilter != nil && (len(tagKeysFilter) > 0) {
 		funcContainsTag, err := client.FuncContainsTags(functionIdentifier, tagKeysFilter)
 		if err != nil {
-			return "", false, fmt.Errorf("check function tags: failed to check tags of function: %s: %w", functionIdentifier, err)
+			return ProviderError{Err: fmt.Errorf("check function tags: failed to check tags of function: %s: %w", functionIdentifier, err)}
 		}
 		if !funcContainsTag {
 			fmt.Printf("function: %s doesn't contain tag in the list: %s, skipping validation", functionIdentifier, tagKeysFilter)
-			return "", false, nil
+			r.Skipped = true
+			r.SkipReason = fmt.Sprintf("function has none of the included tag keys: %s", strings.Join(tagKeysFilter, ","))
+			return nil
+		}
+	}
+	var rule *policy.Rule
+	var details *functionDetails
+	if o.Policy != nil {
+		var err error
+		if details, err = getFunctionDetails(client, o.Policy, functionIdentifier); err != nil {
+			return ProviderError{Err: err}
+		}
+		r.FunctionArn, r.AccountId, r.Region = details.metadata.FunctionIdentifier, details.metadata.AccountId, details.metadata.Region
+		if rule = o.Policy.Match(details.policyFunction()); rule != nil {
+			r.PolicyRule = rule.Name
+			// a rule without an action keeps the action of the command
+			if rule.Action != "" {
+				action = rule.Action
+			}
+			fmt.Printf("function: %s matched policy rule: %s, action: %s\n", functionIdentifier, rule.Name, action)
+		}
+	} else {
+		metadata := clients.Notification{}
+		if err := client.FillNotificationDetails(&metadata, functionIdentifier); err == nil {
+			r.FunctionArn, r.AccountId, r.Region = metadata.FunctionIdentifier, metadata.AccountId, metadata.Region
 		}
 	}
 	packageType, err := client.ResolvePackageType(functionIdentifier)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to resolve package type for function: %s: %w", functionIdentifier, err)
+		return ProviderError{Err: fmt.Errorf("failed to resolve package type for function: %s: %w", functionIdentifier, err)}
+	}
+	if packageType != "Zip" && packageType != "Image" {
+		return fmt.Errorf("unsupported package type: %s for function: %s", packageType, functionIdentifier)
+	}
+	r.PackageType = packageType
+	verificationStart := time.Now()
+	hash := ""
+	if rule != nil && rule.PackageType != "" && rule.PackageType != packageType {
+		err = PackageTypeError{VerifyError{Err: fmt.Errorf("package type: %s of function: %s is not allowed by policy rule: %s, expected: %s", packageType, functionIdentifier, rule.Name, rule.PackageType)}}
+	} else if rule != nil && len(rule.Signers) > 0 {
+		hash, err = verifyWithSigners(rule.Signers, o, func(signerOpts *options.VerifyOpts) (string, error) {
+			return verifyPackage(client, store, functionIdentifier, packageType, signerOpts, "", pathToSignatures, workDir, ctx, r)
+		})
+	} else {
+		hash, err = verifyPackage(client, store, functionIdentifier, packageType, o, pathToPublicKeys, pathToSignatures, workDir, ctx, r)
 	}
+	r.Identity = hash
+	// layer code runs in the function too, so every attached layer version must be signed as well
+	if layersClient, ok := client.(clients.LayersClient); ok && packageType == "Zip" && (err == nil || errors.Is(err, VerifyError{})) {
+		var signers []policy.Signer
+		if rule != nil {
+			signers = rule.Signers
+		}
+		if layersErr := verifyLayers(layersClient, store, functionIdentifier, signers, o, pathToPublicKeys, pathToSignatures, workDir, ctx, r); err == nil {
+			err = layersErr
+		}
+	}
+	if o.Policy != nil && o.Policy.HasRego() && (err == nil || errors.Is(err, VerifyError{})) {
+		action, err = evaluateRegoPolicy(ctx, o.Policy, details, packageType, hash, workDir, action, err)
+	}
+	r.FailureCategory = FailureCategory(err)
+	r.Timings.VerificationMs = time.Since(verificationStart).Milliseconds()
+	if err != nil && errors.Is(err, VerifyError{}) {
+		r.Error = err.Error()
+	}
+
+	actionStart := time.Now()
+	r.Verified, err = HandleVerification(client, action, functionIdentifier, err, topicArn)
+	r.Timings.ActionMs = time.Since(actionStart).Milliseconds()
+	if r.Verified {
+		r.FailureCategory = ""
+		r.Error = ""
+	}
+	if action != "none" {
+		r.Action = action
+	}
+	r.Notified = !r.Verified && topicArn != "" && err == nil
+	return err
+}
+
+func verifyPackage(client clients.Client, store clients.SignatureStore, functionIdentifier string, packageType string, o *options.VerifyOpts,
+	pathToPublicKeys string, pathToSignatures string, workDir string, ctx context.Context, r *VerificationResult) (string, error) {
+	if packageType == "Image" {
+		return verifyImage(client, store, functionIdentifier, o, pathToPublicKeys, ctx, r)
+	}
+	return verifyCode(client, store, functionIdentifier, o, pathToPublicKeys, pathToSignatures, workDir, ctx, r)
+}
+
+// verifyWithSigners verifies with each of the signers of the matched policy rule, until one of them verifies.
+func verifyWithSigners(signers []policy.Signer, o *options.VerifyOpts, verifyFunc func(signerOpts *options.VerifyOpts) (string, error)) (string, error) {
 	hash := ""
-	switch packageType {
-	case "Zip":
-		hash, err = verifyCode(client, functionIdentifier, o, pathToPublicKeys, pathToSignatures, ctx)
-	case "Image":
-		hash, err = verifyImage(client, functionIdentifier, o, pathToPublicKeys, ctx)
-	default:
-		return "", false, fmt.Errorf("unsupported package type: %s for function: %s", packageType, functionIdentifier)
-	}
-	isVerified, err := HandleVerification(client, action, functionIdentifier, err, topicArn)
-	return hash, isVerified, err
+	var err error
+	for _, signer := range signers {
+		signerOpts := *o
+		signerOpts.Key = signer.Key
+		signerOpts.CertVerify.CertEmail = signer.Email
+		signerOpts.CertVerify.CertOidcIssuer = signer.Issuer
+		signerOpts.CertVerify.CertGithubWorkflowRepository = signer.GithubWorkflowRepository
+		if signer.IsKeyless() {
+			restore := enableExperimentalEnv()
+			hash, err = verifyFunc(&signerOpts)
+			restore()
+		} else {
+			hash, err = verifyFunc(&signerOpts)
+		}
+		if err == nil || !errors.Is(err, VerifyError{}) {
+			return hash, err
+		}
+	}
+	return hash, err
+}
+
+// enableExperimentalEnv enables cosign keyless verification, and returns a function restoring the previous environment.
+func enableExperimentalEnv() func() {
+	previous, isSet := os.LookupEnv(integrity.ExperimentalEnv)
+	os.Setenv(integrity.ExperimentalEnv, "1") //nolint:errcheck
+	return func() {
+		if isSet {
+			os.Setenv(integrity.ExperimentalEnv, previous) //nolint:errcheck
+		} else {
+			os.Unsetenv(integrity.ExperimentalEnv) //nolint:errcheck
+		}
+	}
+}
+
+type functionDetails struct {
+	metadata clients.Notification
+	tags     map[string]string
+}
+
+func (d *functionDetails) policyFunction() policy.Function {
+	return policy.Function{Name: d.metadata.FunctionName, AccountId: d.metadata.AccountId, Region: d.metadata.Region, Tags: d.tags}
+}
+
+// getFunctionDetails returns the function metadata, and its tags when needed to evaluate the policy.
+func getFunctionDetails(client clients.Client, p *policy.Policy, functionIdentifier string) (*functionDetails, error) {
+	details := &functionDetails{}
+	if err := client.FillNotificationDetails(&details.metadata, functionIdentifier); err != nil {
+		return nil, fmt.Errorf("failed to evaluate policy for function: %s: %w", functionIdentifier, err)
+	}
+	if p.UsesTags() || p.HasRego() {
+		tags, err := client.GetFuncTags(functionIdentifier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate policy for function: %s: failed to get function tags: %w", functionIdentifier, err)
+		}
+		details.tags = tags
+	}
+	return details, nil
+}
+
+// evaluateRegoPolicy evaluates the rego module of the policy over the verification result. The decision of the
+// module replaces the result, and the action it defines replaces the action.
+func evaluateRegoPolicy(ctx context.Context, p *policy.Policy, details *functionDetails, packageType string, identity string,
+	workDir string, action string, verifyErr error) (string, error) {
+	input := policy.Input{
+		Function: policy.FunctionMetadata{
+			AccountId:          details.metadata.AccountId,
+			FunctionName:       details.metadata.FunctionName,
+			FunctionIdentifier: details.metadata.FunctionIdentifier,
+			Region:             details.metadata.Region,
+		},
+		Tags:        details.tags,
+		PackageType: packageType,
+		Identity:    identity,
+		Verified:    verifyErr == nil,
+	}
+	if verifyErr != nil {
+		input.Error = verifyErr.Error()
+	} else {
+		input.Certificate = verifiedCertificate(packageType, identity, workDir)
+	}
+	decision, err := p.Evaluate(ctx, input)
+	if err != nil {
+		return action, err
+	}
+	fmt.Printf("rego policy decision. allow: %t, reasons: %v, action: %s\n", decision.Allow, decision.Reasons, decision.Action)
+	if decision.Action != "" {
+		action = decision.Action
+	}
+	if decision.Allow {
+		return action, nil
+	}
+	reasons := "denied by rego policy"
+	if len(decision.Reasons) > 0 {
+		reasons = "denied by rego policy: " + strings.Join(decision.Reasons, ", ")
+	}
+	if verifyErr != nil {
+		return action, PolicyDeniedError{VerifyError{Err: fmt.Errorf("%s: %w", reasons, verifyErr)}}
+	}
+	return action, PolicyDeniedError{VerifyError{Err: errors.New(reasons)}}
+}
+
+// rekorLogIndex returns the index of the transparency log entry of the keyless signature of the identity, if found.
+func rekorLogIndex(ctx context.Context, rekorURL string, identity string, payload string, workDir string) *int64 {
+	if rekorURL == "" {
+		return nil
+	}
+	signature, err := os.ReadFile(filepath.Join(workDir, identity+".sig"))
+	if err != nil {
+		return nil
+	}
+	certificate, err := os.ReadFile(filepath.Join(workDir, identity+".crt.base64"))
+	if err != nil {
+		return nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(certificate))); err == nil {
+		certificate = decoded
+	}
+	rekorClient, err := rekor.NewClient(rekorURL)
+	if err != nil {
+		fmt.Printf("failed to create rekor client: %v\n", err)
+		return nil
+	}
+	entries, err := cosign.FindTlogEntry(ctx, rekorClient, strings.TrimSpace(string(signature)), []byte(payload), certificate)
+	if err != nil {
+		fmt.Printf("failed to find transparency log entry of identity: %s: %v\n", identity, err)
+		return nil
+	}
+	var logIndex *int64
+	for _, entry := range entries {
+		if entry.LogIndex != nil && (logIndex == nil || *entry.LogIndex < *logIndex) {
+			logIndex = entry.LogIndex
+		}
+	}
+	return logIndex
+}
+
+// verifiedCertificate returns the claims of the keyless certificate the code identity was verified with, if any.
+func verifiedCertificate(packageType string, identity string, workDir string) *policy.Certificate {
+	if packageType != "Zip" {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(workDir, identity+".crt.base64"))
+	if err != nil {
+		return nil
+	}
+	certificate, err := policy.ParseCertificate(content)
+	if err != nil {
+		fmt.Printf("failed to parse certificate of identity: %s: %v\n", identity, err)
+		return nil
+	}
+	return certificate
 }
 
 func HandleVerification(client clients.Client, action string, funcIdentifier string, err error, topicArn string) (bool, error) {
@@ -76,21 +344,27 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 		return false, err
 	}
 	isVerified := err == nil
+	failureCategory := FailureCategory(err)
 
 	fmt.Printf("verification result. verified: %t\n", isVerified)
+	if !isVerified {
+		fmt.Printf("verification failure category: %s\n", failureCategory)
+	}
 
 	var e error
 	switch action {
-	case "":
+	case "", "none":
 		fmt.Printf("no action defined, nothing to do\n")
+	case "notify":
+		fmt.Printf("notify action defined, the function is left as is\n")
 	case "detect":
-		e = client.HandleDetect(&funcIdentifier, !isVerified)
+		e = client.HandleDetect(&funcIdentifier, failureCategory)
 		if e != nil {
 			e = fmt.Errorf("handleVerification failed on function indication: %w", e)
 		}
 	case "block":
 		{
-			e = client.HandleDetect(&funcIdentifier, !isVerified)
+			e = client.HandleDetect(&funcIdentifier, failureCategory)
 			if e != nil {
 				e = fmt.Errorf("handleVerification failed on function indication: %w", e)
 				break
@@ -110,6 +384,8 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 			return false, err
 		}
 		notification.Action = action
+		notification.FailureCategory = failureCategory
+		notification.Unsigned = utils.IsUnsignedFailure(failureCategory)
 		msg, err := json.Marshal(notification)
 		if err != nil {
 			return false, err
@@ -120,14 +396,15 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 	return isVerified, e
 }
 
-func verifyImage(client clients.Client, functionIdentifier string, o *options.VerifyOpts, pathToPublicKeys string, ctx context.Context) (string, error) {
+func verifyImage(client clients.Client, store clients.SignatureStore, functionIdentifier string, o *options.VerifyOpts, pathToPublicKeys string, ctx context.Context,
+	r *VerificationResult) (string, error) {
 	funcHash, err := client.GetFuncHash(functionIdentifier)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch function hash for function: %s: %w", functionIdentifier, err)
+		return "", ProviderError{Err: fmt.Errorf("failed to fetch function hash for function: %s: %w", functionIdentifier, err)}
 	}
 	imageURI, err := client.GetFuncImageURI(functionIdentifier)
 	if err != nil {
-		return funcHash, fmt.Errorf("failed to fetch function image URI for function: %s: %w", functionIdentifier, err)
+		return funcHash, ProviderError{Err: fmt.Errorf("failed to fetch function image URI for function: %s: %w", functionIdentifier, err)}
 	}
 
 	annotations, err := o.AnnotationsMap()
@@ -165,61 +442,315 @@ func verifyImage(client clients.Client, functionIdentifier string, o *options.Ve
 		LocalImage:                   o.LocalImage,
 	}
 	if pathToPublicKeys != "" {
-		err = verifyMultipleKeys(client, pathToPublicKeys, o, "", ctx, false, []string{imageURI}, nil, &vc)
+		r.Key, err = verifyMultipleKeys(store, pathToPublicKeys, o, "", ctx, false, []string{imageURI}, nil, &vc)
 		if err != nil {
 			return funcHash, err
 		}
 	} else {
 		if err = vc.Exec(ctx, []string{imageURI}); err != nil {
-			return funcHash, VerifyError{Err: fmt.Errorf("image verification error: %w", err)}
+			return funcHash, signatureError(fmt.Errorf("image verification error: %w", err))
 		}
+		r.Key = o.Key
 	}
 	return funcHash, nil
 }
 
-func verifyCode(client clients.Client, functionIdentifier string, o *options.VerifyOpts, pathToPublicKeys string, pathToSignatures string, ctx context.Context) (string, error) {
+func verifyCode(client clients.Client, store clients.SignatureStore, functionIdentifier string, o *options.VerifyOpts, pathToPublicKeys string, pathToSignatures string,
+	workDir string, ctx context.Context, r *VerificationResult) (string, error) {
 	codePath, err := client.GetFuncCode(functionIdentifier)
 	defer utils.CleanDirectory(codePath)
 	if err != nil {
-		return "", fmt.Errorf("verify code: failed to fetch function code for function: %s: %w", functionIdentifier, err)
-	}
-	integrityCalculator := integrity.Sha256{}
-	functionIdentity, err := integrityCalculator.GenerateIdentity(codePath)
-	if err != nil {
-		return "", fmt.Errorf("verify code: failed to generate function identity for function: %s: %w", functionIdentifier, err)
+		return "", PackageDownloadError{Err: fmt.Errorf("verify code: failed to fetch function code for function: %s: %w", functionIdentifier, err)}
 	}
+	return verifyCodePath(store, functionIdentifier, codePath, o, pathToPublicKeys, pathToSignatures, workDir, ctx, r)
+}
 
+// verifyCodePath verifies the code extracted to the path, the code of a function or of one of its layers.
+func verifyCodePath(store clients.SignatureStore, functionIdentifier string, codePath string, o *options.VerifyOpts, pathToPublicKeys string,
+	pathToSignatures string, workDir string, ctx context.Context, r *VerificationResult) (string, error) {
 	isKeyless := false
 	if !o.SecurityKey.Use && o.Key == "" && o.BundlePath == "" && pathToPublicKeys == "" && integrity.IsExperimentalEnv() {
 		isKeyless = true
 	}
-	if err = downloadSignatureAndCertificate(client, functionIdentifier, functionIdentity, isKeyless, pathToSignatures); err != nil {
+	functionIdentity, err := findSignedIdentity(store, functionIdentifier, codePath, o.IdentityVersion, isKeyless, pathToSignatures, workDir)
+	if err != nil {
+		if errors.Is(err, VerifyError{}) {
+			err = describeChangedFiles(store, functionIdentifier, codePath, o, pathToPublicKeys, pathToSignatures, workDir, isKeyless, ctx, err)
+		}
+		return functionIdentity, err
+	}
+	payload, signedManifest, err := signedPayload(store, functionIdentity, pathToSignatures, workDir)
+	if err != nil {
 		return functionIdentity, err
 	}
+	verifyIdentity := func(identity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+		return verify.VerifyPayload(payload, identity, workDir, o, ctx, isKeyless)
+	}
+	key := o.Key
 	if pathToPublicKeys != "" {
-		err = verifyMultipleKeys(client, pathToPublicKeys, o, functionIdentity, ctx, isKeyless, nil, verify.VerifyIdentity, nil)
+		key, err = verifyMultipleKeys(store, pathToPublicKeys, o, functionIdentity, ctx, isKeyless, nil, verifyIdentity, nil)
 		if err != nil {
 			return functionIdentity, err
 		}
 	} else {
-		if err = verify.VerifyIdentity(functionIdentity, o, ctx, isKeyless); err != nil {
-			return functionIdentity, VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+		if err = verifyIdentity(functionIdentity, o, ctx, isKeyless); err != nil {
+			return functionIdentity, signatureError(fmt.Errorf("code verification error: %w", err))
+		}
+	}
+	if signedManifest != nil && signedManifest.Identity != functionIdentity {
+		return functionIdentity, InvalidSignatureError{VerifyError{Err: fmt.Errorf("code verification error: the signed manifest is of identity: %s", signedManifest.Identity)}}
+	}
+	if err = verifyIgnoreRules(functionIdentifier, codePath, functionIdentity, signedManifest); err != nil {
+		return functionIdentity, err
+	}
+	if isKeyless {
+		if certificate := verifiedCertificate("Zip", functionIdentity, workDir); certificate != nil {
+			r.CertificateSubject, r.CertificateIssuer = certificate.Subject, certificate.Issuer
 		}
+		r.RekorLogIndex = rekorLogIndex(ctx, o.Rekor.URL, functionIdentity, payload, workDir)
+	} else {
+		r.Key = key
 	}
 
 	return functionIdentity, nil
 }
 
-func verifyMultipleKeys(client clients.Client, pathToPublicKeys string, o *options.VerifyOpts, functionIdentity string,
+// verifyLayers verifies the code of each layer version attached to the function against its own signature, with
+// the signers of the matched policy rule, or else with the keys of the function. It returns the first failure.
+func verifyLayers(client clients.LayersClient, store clients.SignatureStore, functionIdentifier string, signers []policy.Signer, o *options.VerifyOpts,
+	pathToPublicKeys string, pathToSignatures string, workDir string, ctx context.Context, r *VerificationResult) error {
+	layerArns, err := client.GetFuncLayers(functionIdentifier)
+	if err != nil {
+		return ProviderError{Err: fmt.Errorf("failed to get layers of function: %s: %w", functionIdentifier, err)}
+	}
+	var layersErr error
+	for _, layerArn := range layerArns {
+		layerResult, err := verifyLayer(client, store, layerArn, signers, o, pathToPublicKeys, pathToSignatures, workDir, ctx)
+		fmt.Printf("layer: %s verification result. verified: %t\n", layerArn, layerResult.Verified)
+		r.Layers = append(r.Layers, layerResult)
+		if err != nil && layersErr == nil {
+			layersErr = fmt.Errorf("layer: %s: %w", layerArn, err)
+		}
+	}
+	return layersErr
+}
+
+// VerifyLayer verifies the code of the layer version against its signature, with the signers when given, or else with
+// the keys of the options.
+func VerifyLayer(client clients.LayersClient, store clients.SignatureStore, layerArn string, signers []policy.Signer, o *options.VerifyOpts,
+	pathToPublicKeys string, pathToSignatures string, ctx context.Context) (LayerResult, error) {
+	workDir, err := newWorkDir()
+	if err != nil {
+		return LayerResult{Arn: layerArn, FailureCategory: FailureCategory(err), Error: err.Error()}, err
+	}
+	defer os.RemoveAll(workDir) //nolint:errcheck
+	return verifyLayer(client, store, layerArn, signers, o, pathToPublicKeys, pathToSignatures, workDir, ctx)
+}
+
+func verifyLayer(client clients.LayersClient, store clients.SignatureStore, layerArn string, signers []policy.Signer, o *options.VerifyOpts,
+	pathToPublicKeys string, pathToSignatures string, workDir string, ctx context.Context) (LayerResult, error) {
+	layerResult := LayerResult{Arn: layerArn}
+	codePath, err := client.GetLayerCode(layerArn)
+	defer utils.CleanDirectory(codePath)
+	if err != nil {
+		err = PackageDownloadError{Err: fmt.Errorf("verify code: failed to fetch code of layer: %s: %w", layerArn, err)}
+	} else {
+		// the reference identity is of the function code, it tells nothing about the changes of a layer
+		layerOpts := *o
+		layerOpts.ReferenceIdentity = ""
+		verified := &VerificationResult{}
+		if len(signers) > 0 {
+			layerResult.Identity, err = verifyWithSigners(signers, &layerOpts, func(signerOpts *options.VerifyOpts) (string, error) {
+				return verifyCodePath(store, layerArn, codePath, signerOpts, "", pathToSignatures, workDir, ctx, verified)
+			})
+		} else {
+			layerResult.Identity, err = verifyCodePath(store, layerArn, codePath, &layerOpts, pathToPublicKeys, pathToSignatures, workDir, ctx, verified)
+		}
+		layerResult.Key, layerResult.CertificateSubject = verified.Key, verified.CertificateSubject
+	}
+	layerResult.Verified = err == nil
+	if err != nil {
+		layerResult.FailureCategory = FailureCategory(err)
+		layerResult.Error = err.Error()
+	}
+	return layerResult, err
+}
+
+// signedPayload returns the payload the identity was signed with: its manifest, which holds the identity, or the bare
+// identity for code signed without a manifest. The manifest is nil for a bare identity.
+func signedPayload(store clients.SignatureStore, identity string, pathToSignatures string, workDir string) (string, *integrity.Manifest, error) {
+	manifestName := identity + integrity.ManifestSuffix
+	if err := store.DownloadSignature(manifestName, "json", pathToSignatures, workDir); err != nil {
+		if errors.Is(err, utils.ErrSignatureNotFound) {
+			return identity, nil, nil
+		}
+		return "", nil, ProviderError{Err: fmt.Errorf("verify code: failed to get manifest of identity: %s: %w", identity, err)}
+	}
+	content, err := os.ReadFile(filepath.Join(workDir, manifestName+".json"))
+	if err != nil {
+		return "", nil, err
+	}
+	manifest, err := integrity.ParseManifest(content)
+	if err != nil {
+		return "", nil, InvalidSignatureError{VerifyError{Err: fmt.Errorf("code verification error: %w", err)}}
+	}
+	return string(content), manifest, nil
+}
+
+// verifyIgnoreRules makes sure the files excluded from the identity are the ones excluded when the code was signed,
+// as recorded in the signed manifest of the identity.
+func verifyIgnoreRules(functionIdentifier string, codePath string, functionIdentity string, signedManifest *integrity.Manifest) error {
+	ignoreRules, err := integrity.LoadIgnoreRules(codePath)
+	if err != nil {
+		return fmt.Errorf("verify code: failed to load ignore rules for function: %s: %w", functionIdentifier, err)
+	}
+	if ignoreRules.IsEmpty() {
+		return nil
+	}
+	if signedManifest == nil {
+		return InvalidSignatureError{VerifyError{Err: fmt.Errorf("code verification error: failed to validate %s rules: identity: %s was signed without a manifest", integrity.IgnoreFileName, functionIdentity)}}
+	}
+	if !reflect.DeepEqual(signedManifest.Ignore, ignoreRules.Patterns) {
+		return InvalidSignatureError{VerifyError{Err: fmt.Errorf("code verification error: %s rules don't match the rules signed with identity: %s", integrity.IgnoreFileName, functionIdentity)}}
+	}
+	return nil
+}
+
+// describeChangedFiles adds to the verification error the files that changed compared to the signed manifest of
+// the reference identity, or else of the code last signed for the function.
+func describeChangedFiles(store clients.SignatureStore, functionIdentifier string, codePath string, o *options.VerifyOpts, pathToPublicKeys string,
+	pathToSignatures string, workDir string, isKeyless bool, ctx context.Context, verifyErr error) error {
+	manifestName := integrity.FunctionManifestName(functionIdentifier)
+	if o.ReferenceIdentity != "" {
+		manifestName = o.ReferenceIdentity
+	}
+	signedManifest, err := downloadSignedManifest(store, functionIdentifier, manifestName, o, pathToPublicKeys, pathToSignatures, workDir, isKeyless, ctx)
+	if err != nil {
+		// code signed without a function name has no manifest to compare with
+		if o.ReferenceIdentity != "" || !errors.Is(err, SignatureNotFoundError{}) {
+			fmt.Printf("failed to compare function code with the signed manifest: %s: %v\n", manifestName, err)
+		}
+		return verifyErr
+	}
+	actualManifest, err := integrity.GenerateManifest(codePath, "")
+	if err != nil {
+		fmt.Printf("failed to compare function code with the manifest of identity: %s: %v\n", signedManifest.Identity, err)
+		return verifyErr
+	}
+	diff := integrity.DiffManifests(signedManifest, actualManifest)
+	fmt.Printf("function code changed compared to identity: %s: %s\n", signedManifest.Identity, diff)
+	return fmt.Errorf("%w, changed files compared to identity: %s: %s", verifyErr, signedManifest.Identity, diff)
+}
+
+// downloadSignedManifest downloads the manifest stored under the name, an identity or a function manifest name, and
+// verifies its signature.
+func downloadSignedManifest(store clients.SignatureStore, functionIdentifier string, name string, o *options.VerifyOpts, pathToPublicKeys string,
+	pathToSignatures string, workDir string, isKeyless bool, ctx context.Context) (*integrity.Manifest, error) {
+	manifestName := name + integrity.ManifestSuffix
+	if err := store.DownloadSignature(manifestName, "json", pathToSignatures, workDir); err != nil {
+		if errors.Is(err, utils.ErrSignatureNotFound) {
+			return nil, SignatureNotFoundError{VerifyError{Err: fmt.Errorf("failed to get manifest: %w", err)}}
+		}
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	if err := downloadSignatureAndCertificate(store, functionIdentifier, name, isKeyless, pathToSignatures, workDir); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(workDir, manifestName+".json"))
+	if err != nil {
+		return nil, err
+	}
+	verifyManifest := func(_ string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+		return verify.VerifyPayload(string(content), name, workDir, o, ctx, isKeyless)
+	}
+	// the keys of the function verification are kept as is
+	manifestOpts := *o
+	if pathToPublicKeys != "" {
+		_, err = verifyMultipleKeys(store, pathToPublicKeys, &manifestOpts, "", ctx, isKeyless, nil, verifyManifest, nil)
+	} else {
+		err = verifyManifest("", &manifestOpts, ctx, isKeyless)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manifest signature verification failed: %w", err)
+	}
+	return integrity.ParseManifest(content)
+}
+
+// findSignedIdentity generates the code identity with each of the identity versions, until one with a stored
+// signature is found. The version the function was last signed with is tried first, then the default version, so
+// the code is mostly hashed once.
+func findSignedIdentity(store clients.SignatureStore, functionIdentifier string, codePath string, identityVersion string, isKeyless bool, pathToSignatures string,
+	workDir string) (string, error) {
+	versions := []string{identityVersion}
+	if identityVersion == "" {
+		versions = identityVersionsToTry(signedIdentityVersion(store, functionIdentifier, pathToSignatures, workDir))
+	}
+	functionIdentity := ""
+	var err error
+	for _, version := range versions {
+		identityGenerator, e := integrity.GetIdentityGenerator(version)
+		if e != nil {
+			return "", fmt.Errorf("verify code: %w", e)
+		}
+		functionIdentity, err = identityGenerator.GenerateIdentity(codePath)
+		if err != nil {
+			return "", fmt.Errorf("verify code: failed to generate function identity for function: %s: %w", functionIdentifier, err)
+		}
+		err = downloadSignatureAndCertificate(store, functionIdentifier, functionIdentity, isKeyless, pathToSignatures, workDir)
+		if err == nil || !errors.Is(err, VerifyError{}) {
+			return functionIdentity, err
+		}
+	}
+	return functionIdentity, err
+}
+
+// signedIdentityVersion returns the identity version of the code last signed for the function, as recorded in its
+// manifest, or empty if not found. The manifest isn't verified, the version only orders the identities to look up.
+func signedIdentityVersion(store clients.SignatureStore, functionIdentifier string, pathToSignatures string, workDir string) string {
+	manifestName := integrity.FunctionManifestName(functionIdentifier) + integrity.ManifestSuffix
+	if err := store.DownloadSignature(manifestName, "json", pathToSignatures, workDir); err != nil {
+		return ""
+	}
+	content, err := os.ReadFile(filepath.Join(workDir, manifestName+".json"))
+	if err != nil {
+		return ""
+	}
+	manifest, err := integrity.ParseManifest(content)
+	if err != nil || manifest.Identity == "" {
+		return ""
+	}
+	return integrity.ParseIdentityVersion(manifest.Identity)
+}
+
+// identityVersionsToTry returns the signed identity version if supported, then the default version, then the other
+// versions newest first.
+func identityVersionsToTry(signedVersion string) []string {
+	var first, rest []string
+	for _, version := range integrity.IdentityVersions() {
+		switch version {
+		case signedVersion:
+			first = append([]string{version}, first...)
+		case integrity.DefaultIdentityVersion:
+			first = append(first, version)
+		default:
+			rest = append(rest, version)
+		}
+	}
+	return append(first, rest...)
+}
+
+// verifyMultipleKeys verifies with each of the public keys in the path until one is valid, and returns its path.
+func verifyMultipleKeys(store clients.SignatureStore, pathToPublicKeys string, o *options.VerifyOpts, functionIdentity string,
 	ctx context.Context, isKeyless bool, images []string,
 	codeValidationFunc func(identity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) error,
-	verifyCommand *v.VerifyCommand) error {
+	verifyCommand *v.VerifyCommand) (string, error) {
 
-	publicKeysFolder, err := client.DownloadPublicKeys(pathToPublicKeys)
+	publicKeysFolder, err := store.DownloadPublicKeys(pathToPublicKeys)
 	defer utils.CleanDirectory(publicKeysFolder)
 	if err != nil {
-		return fmt.Errorf("code verification error: %w", err)
+		return "", ProviderError{Err: fmt.Errorf("code verification error: %w", err)}
 	}
+	validKey := ""
 	err = filepath.Walk(publicKeysFolder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -233,33 +764,37 @@ func verifyMultipleKeys(client clients.Client, pathToPublicKeys string, o *optio
 				err = verifyCommand.Exec(ctx, images)
 			}
 			if err == nil {
+				validKey = strings.TrimSuffix(pathToPublicKeys, "/") + "/" + strings.TrimPrefix(strings.TrimPrefix(path, publicKeysFolder), "/")
 				return io.EOF
 			}
 		}
 		return nil
 	})
 	if err != nil && err != io.EOF {
-		return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+		return "", VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
 	}
 	if err == nil {
-		return VerifyError{Err: fmt.Errorf("couldn't find valid public key")}
+		return "", UntrustedKeyError{VerifyError{Err: fmt.Errorf("couldn't find valid public key")}}
 	}
-	return nil
+	return validKey, nil
 }
 
-func downloadSignatureAndCertificate(client clients.Client, functionIdentifier string, functionIdentity string, isKeyless bool, pathToSignatures string) error {
-	if err := client.DownloadSignature(functionIdentity, "sig", pathToSignatures); err != nil {
-		if strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
-			return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+func downloadSignatureAndCertificate(store clients.SignatureStore, functionIdentifier string, functionIdentity string, isKeyless bool, pathToSignatures string,
+	workDir string) error {
+	if err := store.DownloadSignature(functionIdentity, "sig", pathToSignatures, workDir); err != nil {
+		if errors.Is(err, utils.ErrSignatureNotFound) {
+			return SignatureNotFoundError{VerifyError{Err: fmt.Errorf("code verification error: %w", err)}}
 		}
-		return fmt.Errorf("verify code: failed to get signed identity for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+		return ProviderError{Err: fmt.Errorf("verify code: failed to get signed identity for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)}
 	}
+	// a certificate left from a previous verification must not be taken for the certificate of this one
+	os.Remove(filepath.Join(workDir, functionIdentity+".crt.base64")) //nolint:errcheck
 	if isKeyless {
-		if err := client.DownloadSignature(functionIdentity, "crt.base64", pathToSignatures); err != nil {
-			if strings.Contains(err.Error(), utils.FunctionClaritySignatureNotFoundMessage) {
-				return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+		if err := store.DownloadSignature(functionIdentity, "crt.base64", pathToSignatures, workDir); err != nil {
+			if errors.Is(err, utils.ErrSignatureNotFound) {
+				return CertificateNotFoundError{VerifyError{Err: fmt.Errorf("code verification error: %w", err)}}
 			}
-			return fmt.Errorf("verify code: failed to get certificate for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
+			return ProviderError{Err: fmt.Errorf("verify code: failed to get certificate for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)}
 		}
 	}
 	return nil
@@ -0,0 +1,308 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openclarity/functionclarity/pkg/clients"
+	"github.com/openclarity/functionclarity/pkg/integrity"
+	"github.com/openclarity/functionclarity/pkg/options"
+	"github.com/openclarity/functionclarity/pkg/policy"
+)
+
+// signIdentity signs the identity with a new key, saves the signature to the signatures dir and returns the path of the public key.
+func signIdentity(t *testing.T, identity string, signaturesDir string) string {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signPayload(t, privateKey, identity, identity, signaturesDir)
+	return publicKeyPath(t, privateKey)
+}
+
+// signPayload signs the payload with the key and saves the signature under the name to the signatures dir.
+func signPayload(t *testing.T, privateKey *ecdsa.PrivateKey, payload string, name string, signaturesDir string) {
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(signaturesDir, name+".sig"), []byte(base64.StdEncoding.EncodeToString(signature)), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func publicKeyPath(t *testing.T, privateKey *ecdsa.PrivateKey) string {
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	if err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath
+}
+
+// signManifest signs the manifest of the code with a new key and saves it under both the identity and the function
+// name, as the signer does, and returns the path of the public key.
+func signManifest(t *testing.T, codePath string, identity string, signaturesDir string) string {
+	manifest, err := integrity.GenerateManifest(codePath, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestContent, err := manifest.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{identity, integrity.FunctionManifestName(codePath)} {
+		signPayload(t, privateKey, manifestContent, name, signaturesDir)
+		if err = os.WriteFile(filepath.Join(signaturesDir, name+integrity.ManifestSuffix+".json"), []byte(manifestContent), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return publicKeyPath(t, privateKey)
+}
+
+// recordingStore records the signatures looked up.
+type recordingStore struct {
+	*clients.FileSignatureStore
+	signatures []string
+}
+
+func (s *recordingStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	if outputType == "sig" {
+		s.signatures = append(s.signatures, fileName)
+	}
+	return s.FileSignatureStore.DownloadSignature(fileName, outputType, bucketPathToSignatures, outputDir)
+}
+
+// streamingStore truncates the downloaded file and writes it after a while, as the downloads of the cloud stores do.
+type streamingStore struct {
+	*clients.FileSignatureStore
+}
+
+func (s *streamingStore) DownloadSignature(fileName string, outputType string, bucketPathToSignatures string, outputDir string) error {
+	if err := os.WriteFile(filepath.Join(outputDir, fileName+"."+outputType), nil, 0600); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	return s.FileSignatureStore.DownloadSignature(fileName, outputType, bucketPathToSignatures, outputDir)
+}
+
+func TestVerifySameIdentityConcurrently(t *testing.T) {
+	// two functions with the same code, i.e. the same function deployed in two regions
+	var targets []string
+	for i := 0; i < 2; i++ {
+		codePath := t.TempDir()
+		if err := os.WriteFile(filepath.Join(codePath, "main.py"), []byte("print('hello')"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, codePath)
+	}
+	generator, err := integrity.GetIdentityGenerator(integrity.DefaultIdentityVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(targets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signaturesDir := t.TempDir()
+	keyPath := signIdentity(t, identity, signaturesDir)
+	store := &streamingStore{FileSignatureStore: clients.NewFileSignatureStore(signaturesDir)}
+
+	results := make([]*VerificationResult, 8)
+	errs := make([]error, len(results))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			o := &options.VerifyOpts{}
+			o.Key = keyPath
+			results[i], errs[i] = Verify(clients.NewLocalClient(), store, targets[i%len(targets)], o, context.Background(), "", "", nil, nil, "", "")
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		if errs[i] != nil || !result.Verified || result.Identity != identity {
+			t.Errorf("Error. expected verification: %d to pass, got: %+v: %v", i, result, errs[i])
+		}
+	}
+}
+
+func TestVerifySignedManifest(t *testing.T) {
+	codePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(codePath, "main.py"), []byte("print('hello')"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	generator, err := integrity.GetIdentityGenerator(integrity.DefaultIdentityVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signaturesDir := t.TempDir()
+	keyPath := signManifest(t, codePath, identity, signaturesDir)
+	store := clients.NewFileSignatureStore(signaturesDir)
+
+	o := &options.VerifyOpts{}
+	o.Key = keyPath
+	result, err := Verify(clients.NewLocalClient(), store, codePath, o, context.Background(), "", "", nil, nil, "", "")
+	if err != nil || !result.Verified || result.Identity != identity {
+		t.Fatalf("Error. expected verification to pass, got: %+v: %v", result, err)
+	}
+
+	if err = os.WriteFile(filepath.Join(codePath, "main.py"), []byte("print('bye')"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	o = &options.VerifyOpts{}
+	o.Key = keyPath
+	result, err = Verify(clients.NewLocalClient(), store, codePath, o, context.Background(), "", "", nil, nil, "", "")
+	if err != nil || result.Verified {
+		t.Fatalf("Error. expected verification of the changed code to fail, got: %+v: %v", result, err)
+	}
+	if !strings.Contains(result.Error, "changed files compared to identity: "+identity) || !strings.Contains(result.Error, "modified: main.py") {
+		t.Errorf("Error. expected the changed files in the verification error, got: %s", result.Error)
+	}
+}
+
+func TestVerifyLooksUpSignedIdentityVersion(t *testing.T) {
+	codePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(codePath, "main.py"), []byte("print('hello')"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	generator, err := integrity.GetIdentityGenerator(integrity.IdentityVersionV2Sha512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signaturesDir := t.TempDir()
+	keyPath := signManifest(t, codePath, identity, signaturesDir)
+	store := &recordingStore{FileSignatureStore: clients.NewFileSignatureStore(signaturesDir)}
+
+	o := &options.VerifyOpts{}
+	o.Key = keyPath
+	result, err := Verify(clients.NewLocalClient(), store, codePath, o, context.Background(), "", "", nil, nil, "", "")
+	if err != nil || !result.Verified || result.Identity != identity {
+		t.Fatalf("Error. expected verification to pass, got: %+v: %v", result, err)
+	}
+	if !reflect.DeepEqual(store.signatures, []string{identity}) {
+		t.Errorf("Error. expected only the signature of the identity version of the function to be looked up, got: %v", store.signatures)
+	}
+}
+
+func TestIdentityVersionsToTry(t *testing.T) {
+	tests := []struct {
+		signedVersion string
+		expected      []string
+	}{
+		{signedVersion: "", expected: []string{integrity.IdentityVersionLegacy, integrity.IdentityVersionV2Sha512, integrity.IdentityVersionV2Sha256}},
+		{signedVersion: integrity.IdentityVersionLegacy, expected: []string{integrity.IdentityVersionLegacy, integrity.IdentityVersionV2Sha512, integrity.IdentityVersionV2Sha256}},
+		{signedVersion: integrity.IdentityVersionV2Sha256, expected: []string{integrity.IdentityVersionV2Sha256, integrity.IdentityVersionLegacy, integrity.IdentityVersionV2Sha512}},
+		{signedVersion: "fc-v9:unknown", expected: []string{integrity.IdentityVersionLegacy, integrity.IdentityVersionV2Sha512, integrity.IdentityVersionV2Sha256}},
+	}
+	for _, test := range tests {
+		if versions := identityVersionsToTry(test.signedVersion); !reflect.DeepEqual(versions, test.expected) {
+			t.Errorf("Error. expected versions to try for signed version: %s to be: %v, got: %v", test.signedVersion, test.expected, versions)
+		}
+	}
+}
+
+func TestPolicyMatchesLambdaArn(t *testing.T) {
+	p, err := policy.Parse([]byte(`
+rules:
+  - name: sandbox
+    functions: ["sandbox-*"]
+    accounts: ["123456789012"]
+    regions: ["us-*"]
+    action: detect
+  - name: default
+    action: block
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := clients.NewAwsClient("", "", "us-east-1", "us-east-1")
+	for _, functionArn := range []string{
+		"arn:aws:lambda:us-east-1:123456789012:function:sandbox-orders",
+		"arn:aws:lambda:us-east-1:123456789012:function:sandbox-orders:3",
+		"arn:aws:lambda:us-east-1:123456789012:function:sandbox-orders:live",
+	} {
+		details, err := getFunctionDetails(client, p, functionArn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := details.policyFunction().Name; name != "sandbox-orders" {
+			t.Errorf("Error. expected the bare name of function: %s, got: %s", functionArn, name)
+		}
+		if rule := p.Match(details.policyFunction()); rule == nil || rule.Name != "sandbox" {
+			t.Errorf("Error. expected function: %s to match rule: sandbox, got: %+v", functionArn, rule)
+		}
+	}
+}
+
+func TestPolicyRuleWithoutActionKeepsAction(t *testing.T) {
+	codePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(codePath, "main.py"), []byte("print('hello')"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	generator, err := integrity.GetIdentityGenerator(integrity.DefaultIdentityVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := generator.GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signaturesDir := t.TempDir()
+	keyPath := signIdentity(t, identity, signaturesDir)
+	// the rule only selects the signers
+	p, err := policy.Parse([]byte("rules:\n  - name: signers\n    signers:\n      - key: " + keyPath + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &options.VerifyOpts{Policy: p}
+	result, _ := Verify(clients.NewLocalClient(), clients.NewFileSignatureStore(signaturesDir), codePath, o, context.Background(), "detect", "", nil, nil, "", "")
+	if result.PolicyRule != "signers" || result.Action != "detect" {
+		t.Errorf("Error. expected rule: signers to keep action: detect, got rule: %s, action: %s", result.PolicyRule, result.Action)
+	}
+}
@@ -69,6 +69,7 @@ const (
 )
 
 var awsClient *clients.AwsClient
+var s3Store *clients.S3SignatureStore
 var lambdaClient *lambda.Client
 var formationClient *cloudformation.Client
 var sqsClient *sqs.Client
@@ -113,7 +114,8 @@ func setup() {
 	region = getEnvVar("REGION", "region")
 	lambdaRegion = getEnvVar("FUNCTION_REGION", "function region")
 
-	awsClient = clients.NewAwsClient(accessKey, secretKey, bucket, region, lambdaRegion)
+	awsClient = clients.NewAwsClient(accessKey, secretKey, region, lambdaRegion)
+	s3Store = clients.NewS3SignatureStore(accessKey, secretKey, bucket, "", region)
 
 	cfg := createConfig(region)
 	lambdaClient = lambda.NewFromConfig(*createConfig(lambdaRegion))
@@ -138,7 +140,7 @@ func setup() {
 		configForDeployment.IsKeyless = false
 		configForDeployment.SnsTopicArn = "arn:aws:sns:us-east-1:813189926740:func-clarity-e2e"
 		configForDeployment.IncludedFuncTagKeys = []string{includeFuncTag + suffix}
-		if err := awsClient.DeployFunctionClarity("SecurecnMonitoringTrail", publicKey, configForDeployment, suffix); err != nil {
+		if err := awsClient.DeployFunctionClarity("SecurecnMonitoringTrail", publicKey, "aws_function", configForDeployment, suffix); err != nil {
 			log.Fatal(err)
 		}
 		time.Sleep(2 * time.Minute)
@@ -228,7 +230,7 @@ func TestCodeSignAndVerify(t *testing.T) {
 			Registry:     options.RegistryOptions{},
 		},
 	}
-	err = sign.SignAndUploadCode(awsClient, "utils/testing_lambda", &sbo, ro)
+	err = sign.SignAndUploadCode(s3Store, "utils/testing_lambda", &sbo, ro)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -245,7 +247,7 @@ func TestCodeSignAndVerify(t *testing.T) {
 	}
 	fmt.Println(successTagValue + " tag found in the signed function")
 	deleteLambda(codeFuncNameSigned + suffix)
-	deleteS3BucketContent(&bucket, []string{"function-clarity.zip"})
+	deleteS3BucketContent(&bucket, []string{"function-clarity-"})
 }
 
 func TestImageSignAndVerify(t *testing.T) {
@@ -339,7 +341,7 @@ func TestCodeSignAndVerifyKeyless(t *testing.T) {
 		},
 	}
 
-	err := sign.SignAndUploadCode(awsClient, "utils/testing_lambda", &sbo, ro)
+	err := sign.SignAndUploadCode(s3Store, "utils/testing_lambda", &sbo, ro)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -356,7 +358,7 @@ func TestCodeSignAndVerifyKeyless(t *testing.T) {
 	}
 	fmt.Println(successTagValue + " tag found in the signed function")
 	deleteLambda(codeFuncNameSigned + suffix)
-	deleteS3BucketContent(&bucket, []string{"function-clarity.zip"})
+	deleteS3BucketContent(&bucket, []string{"function-clarity-"})
 }
 
 func findTag(t *testing.T, functionArn string, lambdaClient *lambda.Client, successTagKey string, successTagValue string) (bool, bool) {
@@ -464,6 +466,7 @@ func deleteS3TrailBucketContent() {
 	}
 }
 
+// deleteS3BucketContent deletes the objects of the bucket, except those whose key starts with one of the except prefixes.
 func deleteS3BucketContent(name *string, except []string) {
 	listObjectsV2Response, err := s3Client.ListObjectsV2(context.TODO(),
 		&s3.ListObjectsV2Input{
@@ -476,7 +479,7 @@ func deleteS3BucketContent(name *string, except []string) {
 			log.Fatalf("Couldn't list objects... delete all objects in bucket: %s failed", *name)
 		}
 		for _, item := range listObjectsV2Response.Contents {
-			if !contains(except, *item.Key) {
+			if !hasAnyPrefix(*item.Key, except) {
 				_, err = s3Client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
 					Bucket: name,
 					Key:    item.Key,
@@ -510,6 +513,15 @@ func contains(s []string, str string) bool {
 	return false
 }
 
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func deleteS3Bucket(name string) {
 	if _, err := s3Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{Bucket: aws.String(name)}); err != nil {
 		return
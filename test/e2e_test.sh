@@ -10,8 +10,6 @@ go build testing_lambda.go
 echo "testing lambda built successfully"
 
 cd ../..
-cp ./run_env/utils/unified-template.template ./test/
-echo "stack template copied to test folder"
 
 echo "e2e tests started"
 cd ./test